@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"govtech/pkg/admin"
+	"govtech/pkg/config"
+	"govtech/pkg/server/auth"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/logging"
+)
+
+func main() {
+	program := filepath.Base(os.Args[0])
+
+	// Init config, from the flags before the command.
+	cfg, args, err := config.LoadCommand(program, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		admin.Usage(os.Stderr, program)
+		return
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(admin.EXIT_USAGE)
+	}
+
+	if len(args) == 0 || args[0] == "help" {
+		admin.Usage(os.Stderr, program)
+		if len(args) == 0 {
+			os.Exit(admin.EXIT_USAGE)
+		}
+		return
+	}
+	if !admin.IsCommand(args[0]) {
+		fmt.Fprintf(os.Stderr, "error: unknown command %q\n\n", args[0])
+		admin.Usage(os.Stderr, program)
+		os.Exit(admin.EXIT_USAGE)
+	}
+
+	// Init logger. Only warnings and errors are logged, so that query logs do not clutter the output.
+	slog.SetDefault(logging.NewLogger(os.Stderr, slog.LevelWarn))
+
+	// Init database.
+	dbConfig := database.NewMySqlConfig(cfg.Database)
+	db := database.ConnectDB(&dbConfig)
+	database.InitDB(db)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	cli := &admin.CLI{
+		Service: services.New(database.NewStore(db)),
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+	if cfg.Auth.TokenSecret != "" {
+		cli.Tokens = auth.NewSigner(cfg.Auth.TokenSecret)
+	}
+	code := cli.Run(ctx, args)
+
+	stop()
+	database.DisconnectDB(db)
+	os.Exit(code)
+}
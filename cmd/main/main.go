@@ -1,41 +1,62 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
 
-	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
 
+	"govtech/pkg/config"
+	"govtech/pkg/controllers"
+	"govtech/pkg/events"
+	"govtech/pkg/server/cache"
 	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/gql"
 	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/metrics"
+	"govtech/pkg/server/openapi"
+	"govtech/pkg/server/rpc"
+	"govtech/pkg/server/ws"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/logging"
+	"govtech/pkg/webhooks"
 )
 
-var dbConfig database.MySqlConfig
-var routerConfig handlers.RouterConfig
-
-func init() {
-	// Init env
-	err := godotenv.Load(filepath.Join("..", "..", ".env"))
-	if err != nil {
-		fmt.Println("Failed to load .env file")
+func main() {
+	// Init config.
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
 	}
 
-	dbConfig = database.MySqlConfig{
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASS"),
-		Port:     os.Getenv("DB_PORT"),
-		Host:     os.Getenv("DB_HOST"),
-		Name:     os.Getenv("DB_NAME"),
-	}
+	dbConfig := database.NewMySqlConfig(cfg.Database)
+	routerConfig := handlers.NewRouterConfig(cfg.Router)
+	rateLimitConfig := middlewares.NewRateLimitConfig(cfg.RateLimit)
+	timeoutConfig := middlewares.NewTimeoutConfig(cfg.Router)
+	openAPIConfig := middlewares.NewOpenAPIConfig(cfg.Features)
+	deprecationConfig := middlewares.NewDeprecationConfig(cfg.API, handlers.API_V2_PREFIX)
+	authConfig := middlewares.NewAuthConfig(cfg.Auth)
+	rpcConfig := rpc.NewRPCConfig(cfg.GRPC)
+	graphQLLimits := gql.NewLimits(cfg.GraphQL)
+	webSocketConfig := ws.NewConfig(cfg.WebSocket, cfg.Auth)
+	dispatcherConfig := events.NewDispatcherConfig(cfg.Events)
+	webhooksConfig := webhooks.NewConfig(cfg.Webhooks)
+	cacheConfig := cache.NewConfig(cfg.Cache)
 
-	routerConfig = handlers.RouterConfig{
-		Port: os.Getenv("ROUTER_PORT"),
-		Host: os.Getenv("ROUTER_HOST"),
-	}
-}
+	// Init logger.
+	slog.SetDefault(logging.NewLogger(os.Stdout, logging.ParseLevel(cfg.Log.Level)))
 
-func main() {
 	// Init database.
 	db := database.ConnectDB(&dbConfig)
 	database.InitDB(db)
@@ -44,8 +65,106 @@ func main() {
 	// Init router.
 	r := handlers.InitRouter()
 
-	handlers.RegisterMiddlewares(r, db)
-	handlers.RegisterEndpoints(r, db)
+	// The rate limit store is shared by the router and the gRPC server, so that clients share their limits.
+	rateLimitStore := middlewares.NewMemoryRateLimitStore()
+	if cfg.Features.RateLimit {
+		middlewares.RegisterRateLimitMiddleware(r, &rateLimitConfig, rateLimitStore)
+	}
+	middlewares.RegisterTimeoutMiddleware(r, &timeoutConfig)
+	if cfg.Features.RequestValidation {
+		doc, err := openapi.Load()
+		if err == nil {
+			err = middlewares.RegisterOpenAPIMiddleware(r, doc, &openAPIConfig)
+		}
+		if err != nil {
+			slog.Error("failed to load openapi document", "error", err)
+			os.Exit(1)
+		}
+	}
+	// The service is shared by the router and the gRPC server, so that both publish to the same subscribers.
+	store := database.NewStore(db)
+	service := services.New(store)
+	if cfg.Features.Cache {
+		service.UseCaches(services.NewCaches(cacheConfig))
+	}
+	handlers.RegisterServiceMiddlewares(r, service)
+	middlewares.RegisterAuthMiddleware(r, &authConfig)
+	controllers.UseWebhookPingClient(webhooks.NewClient(webhooks.PING_TIMEOUT, cfg.Webhooks.AllowPrivateNetworks))
+	handlers.RegisterEndpoints(r, db, &deprecationConfig)
+
+	if cfg.Features.Metrics {
+		metrics.RegisterDBStatsCollector(db, dbConfig.Name)
+		metrics.RegisterDeadEventsCollector(store.CountDeadEvents)
+		controllers.RegisterMetricsEndpoint(r)
+	}
+	if cfg.Features.GraphQL {
+		controllers.RegisterGraphQLEndpoint(r, &graphQLLimits)
+	}
+	if cfg.Features.WebSocket {
+		controllers.RegisterTeacherEventsEndpoint(r, &webSocketConfig)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Init dispatcher of the domain events written to the outbox.
+	dispatcher := events.NewDispatcher(store, dispatcherConfig)
+	store.OnEventsWritten(func([]events.Event) { dispatcher.Notify() })
+	dispatcher.Subscribe(services.SUBSCRIBER_TEACHER_EVENTS, service.PublishTeacherEvent)
+	if cfg.Features.Cache {
+		dispatcher.Subscribe(services.SUBSCRIBER_CACHES, service.InvalidateCaches)
+	}
+
+	webhookClient := &http.Client{Timeout: cfg.Events.WebhookTimeout.Duration}
+	for _, v := range cfg.EventWebhooks() {
+		dispatcher.Subscribe(events.WebhookName(v), events.Webhook(webhookClient, v))
+	}
+
+	// Init deliverer of the events to the webhooks registered with the API.
+	deliverer := webhooks.NewDeliverer(store, webhooks.NewClient(cfg.Webhooks.Timeout.Duration, cfg.Webhooks.AllowPrivateNetworks), webhooksConfig)
+	dispatcher.Subscribe(webhooks.SUBSCRIBER, deliverer.Handle)
+
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		dispatcher.Run(ctx)
+	}()
+
+	delivererDone := make(chan struct{})
+	go func() {
+		defer close(delivererDone)
+		deliverer.Run(ctx)
+	}()
+
+	// Init gRPC server, sharing the service layer with the router.
+
+	rpcDone := make(chan struct{})
+	if cfg.Features.GRPC {
+		// Calls have the rate limits and deadlines of the equivalent routes.
+		var interceptors []grpc.UnaryServerInterceptor
+		if cfg.Features.RateLimit {
+			interceptors = append(interceptors, rpc.RateLimitInterceptor(&rateLimitConfig, rateLimitStore))
+		}
+		interceptors = append(interceptors, rpc.TimeoutInterceptor(&timeoutConfig))
+		server := rpc.NewGRPCServer(service, grpc.ChainUnaryInterceptor(interceptors...))
+
+		go func() {
+			defer close(rpcDone)
+			if err := rpc.Run(ctx, server, &rpcConfig); err != nil {
+				slog.Error("grpc server stopped unexpectedly", "error", err)
+			}
+		}()
+	} else {
+		close(rpcDone)
+	}
+
+	// Returns once the server has shut down, so the DB is closed after in-flight requests.
+	if err := handlers.RunRouter(r, &routerConfig); err != nil {
+		slog.Error("server stopped unexpectedly", "error", err)
+	}
 
-	handlers.RunRouter(r, &routerConfig)
+	stop()
+	<-rpcDone
+	<-dispatcherDone
+	<-delivererDone
 }
@@ -0,0 +1,283 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+
+	"govtech/pkg/server/auth"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Formats of the output of commands.
+const OUTPUT_TABLE = "table"
+const OUTPUT_JSON = "json"
+
+// Exit codes of commands.
+const EXIT_OK = 0
+const EXIT_FAILURE = 1
+const EXIT_USAGE = 2
+
+/*
+Structure for the admin CLI, which runs commands with the same service as the controllers.
+Changes are written to the outbox like those of the API, so that the dispatcher of the server
+delivers their events.
+*/
+type CLI struct {
+	Service *services.Service
+	// Signer of the tokens issued by the token command, nil if no token secret is set.
+	Tokens *auth.Signer
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Structure for a command of the CLI.
+type command struct {
+	name string
+	// Flags and arguments of the command.
+	synopsis string
+	summary  string
+	run      func(c *CLI, ctx context.Context, args []string) error
+}
+
+// Commands of the CLI, in the order they are listed.
+var commands = []command{
+	{"register", "-teacher <email> <student>... | -student <email> <teacher>...", "register students to a teacher, or teachers to a student", (*CLI).register},
+	{"deregister", "-teacher <email> <student>...", "deregister students from a teacher", (*CLI).deregister},
+	{"suspend", "<student>", "suspend a student", (*CLI).suspend},
+	{"unsuspend", "<student>", "unsuspend a student", (*CLI).unsuspend},
+	{"list", "[-teacher <email>] [-class <name>] [-suspended <bool>] teachers|students|teaches|notifications", "list the rows of a relation", (*CLI).list},
+	{"common", "<teacher>...", "list the students common to the teachers", (*CLI).common},
+	{"notify", "-teacher <email> <notification>", "send a notification and list its recipients", (*CLI).notify},
+	{"import", "[-dry-run] [<file>]", "import registrations from CSV rows teacher,student[,class], from stdin if no file or -", (*CLI).importRegistrations},
+	{"export", "[-format csv|ndjson] [-teacher <email>] [-class <name>] [-suspended <bool>] teachers|students|teaches|notifications", "export the rows of a relation to stdout", (*CLI).export},
+	{"token", "[-ttl <duration>] <email>", "issue a token authenticating a teacher to /ws/teachers, or a student to their notification stream", (*CLI).token},
+	{"seed", "[-seed <n>] [-teachers <n>] [-students <n>] [-suspended <rate>] [-csv]", "write a generated school, or print its registrations as CSV", (*CLI).seed},
+}
+
+// Error returned for invalid flags or arguments.
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usageErrorf(format string, args ...any) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// Returns true if the name is a command of the CLI.
+func IsCommand(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+func lookup(name string) (command, bool) {
+	for _, v := range commands {
+		if v.name == name {
+			return v, true
+		}
+	}
+
+	return command{}, false
+}
+
+// Writes the usage of the CLI, with the name of its program.
+func Usage(w io.Writer, program string) {
+	fmt.Fprintf(w, "Usage: %s [config flags] <command> [flags] [arguments]\n\nCommands:\n", program)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, v := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", v.name, v.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRun %s <command> -h for the flags of a command, and %s -h for the config flags.\n", program, program)
+}
+
+/*
+Runs the command of the arguments, and returns its exit code.
+Errors are written to Stderr, with the usage of the command if its flags or arguments are invalid.
+*/
+func (c *CLI) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		return c.fail(usageErrorf("a command is required"))
+	}
+
+	cmd, ok := lookup(args[0])
+	if !ok {
+		return c.fail(usageErrorf("unknown command %q", args[0]))
+	}
+
+	err := cmd.run(c, ctx, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return EXIT_OK
+	}
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		fmt.Fprintf(c.Stderr, "error: %s\nusage: %s %s\n", err.Error(), cmd.name, cmd.synopsis)
+		return EXIT_USAGE
+	}
+
+	return c.fail(err)
+}
+
+// Writes the error to Stderr, and returns the exit code of the error.
+func (c *CLI) fail(err error) int {
+	if err == nil {
+		return EXIT_OK
+	}
+
+	fmt.Fprintln(c.Stderr, "error: "+err.Error())
+
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return EXIT_USAGE
+	}
+
+	return EXIT_FAILURE
+}
+
+// Returns the flags of the command, with the output flag if output is not nil.
+func (c *CLI) flagSet(name string, output *string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.Stderr)
+
+	if output != nil {
+		*output = OUTPUT_TABLE
+		flags.Func("o", "output format: table or json (default table)", func(value string) error {
+			if value != OUTPUT_TABLE && value != OUTPUT_JSON {
+				return errors.New("expected table or json")
+			}
+			*output = value
+			return nil
+		})
+	}
+
+	return flags
+}
+
+// Parses the flags of the command, and returns invalid flags as usage errors.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return &usageError{message: err.Error()}
+	}
+
+	return err
+}
+
+// Adds the flag of an optional boolean to the flags.
+func optionalBool(flags *flag.FlagSet, name string, usage string) **bool {
+	var p *bool
+	flags.Func(name, usage, func(value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("expected a boolean")
+		}
+		p = &v
+		return nil
+	})
+
+	return &p
+}
+
+/*
+Validates the request with the same rules as the controllers, and returns the field errors as a usage error.
+Fields are named as in the request bodies of the API.
+*/
+func validate(request any) error {
+	err := binding.Validator.ValidateStruct(request)
+	if err == nil {
+		return nil
+	}
+
+	problem := problems.FromBindError(err)
+	problems.Translate(problem, messages.LANGUAGE_EN)
+
+	details := make([]string, len(problem.Errors))
+	for i, v := range problem.Errors {
+		details[i] = v.Field + ": " + v.Detail
+	}
+
+	return &usageError{message: problem.Title + "\n  " + strings.Join(details, "\n  ")}
+}
+
+/*
+Prints the result as indented JSON, or as a table of the rows with the header.
+The same result is printed in both formats.
+*/
+func (c *CLI) print(output string, result any, header []string, rows [][]string) error {
+	if output == OUTPUT_JSON {
+		encoder := json.NewEncoder(c.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	w := newTableWriter(c.Stdout)
+	if err := w.WriteHeader(header); err != nil {
+		return err
+	}
+	for _, v := range rows {
+		values := make([]any, len(v))
+		for i, value := range v {
+			values[i] = value
+		}
+		if err := w.WriteRow(values); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// Writer of rows as a table with aligned columns, written on Flush.
+type tableWriter struct {
+	w *tabwriter.Writer
+}
+
+func newTableWriter(w io.Writer) *tableWriter {
+	return &tableWriter{w: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}
+}
+
+func (w *tableWriter) WriteHeader(columns []string) error {
+	_, err := fmt.Fprintln(w.w, strings.ToUpper(strings.Join(columns, "\t")))
+	return err
+}
+
+func (w *tableWriter) WriteRow(values []any) error {
+	fields := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case time.Time:
+			fields[i] = v.UTC().Format(time.RFC3339)
+		case string:
+			// Keep rows on one line and columns aligned.
+			fields[i] = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(v)
+		default:
+			fields[i] = fmt.Sprint(v)
+		}
+	}
+
+	_, err := fmt.Fprintln(w.w, strings.Join(fields, "\t"))
+	return err
+}
+
+func (w *tableWriter) Flush() error {
+	return w.w.Flush()
+}
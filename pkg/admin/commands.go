@@ -0,0 +1,472 @@
+package admin
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"govtech/pkg/exports"
+	"govtech/pkg/fixtures"
+	"govtech/pkg/imports"
+	"govtech/pkg/models/request"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+)
+
+// Structure for the result of the register and deregister commands.
+type registrationResult struct {
+	Teacher  string   `json:"teacher,omitempty"`
+	Student  string   `json:"student,omitempty"`
+	Teachers []string `json:"teachers,omitempty"`
+	Students []string `json:"students,omitempty"`
+}
+
+// Structure for the result of the suspend and unsuspend commands.
+type suspensionResult struct {
+	Student   string `json:"student"`
+	Suspended bool   `json:"suspended"`
+}
+
+// Registers students to a teacher, or teachers to a student.
+func (c *CLI) register(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("register", &output)
+	teacher := flags.String("teacher", "", "teacher to register the students to")
+	student := flags.String("student", "", "student to register the teachers to")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	registration := request.RegisterRequest{Teacher: *teacher, Student: *student}
+	if *student != "" {
+		registration.Teachers = flags.Args()
+	} else {
+		registration.Students = flags.Args()
+	}
+	if err := validate(registration); err != nil {
+		return err
+	}
+
+	var rows [][]string
+	if registration.IsStudentShape() {
+		if err := c.Service.RegisterTeachers(ctx, registration.Student, registration.Teachers); err != nil {
+			return err
+		}
+		for _, v := range registration.Teachers {
+			rows = append(rows, []string{v, registration.Student})
+		}
+	} else {
+		if err := c.Service.RegisterStudents(ctx, registration.Teacher, registration.Students); err != nil {
+			return err
+		}
+		for _, v := range registration.Students {
+			rows = append(rows, []string{registration.Teacher, v})
+		}
+	}
+
+	result := registrationResult{
+		Teacher:  registration.Teacher,
+		Student:  registration.Student,
+		Teachers: registration.Teachers,
+		Students: registration.Students,
+	}
+	return c.print(output, result, []string{"teacher", "student"}, rows)
+}
+
+// Deregisters students from a teacher, and prints the students which were registered.
+func (c *CLI) deregister(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("deregister", &output)
+	teacher := flags.String("teacher", "", "teacher to deregister the students from")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	registration := request.RegisterRequest{Teacher: *teacher, Students: flags.Args()}
+	if err := validate(registration); err != nil {
+		return err
+	}
+
+	removed, err := c.Service.DeregisterStudents(ctx, registration.Teacher, registration.Students)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(removed))
+	for i, v := range removed {
+		rows[i] = []string{registration.Teacher, v}
+	}
+
+	result := registrationResult{Teacher: registration.Teacher, Students: removed}
+	if result.Students == nil {
+		result.Students = []string{}
+	}
+	return c.print(output, result, []string{"teacher", "student"}, rows)
+}
+
+// Suspends a student.
+func (c *CLI) suspend(ctx context.Context, args []string) error {
+	return c.setSuspended(ctx, "suspend", args, true)
+}
+
+// Unsuspends a student.
+func (c *CLI) unsuspend(ctx context.Context, args []string) error {
+	return c.setSuspended(ctx, "unsuspend", args, false)
+}
+
+// Sets the suspended state of the student of the arguments, which must exist.
+func (c *CLI) setSuspended(ctx context.Context, name string, args []string, suspended bool) error {
+	var output string
+	flags := c.flagSet(name, &output)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageErrorf("exactly one student is required")
+	}
+
+	suspension := request.SuspendRequest{Student: flags.Arg(0)}
+	if err := validate(suspension); err != nil {
+		return err
+	}
+
+	students, err := c.Service.Store().FindStudents(ctx, []string{suspension.Student})
+	if err != nil {
+		return err
+	}
+	if len(students) == 0 {
+		return fmt.Errorf("student %s does not exist", suspension.Student)
+	}
+
+	if suspended {
+		err = c.Service.Suspend(ctx, suspension.Student)
+	} else {
+		err = c.Service.Unsuspend(ctx, suspension.Student)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := suspensionResult{Student: suspension.Student, Suspended: suspended}
+	return c.print(output, result, []string{"student", "suspended"},
+		[][]string{{result.Student, strconv.FormatBool(result.Suspended)}})
+}
+
+/*
+Lists the rows of a relation matching the filters.
+As JSON, rows are printed as JSON Lines as they are read. As a table, rows are printed once they are all read
+to align the columns, so large relations are better exported.
+*/
+func (c *CLI) list(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("list", &output)
+	resource, filter, err := c.parseExport(flags, args)
+	if err != nil {
+		return err
+	}
+
+	var w exports.Writer
+	if output == OUTPUT_JSON {
+		w = exports.NewWriter(exports.FORMAT_NDJSON, c.Stdout)
+	} else {
+		w = newTableWriter(c.Stdout)
+	}
+
+	_, err = exports.Export(ctx, c.Service.Store(), resource, filter, w)
+	return err
+}
+
+// Lists the students common to the teachers.
+func (c *CLI) common(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("common", &output)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usageErrorf("at least one teacher is required")
+	}
+
+	students, err := c.Service.CommonStudents(ctx, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(students))
+	for i, v := range students {
+		rows[i] = []string{v}
+	}
+
+	result := map[string][]string{"students": students}
+	if students == nil {
+		result["students"] = []string{}
+	}
+	return c.print(output, result, []string{"student"}, rows)
+}
+
+// Sends a notification from a teacher, and lists its recipients.
+func (c *CLI) notify(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("notify", &output)
+	teacher := flags.String("teacher", "", "teacher sending the notification")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageErrorf("exactly one notification is required, quote it if it has spaces")
+	}
+
+	notification := request.ReceieveForNotificationsRequest{Teacher: *teacher, Notification: flags.Arg(0)}
+	if err := validate(notification); err != nil {
+		return err
+	}
+
+	recipients, err := c.Service.RetrieveForNotifications(ctx, notification.Teacher, notification.Notification)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, len(recipients))
+	for i, v := range recipients {
+		rows[i] = []string{v}
+	}
+
+	result := map[string][]string{"recipients": recipients}
+	if recipients == nil {
+		result["recipients"] = []string{}
+	}
+	return c.print(output, result, []string{"recipient"}, rows)
+}
+
+/*
+Imports registrations from a CSV file, or stdin, and prints the report of the import.
+Fails if any row is invalid, after the valid rows are applied.
+*/
+func (c *CLI) importRegistrations(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("import", &output)
+	dryRun := flags.Bool("dry-run", false, "validate the rows and report the registrations without writing anything")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usageErrorf("at most one file is allowed")
+	}
+
+	var r io.Reader = c.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	report, err := imports.Registrations(ctx, r, c.Service, imports.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+
+	result := report.Response(messages.LANGUAGE_EN)
+	rows := [][]string{
+		{"rows", strconv.Itoa(result.Rows)},
+		{"registered", strconv.Itoa(result.Registered)},
+		{"existing", strconv.Itoa(result.Existing)},
+		{"invalid", strconv.Itoa(result.Invalid)},
+	}
+	for _, v := range result.Errors {
+		rows = append(rows, []string{"row " + strconv.Itoa(v.Row), v.Field + ": " + v.Detail})
+	}
+	if result.ErrorsTruncated {
+		rows = append(rows, []string{"...", "errors of further rows are not shown"})
+	}
+
+	if err := c.print(output, result, []string{"import", "count"}, rows); err != nil {
+		return err
+	}
+
+	if result.Invalid > 0 {
+		return fmt.Errorf("%d invalid rows were skipped", result.Invalid)
+	}
+	return nil
+}
+
+// Exports the rows of a relation matching the filters to stdout, as they are read.
+func (c *CLI) export(ctx context.Context, args []string) error {
+	flags := c.flagSet("export", nil)
+	format := flags.String("format", exports.FORMAT_CSV, "format of the export: csv or ndjson")
+	resource, filter, err := c.parseExport(flags, args)
+	if err != nil {
+		return err
+	}
+	if !exports.IsFormat(*format) {
+		return usageErrorf("unknown format %q", *format)
+	}
+
+	_, err = exports.Export(ctx, c.Service.Store(), resource, filter, exports.NewWriter(*format, c.Stdout))
+	return err
+}
+
+// Parses the filters of an export and the relation of the arguments.
+func (c *CLI) parseExport(flags *flag.FlagSet, args []string) (string, database.ExportFilter, error) {
+	teacher := flags.String("teacher", "", "rows related to the registrations of the teacher")
+	class := flags.String("class", "", "rows related to the registrations in the class")
+	suspended := optionalBool(flags, "suspended", "rows related to students of the suspended state")
+	if err := parseFlags(flags, args); err != nil {
+		return "", database.ExportFilter{}, err
+	}
+
+	if flags.NArg() != 1 {
+		return "", database.ExportFilter{}, usageErrorf("exactly one relation is required")
+	}
+	resource := flags.Arg(0)
+	if !exports.IsResource(resource) {
+		return "", database.ExportFilter{}, usageErrorf("unknown relation %q", resource)
+	}
+
+	query := request.ExportRequest{Teacher: *teacher, Class: *class}
+	if err := validate(query); err != nil {
+		return "", database.ExportFilter{}, err
+	}
+
+	return resource, database.ExportFilter{Teacher: *teacher, Class: *class, Suspended: *suspended}, nil
+}
+
+// Structure for the result of the seed command.
+type seedResult struct {
+	Seed          int64 `json:"seed"`
+	Teachers      int   `json:"teachers"`
+	Students      int   `json:"students"`
+	Classes       int   `json:"classes"`
+	Registrations int   `json:"registrations"`
+	Suspended     int   `json:"suspended"`
+	// Number of students newly registered to teachers.
+	Registered int `json:"registered"`
+}
+
+/*
+Generates a school from the seed, and writes it or prints its registrations as CSV rows teacher,student,class
+which can be imported. Suspensions are not printed as CSV.
+*/
+func (c *CLI) seed(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("seed", &output)
+	seed := flags.Int64("seed", 1, "seed of the school, the same seed and flags generate the same school")
+	teachers := flags.Int("teachers", 20, "number of teachers")
+	students := flags.Int("students", 500, "number of students")
+	classSize := flags.Int("class-size", fixtures.DEFAULT_CLASS_SIZE, "number of students in each class")
+	subjectTeachers := flags.Int("subject-teachers", fixtures.DEFAULT_SUBJECT_TEACHERS, "number of subject teachers of each class, besides its form teacher")
+	electives := flags.Float64("electives", fixtures.DEFAULT_ELECTIVE_RATE, "fraction of students registered to a teacher outside of their class")
+	suspended := flags.Float64("suspended", fixtures.DEFAULT_SUSPENDED_RATE, "fraction of suspended students")
+	printCSV := flags.Bool("csv", false, "print the registrations as CSV instead of writing them")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageErrorf("unexpected arguments")
+	}
+
+	switch {
+	case *teachers < 1 || *students < 1:
+		return usageErrorf("at least one teacher and one student are required")
+	case *classSize < 1 || *subjectTeachers < 1:
+		return usageErrorf("class size and subject teachers must be positive")
+	case *electives < 0 || *electives > 1 || *suspended < 0 || *suspended > 1:
+		return usageErrorf("rates must be between 0 and 1")
+	}
+
+	school := fixtures.Generate(fixtures.Config{
+		Seed:            *seed,
+		Teachers:        *teachers,
+		Students:        *students,
+		ClassSize:       *classSize,
+		SubjectTeachers: *subjectTeachers,
+		ElectiveRate:    *electives,
+		SuspendedRate:   *suspended,
+	})
+
+	if *printCSV {
+		w := csv.NewWriter(c.Stdout)
+		w.Write([]string{imports.COLUMN_TEACHER, imports.COLUMN_STUDENT, imports.COLUMN_CLASS})
+		for _, v := range school.Registrations {
+			w.Write([]string{v.Teacher, v.Student, v.Class})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	registered, err := fixtures.Load(ctx, c.Service.Store(), school)
+	if err != nil {
+		return err
+	}
+
+	result := seedResult{
+		Seed:          *seed,
+		Teachers:      len(school.Teachers),
+		Students:      len(school.Students),
+		Classes:       len(school.Classes),
+		Registrations: len(school.Registrations),
+		Suspended:     len(school.Suspended()),
+		Registered:    registered,
+	}
+	return c.print(output, result, []string{"school", "count"}, [][]string{
+		{"seed", strconv.FormatInt(result.Seed, 10)},
+		{"teachers", strconv.Itoa(result.Teachers)},
+		{"students", strconv.Itoa(result.Students)},
+		{"classes", strconv.Itoa(result.Classes)},
+		{"registrations", strconv.Itoa(result.Registrations)},
+		{"suspended", strconv.Itoa(result.Suspended)},
+		{"registered", strconv.Itoa(result.Registered)},
+	})
+}
+
+// Default lifetime of the tokens issued by the token command.
+const DEFAULT_TOKEN_TTL = 24 * time.Hour
+
+// Structure for the result of the token command.
+type tokenResult struct {
+	Subject   string    `json:"subject"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+/*
+Issues a token authenticating the email, signed with the token secret of the server.
+The same token authenticates a teacher to /ws/teachers, or a student to their notification stream.
+*/
+func (c *CLI) token(ctx context.Context, args []string) error {
+	var output string
+	flags := c.flagSet("token", &output)
+	ttl := flags.Duration("ttl", DEFAULT_TOKEN_TTL, "duration until the token expires")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageErrorf("exactly one email is required")
+	}
+	if !patterns.IsEmail(flags.Arg(0)) {
+		return usageErrorf("%q is not an email", flags.Arg(0))
+	}
+	if *ttl <= 0 {
+		return usageErrorf("ttl must be positive")
+	}
+	if c.Tokens == nil {
+		return errors.New("the token secret is not set (set AUTH_TOKEN_SECRET)")
+	}
+
+	// Tokens expire at whole seconds, so the expiry printed is the one signed.
+	expires := time.Now().Add(*ttl).Truncate(time.Second)
+	result := tokenResult{Subject: flags.Arg(0), Token: c.Tokens.Sign(flags.Arg(0), expires), ExpiresAt: expires.UTC()}
+
+	return c.print(output, result, []string{"subject", "token", "expires_at"}, [][]string{
+		{result.Subject, result.Token, result.ExpiresAt.Format(time.RFC3339)},
+	})
+}
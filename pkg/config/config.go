@@ -0,0 +1,398 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Env variable holding the path of the config file, overridden by the -config flag.
+const ENV_CONFIG_FILE = "CONFIG_FILE"
+
+// Structure for the configuration of the server.
+type Config struct {
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	Router    RouterConfig    `yaml:"router" toml:"router"`
+	GRPC      GRPCConfig      `yaml:"grpc" toml:"grpc"`
+	GraphQL   GraphQLConfig   `yaml:"graphql" toml:"graphql"`
+	WebSocket WebSocketConfig `yaml:"websocket" toml:"websocket"`
+	Events    EventsConfig    `yaml:"events" toml:"events"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks" toml:"webhooks"`
+	Cache     CacheConfig     `yaml:"cache" toml:"cache"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Log       LogConfig       `yaml:"log" toml:"log"`
+	API       APIConfig       `yaml:"api" toml:"api"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Features  FeaturesConfig  `yaml:"features" toml:"features"`
+}
+
+// Structure for the configuration of the MySQL DB and its connection pool.
+type DatabaseConfig struct {
+	User            string   `yaml:"user" toml:"user"`
+	Password        string   `yaml:"password" toml:"password"`
+	Host            string   `yaml:"host" toml:"host"`
+	Port            string   `yaml:"port" toml:"port"`
+	Name            string   `yaml:"name" toml:"name"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+
+	DialTimeout  Duration `yaml:"dial_timeout" toml:"dial_timeout"`
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout"`
+	TLS          string   `yaml:"tls" toml:"tls"`
+	TLSCAFile    string   `yaml:"tls_ca_file" toml:"tls_ca_file"`
+
+	ConnectAttempts   int      `yaml:"connect_attempts" toml:"connect_attempts"`
+	ConnectBackoff    Duration `yaml:"connect_backoff" toml:"connect_backoff"`
+	ConnectMaxBackoff Duration `yaml:"connect_max_backoff" toml:"connect_max_backoff"`
+}
+
+// Structure for the configuration of the HTTP server.
+type RouterConfig struct {
+	Host              string   `yaml:"host" toml:"host"`
+	Port              string   `yaml:"port" toml:"port"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// Deadline of requests including their DB calls, with per-route overrides
+	// of the form "<route>=<duration>,...".
+	RequestTimeout       Duration `yaml:"request_timeout" toml:"request_timeout"`
+	RequestTimeoutRoutes string   `yaml:"request_timeout_routes" toml:"request_timeout_routes"`
+	// Deadline of imports and exports, which are read and written until it instead of the read and write timeouts.
+	TransferTimeout Duration `yaml:"transfer_timeout" toml:"transfer_timeout"`
+	TLSCertFile     string   `yaml:"tls_cert_file" toml:"tls_cert_file"`
+	TLSKeyFile      string   `yaml:"tls_key_file" toml:"tls_key_file"`
+}
+
+// Structure for the configuration of the gRPC server.
+type GRPCConfig struct {
+	Host string `yaml:"host" toml:"host"`
+	Port string `yaml:"port" toml:"port"`
+}
+
+// Structure for the configuration of the limits of GraphQL queries.
+// A limit of 0 disables the check.
+type GraphQLConfig struct {
+	MaxDepth      int `yaml:"max_depth" toml:"max_depth"`
+	MaxComplexity int `yaml:"max_complexity" toml:"max_complexity"`
+}
+
+// Structure for the configuration of the teacher events WebSocket.
+type WebSocketConfig struct {
+	PingInterval Duration `yaml:"ping_interval" toml:"ping_interval"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout"`
+	// Number of messages buffered for each client before it is disconnected as too slow.
+	Buffer int `yaml:"buffer" toml:"buffer"`
+}
+
+// Structure for the configuration of the dispatch of domain events from the outbox.
+type EventsConfig struct {
+	PollInterval    Duration `yaml:"poll_interval" toml:"poll_interval"`
+	BatchSize       int      `yaml:"batch_size" toml:"batch_size"`
+	Lease           Duration `yaml:"lease" toml:"lease"`
+	RetryBackoff    Duration `yaml:"retry_backoff" toml:"retry_backoff"`
+	MaxRetryBackoff Duration `yaml:"max_retry_backoff" toml:"max_retry_backoff"`
+	// Number of attempts after which an event is dead.
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// URLs of the webhooks receiving every event, of the form "<url>,...".
+	Webhooks       string   `yaml:"webhooks" toml:"webhooks"`
+	WebhookTimeout Duration `yaml:"webhook_timeout" toml:"webhook_timeout"`
+}
+
+// Structure for the configuration of the deliveries to the webhooks registered with the API.
+type WebhooksConfig struct {
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	BatchSize    int      `yaml:"batch_size" toml:"batch_size"`
+	// Number of webhooks delivered to at once.
+	Concurrency     int      `yaml:"concurrency" toml:"concurrency"`
+	Lease           Duration `yaml:"lease" toml:"lease"`
+	RetryBackoff    Duration `yaml:"retry_backoff" toml:"retry_backoff"`
+	MaxRetryBackoff Duration `yaml:"max_retry_backoff" toml:"max_retry_backoff"`
+	// Number of attempts after which a delivery fails.
+	MaxAttempts int      `yaml:"max_attempts" toml:"max_attempts"`
+	Timeout     Duration `yaml:"timeout" toml:"timeout"`
+	// Allows webhooks at loopback, link-local and private addresses, eg. receivers on the same host in development.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks" toml:"allow_private_networks"`
+}
+
+// Structure for the configuration of the caches of rosters and common students.
+type CacheConfig struct {
+	// Maximum number of values in each cache.
+	Size int `yaml:"size" toml:"size"`
+	// Duration for which values are cached, bounding how long changes by other servers are missed.
+	TTL Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// Structure for the configuration of rate limiting.
+// Limits are of the form "<rate>:<burst>".
+type RateLimitConfig struct {
+	KeyBy   string `yaml:"key_by" toml:"key_by"`
+	Default string `yaml:"default" toml:"default"`
+	Routes  string `yaml:"routes" toml:"routes"`
+}
+
+// Structure for the configuration of logging.
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level"`
+}
+
+// Structure for the configuration of the versions of the API.
+type APIConfig struct {
+	// Dates of the deprecation and sunset of v1, eg. "2027-01-31" or "2027-01-31T00:00:00Z".
+	V1Deprecation string `yaml:"v1_deprecation" toml:"v1_deprecation"`
+	V1Sunset      string `yaml:"v1_sunset" toml:"v1_sunset"`
+}
+
+// Structure for the configuration of the authentication of requests.
+type AuthConfig struct {
+	// Key of administrators required by the management endpoints, eg. "/api/webhooks", which are refused if empty.
+	AdminKey string `yaml:"admin_key" toml:"admin_key"`
+	// Secret shared with the services issuing the tokens of teachers and students, see pkg/server/auth.
+	TokenSecret string `yaml:"token_secret" toml:"token_secret"`
+}
+
+// Structure for toggling optional features.
+type FeaturesConfig struct {
+	RateLimit bool `yaml:"rate_limit" toml:"rate_limit"`
+	Metrics   bool `yaml:"metrics" toml:"metrics"`
+	// Serves the gRPC API on its own port.
+	GRPC bool `yaml:"grpc" toml:"grpc"`
+	// Serves the GraphQL API at "/graphql".
+	GraphQL bool `yaml:"graphql" toml:"graphql"`
+	// Serves the teacher events WebSocket at "/ws/teachers", requires a secret.
+	WebSocket bool `yaml:"websocket" toml:"websocket"`
+	// Caches rosters and common students in memory.
+	// Servers miss the changes of other servers until their events are dispatched, so reads can be stale across replicas.
+	Cache bool `yaml:"cache" toml:"cache"`
+	// Validates requests against the OpenAPI document.
+	RequestValidation bool `yaml:"request_validation" toml:"request_validation"`
+	// Validates responses against the OpenAPI document as well, eg. in test environments.
+	ResponseValidation bool `yaml:"response_validation" toml:"response_validation"`
+}
+
+// Duration which can be decoded from strings such as "10s" in config files.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	d.Duration = duration
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+// Returns the configuration used for values which are not set.
+func Default() *Config {
+	return &Config{
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            "3306",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: Duration{5 * time.Minute},
+			ConnMaxIdleTime: Duration{time.Minute},
+
+			DialTimeout:  Duration{5 * time.Second},
+			ReadTimeout:  Duration{30 * time.Second},
+			WriteTimeout: Duration{30 * time.Second},
+			TLS:          "false",
+
+			ConnectAttempts:   10,
+			ConnectBackoff:    Duration{500 * time.Millisecond},
+			ConnectMaxBackoff: Duration{10 * time.Second},
+		},
+		Router: RouterConfig{
+			Host:              "localhost",
+			Port:              "8080",
+			ReadTimeout:       Duration{10 * time.Second},
+			ReadHeaderTimeout: Duration{5 * time.Second},
+			WriteTimeout:      Duration{30 * time.Second},
+			IdleTimeout:       Duration{120 * time.Second},
+			ShutdownTimeout:   Duration{15 * time.Second},
+			RequestTimeout:    Duration{10 * time.Second},
+			TransferTimeout:   Duration{10 * time.Minute},
+		},
+		GRPC: GRPCConfig{
+			Host: "localhost",
+			Port: "9090",
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      6,
+			MaxComplexity: 1000,
+		},
+		WebSocket: WebSocketConfig{
+			PingInterval: Duration{30 * time.Second},
+			WriteTimeout: Duration{10 * time.Second},
+			Buffer:       64,
+		},
+		Events: EventsConfig{
+			PollInterval:    Duration{time.Second},
+			BatchSize:       100,
+			Lease:           Duration{time.Minute},
+			RetryBackoff:    Duration{time.Second},
+			MaxRetryBackoff: Duration{5 * time.Minute},
+			MaxAttempts:     20,
+			WebhookTimeout:  Duration{10 * time.Second},
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval:    Duration{time.Second},
+			BatchSize:       50,
+			Concurrency:     10,
+			Lease:           Duration{time.Minute},
+			RetryBackoff:    Duration{10 * time.Second},
+			MaxRetryBackoff: Duration{time.Hour},
+			MaxAttempts:     10,
+			Timeout:         Duration{10 * time.Second},
+		},
+		Cache: CacheConfig{
+			Size: 10000,
+			TTL:  Duration{30 * time.Second},
+		},
+		RateLimit: RateLimitConfig{
+			KeyBy:   "ip",
+			Default: "10:20",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Features: FeaturesConfig{
+			RateLimit:         true,
+			Metrics:           true,
+			RequestValidation: true,
+			GRPC:              true,
+			GraphQL:           true,
+		},
+	}
+}
+
+/*
+Returns the configuration loaded from, in increasing order of precedence:
+defaults, the config file, env variables (including those in ".env") and flags.
+Returns an error describing every invalid value if the configuration is invalid.
+*/
+func Load(name string, args []string) (*Config, error) {
+	config, _, err := LoadCommand(name, args)
+	return config, err
+}
+
+/*
+Returns the configuration loaded as with Load from the flags at the start of args,
+and the arguments after the flags, eg. the subcommand of a CLI and its arguments.
+*/
+func LoadCommand(name string, args []string) (*Config, []string, error) {
+	config := Default()
+	settings := config.settings()
+
+	// Load ".env" from the working directory if it exists.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	// Parse flags first to find the config file, but apply them last.
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(ENV_CONFIG_FILE),
+		"path of YAML or TOML config file (env "+ENV_CONFIG_FILE+")")
+
+	flagValues := make(map[string]string)
+	for _, v := range settings {
+		v := v
+		record := func(value string) error {
+			flagValues[v.flag] = value
+			return nil
+		}
+
+		if v.isBool {
+			flags.BoolFunc(v.flag, v.usage+" (env "+v.env+")", record)
+		} else {
+			flags.Func(v.flag, v.usage+" (env "+v.env+")", record)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []string
+
+	for _, v := range settings {
+		if value, ok := os.LookupEnv(v.env); ok && value != "" {
+			if err := v.set(value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: invalid value %q: %s", v.env, value, err))
+			}
+		}
+	}
+
+	for _, v := range settings {
+		if value, ok := flagValues[v.flag]; ok {
+			if err := v.set(value); err != nil {
+				errs = append(errs, fmt.Sprintf("-%s: invalid value %q: %s", v.flag, value, err))
+			}
+		}
+	}
+
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, nil, &ValidationError{Problems: errs}
+	}
+
+	return config, flags.Args(), nil
+}
+
+// Loads the YAML or TOML file at path over the current values.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file %q, expected .yaml, .yml or .toml", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// Error returned for an invalid configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
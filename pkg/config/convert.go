@@ -0,0 +1,17 @@
+package config
+
+import (
+	"strings"
+)
+
+// Returns the URLs of the webhooks receiving every event.
+func (c *Config) EventWebhooks() []string {
+	var urls []string
+	for _, v := range strings.Split(c.Events.Webhooks, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			urls = append(urls, v)
+		}
+	}
+
+	return urls
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values for RateLimitConfig.KeyBy denoting what identifies a client.
+const RATE_LIMIT_KEY_IP = "ip"
+const RATE_LIMIT_KEY_API_KEY = "api_key"
+const RATE_LIMIT_KEY_TEACHER = "teacher"
+
+// Structure for a token bucket limit of the form "<rate>:<burst>".
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+/*
+Parses per-route limits of the form "<route>=<rate>:<burst>", separated by commas.
+eg. "/api/retrievefornotifications=0.5:5,/api/register=2:10"
+*/
+func ParseRateLimitRoutes(value string) (map[string]RateLimit, error) {
+	routes := make(map[string]RateLimit)
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		route, limitValue, found := strings.Cut(v, "=")
+		if !found {
+			return nil, &RateLimitParseError{Value: v}
+		}
+
+		limit, err := ParseRateLimit(limitValue)
+		if err != nil {
+			return nil, &RateLimitParseError{Value: v}
+		}
+		routes[strings.TrimSpace(route)] = limit
+	}
+
+	return routes, nil
+}
+
+// Parses a limit of the form "<rate>:<burst>".
+func ParseRateLimit(value string) (RateLimit, error) {
+	rateValue, burstValue, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return RateLimit{}, &RateLimitParseError{Value: value}
+	}
+
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil {
+		return RateLimit{}, &RateLimitParseError{Value: value}
+	}
+
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil {
+		return RateLimit{}, &RateLimitParseError{Value: value}
+	}
+
+	return RateLimit{Rate: rate, Burst: burst}, nil
+}
+
+// Error returned for a malformed rate limit.
+type RateLimitParseError struct {
+	Value string
+}
+
+func (e *RateLimitParseError) Error() string {
+	return `invalid rate limit "` + e.Value + `", expected "<rate>:<burst>"`
+}
+
+/*
+Parses per-route deadlines of the form "<route>=<duration>", separated by commas.
+eg. "/api/retrievefornotifications=2s,/api/register=5s"
+*/
+func ParseRouteTimeouts(value string) (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration)
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		route, durationValue, found := strings.Cut(v, "=")
+		if !found {
+			return nil, &RouteTimeoutParseError{Value: v}
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(durationValue))
+		if err != nil {
+			return nil, &RouteTimeoutParseError{Value: v}
+		}
+		routes[strings.TrimSpace(route)] = duration
+	}
+
+	return routes, nil
+}
+
+// Error returned for a malformed route deadline.
+type RouteTimeoutParseError struct {
+	Value string
+}
+
+func (e *RouteTimeoutParseError) Error() string {
+	return `invalid route timeout "` + e.Value + `", expected "<route>=<duration>"`
+}
+
+// Returns the time of a date or a RFC 3339 timestamp, or the zero time if not set.
+func ParseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
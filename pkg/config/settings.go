@@ -0,0 +1,148 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// Structure for a value which can be set by a flag or an env variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(string) error
+	// Boolean flags can be given without a value, eg. "-enable-metrics".
+	isBool bool
+}
+
+// Returns the settings of every value of the configuration.
+func (c *Config) settings() []setting {
+	return []setting{
+		stringSetting("db-user", "DB_USER", "database user", &c.Database.User),
+		stringSetting("db-pass", "DB_PASS", "database password", &c.Database.Password),
+		stringSetting("db-host", "DB_HOST", "database host", &c.Database.Host),
+		stringSetting("db-port", "DB_PORT", "database port", &c.Database.Port),
+		stringSetting("db-name", "DB_NAME", "database name", &c.Database.Name),
+		intSetting("db-max-open-conns", "DB_MAX_OPEN_CONNS", "maximum open connections, 0 for unlimited", &c.Database.MaxOpenConns),
+		intSetting("db-max-idle-conns", "DB_MAX_IDLE_CONNS", "maximum idle connections", &c.Database.MaxIdleConns),
+		durationSetting("db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "maximum lifetime of a connection, 0 for unlimited", &c.Database.ConnMaxLifetime),
+		durationSetting("db-conn-max-idle-time", "DB_CONN_MAX_IDLE_TIME", "maximum idle time of a connection, 0 for unlimited", &c.Database.ConnMaxIdleTime),
+		durationSetting("db-dial-timeout", "DB_DIAL_TIMEOUT", "timeout for establishing a connection", &c.Database.DialTimeout),
+		durationSetting("db-read-timeout", "DB_READ_TIMEOUT", "I/O read timeout of a connection", &c.Database.ReadTimeout),
+		durationSetting("db-write-timeout", "DB_WRITE_TIMEOUT", "I/O write timeout of a connection", &c.Database.WriteTimeout),
+		stringSetting("db-tls", "DB_TLS", "TLS mode: false, true, skip-verify or preferred", &c.Database.TLS),
+		stringSetting("db-tls-ca-file", "DB_TLS_CA_FILE", "CA certificate to verify the database against", &c.Database.TLSCAFile),
+		intSetting("db-connect-attempts", "DB_CONNECT_ATTEMPTS", "attempts to reach the database on startup", &c.Database.ConnectAttempts),
+		durationSetting("db-connect-backoff", "DB_CONNECT_BACKOFF", "delay before retrying to reach the database, doubled on every attempt", &c.Database.ConnectBackoff),
+		durationSetting("db-connect-max-backoff", "DB_CONNECT_MAX_BACKOFF", "maximum delay between attempts to reach the database", &c.Database.ConnectMaxBackoff),
+
+		stringSetting("host", "ROUTER_HOST", "host to listen on", &c.Router.Host),
+		stringSetting("port", "ROUTER_PORT", "port to listen on", &c.Router.Port),
+		durationSetting("read-timeout", "ROUTER_READ_TIMEOUT", "maximum duration to read a request", &c.Router.ReadTimeout),
+		durationSetting("read-header-timeout", "ROUTER_READ_HEADER_TIMEOUT", "maximum duration to read request headers", &c.Router.ReadHeaderTimeout),
+		durationSetting("write-timeout", "ROUTER_WRITE_TIMEOUT", "maximum duration to write a response", &c.Router.WriteTimeout),
+		durationSetting("idle-timeout", "ROUTER_IDLE_TIMEOUT", "maximum duration to keep idle connections", &c.Router.IdleTimeout),
+		durationSetting("shutdown-timeout", "ROUTER_SHUTDOWN_TIMEOUT", "maximum duration to wait for requests on shutdown", &c.Router.ShutdownTimeout),
+		durationSetting("request-timeout", "ROUTER_REQUEST_TIMEOUT", "deadline of requests including their database calls, 0 for none", &c.Router.RequestTimeout),
+		stringSetting("request-timeout-routes", "ROUTER_REQUEST_TIMEOUT_ROUTES", "per-route request deadlines as <route>=<duration>,...", &c.Router.RequestTimeoutRoutes),
+		durationSetting("transfer-timeout", "ROUTER_TRANSFER_TIMEOUT", "deadline of imports and exports including reading and writing them, 0 for none", &c.Router.TransferTimeout),
+		stringSetting("tls-cert-file", "ROUTER_TLS_CERT_FILE", "TLS certificate file, enables HTTPS", &c.Router.TLSCertFile),
+		stringSetting("tls-key-file", "ROUTER_TLS_KEY_FILE", "TLS private key file", &c.Router.TLSKeyFile),
+
+		stringSetting("grpc-host", "GRPC_HOST", "host for the gRPC server to listen on", &c.GRPC.Host),
+		stringSetting("grpc-port", "GRPC_PORT", "port for the gRPC server to listen on", &c.GRPC.Port),
+
+		intSetting("graphql-max-depth", "GRAPHQL_MAX_DEPTH", "maximum nesting of fields of GraphQL queries, 0 for unlimited", &c.GraphQL.MaxDepth),
+		intSetting("graphql-max-complexity", "GRAPHQL_MAX_COMPLEXITY", "maximum complexity of GraphQL queries, 0 for unlimited", &c.GraphQL.MaxComplexity),
+
+		durationSetting("websocket-ping-interval", "WEBSOCKET_PING_INTERVAL", "interval between pings of WebSocket clients", &c.WebSocket.PingInterval),
+		durationSetting("websocket-write-timeout", "WEBSOCKET_WRITE_TIMEOUT", "maximum duration to write a WebSocket message", &c.WebSocket.WriteTimeout),
+		intSetting("websocket-buffer", "WEBSOCKET_BUFFER", "messages buffered for each WebSocket client before it is disconnected", &c.WebSocket.Buffer),
+
+		durationSetting("events-poll-interval", "EVENTS_POLL_INTERVAL", "interval between polls of the outbox", &c.Events.PollInterval),
+		intSetting("events-batch-size", "EVENTS_BATCH_SIZE", "maximum events dispatched at once", &c.Events.BatchSize),
+		durationSetting("events-lease", "EVENTS_LEASE", "duration for which other servers skip the events being dispatched", &c.Events.Lease),
+		durationSetting("events-retry-backoff", "EVENTS_RETRY_BACKOFF", "delay before retrying an event, doubled on every attempt", &c.Events.RetryBackoff),
+		durationSetting("events-max-retry-backoff", "EVENTS_MAX_RETRY_BACKOFF", "maximum delay before retrying an event", &c.Events.MaxRetryBackoff),
+		intSetting("events-max-attempts", "EVENTS_MAX_ATTEMPTS", "attempts after which an event is dead", &c.Events.MaxAttempts),
+		stringSetting("events-webhooks", "EVENTS_WEBHOOKS", "URLs of webhooks receiving every event as <url>,...", &c.Events.Webhooks),
+		durationSetting("events-webhook-timeout", "EVENTS_WEBHOOK_TIMEOUT", "maximum duration of a delivery to a webhook", &c.Events.WebhookTimeout),
+
+		durationSetting("webhooks-poll-interval", "WEBHOOKS_POLL_INTERVAL", "interval between polls of pending webhook deliveries", &c.Webhooks.PollInterval),
+		intSetting("webhooks-batch-size", "WEBHOOKS_BATCH_SIZE", "maximum webhook deliveries sent at once", &c.Webhooks.BatchSize),
+		intSetting("webhooks-concurrency", "WEBHOOKS_CONCURRENCY", "maximum webhooks delivered to at once", &c.Webhooks.Concurrency),
+		durationSetting("webhooks-lease", "WEBHOOKS_LEASE", "duration for which other servers skip the webhook deliveries being sent", &c.Webhooks.Lease),
+		durationSetting("webhooks-retry-backoff", "WEBHOOKS_RETRY_BACKOFF", "delay before retrying a webhook delivery, doubled on every attempt", &c.Webhooks.RetryBackoff),
+		durationSetting("webhooks-max-retry-backoff", "WEBHOOKS_MAX_RETRY_BACKOFF", "maximum delay before retrying a webhook delivery", &c.Webhooks.MaxRetryBackoff),
+		intSetting("webhooks-max-attempts", "WEBHOOKS_MAX_ATTEMPTS", "attempts after which a webhook delivery fails", &c.Webhooks.MaxAttempts),
+		durationSetting("webhooks-timeout", "WEBHOOKS_TIMEOUT", "maximum duration of an attempt to deliver to a webhook", &c.Webhooks.Timeout),
+		boolSetting("webhooks-allow-private-networks", "WEBHOOKS_ALLOW_PRIVATE_NETWORKS", "allow webhooks at loopback, link-local and private addresses", &c.Webhooks.AllowPrivateNetworks),
+
+		intSetting("cache-size", "CACHE_SIZE", "maximum values in each cache", &c.Cache.Size),
+		durationSetting("cache-ttl", "CACHE_TTL", "duration for which values are cached", &c.Cache.TTL),
+
+		stringSetting("rate-limit-key", "RATE_LIMIT_KEY", "client key for rate limiting: ip, api_key or teacher", &c.RateLimit.KeyBy),
+		stringSetting("rate-limit-default", "RATE_LIMIT_DEFAULT", "default rate limit as <rate>:<burst>", &c.RateLimit.Default),
+		stringSetting("rate-limit-routes", "RATE_LIMIT_ROUTES", "per-route rate limits as <route>=<rate>:<burst>,...", &c.RateLimit.Routes),
+
+		stringSetting("log-level", "LOG_LEVEL", "log level: debug, info, warn or error", &c.Log.Level),
+
+		stringSetting("api-v1-deprecation", "API_V1_DEPRECATION", "date from which v1 of the API is deprecated", &c.API.V1Deprecation),
+		stringSetting("api-v1-sunset", "API_V1_SUNSET", "date after which v1 of the API may be removed", &c.API.V1Sunset),
+
+		stringSetting("auth-admin-key", "AUTH_ADMIN_KEY", "key of administrators required by the /api/webhooks endpoints", &c.Auth.AdminKey),
+		stringSetting("auth-token-secret", "AUTH_TOKEN_SECRET", "secret used to verify the tokens of teachers and students", &c.Auth.TokenSecret),
+
+		boolSetting("enable-rate-limit", "FEATURE_RATE_LIMIT", "enable rate limiting", &c.Features.RateLimit),
+		boolSetting("enable-metrics", "FEATURE_METRICS", "enable the /metrics endpoint", &c.Features.Metrics),
+		boolSetting("enable-grpc", "FEATURE_GRPC", "enable the gRPC server", &c.Features.GRPC),
+		boolSetting("enable-graphql", "FEATURE_GRAPHQL", "enable the /graphql endpoint", &c.Features.GraphQL),
+		boolSetting("enable-websocket", "FEATURE_WEBSOCKET", "enable the /ws/teachers endpoint", &c.Features.WebSocket),
+		boolSetting("enable-cache", "FEATURE_CACHE", "cache rosters and common students in memory", &c.Features.Cache),
+		boolSetting("enable-request-validation", "FEATURE_REQUEST_VALIDATION", "validate requests against the OpenAPI document", &c.Features.RequestValidation),
+		boolSetting("enable-response-validation", "FEATURE_RESPONSE_VALIDATION", "validate responses against the OpenAPI document and log mismatches", &c.Features.ResponseValidation),
+	}
+}
+
+func stringSetting(flag string, env string, usage string, p *string) setting {
+	return setting{flag, env, usage, func(value string) error {
+		*p = value
+		return nil
+	}, false}
+}
+
+func intSetting(flag string, env string, usage string, p *int) setting {
+	return setting{flag, env, usage, func(value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+
+		*p = v
+		return nil
+	}, false}
+}
+
+func boolSetting(flag string, env string, usage string, p *bool) setting {
+	return setting{flag, env, usage, func(value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		*p = v
+		return nil
+	}, true}
+}
+
+func durationSetting(flag string, env string, usage string, p *Duration) setting {
+	return setting{flag, env, usage, func(value string) error {
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		p.Duration = v
+		return nil
+	}, false}
+}
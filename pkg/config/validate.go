@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Minimum length of secrets used to sign tokens.
+const MIN_SECRET_LENGTH = 32
+
+// Returns a description of every invalid value of the configuration.
+func (c *Config) validate() []string {
+	var errs []string
+
+	required := func(value string, name string, env string) {
+		if value == "" {
+			errs = append(errs, fmt.Sprintf("%s is required (set %s)", name, env))
+		}
+	}
+
+	port := func(value string, name string) {
+		if v, err := strconv.Atoi(value); value != "" && (err != nil || v < 0 || v > 65535) {
+			errs = append(errs, fmt.Sprintf("%s must be a port number between 0 and 65535, got %q", name, value))
+		}
+	}
+
+	nonNegative := func(value int64, name string) {
+		if value < 0 {
+			errs = append(errs, fmt.Sprintf("%s must not be negative", name))
+		}
+	}
+
+	// Database.
+	required(c.Database.User, "database.user", "DB_USER")
+	required(c.Database.Host, "database.host", "DB_HOST")
+	required(c.Database.Port, "database.port", "DB_PORT")
+	required(c.Database.Name, "database.name", "DB_NAME")
+	port(c.Database.Port, "database.port")
+	nonNegative(int64(c.Database.MaxOpenConns), "database.max_open_conns")
+	nonNegative(int64(c.Database.MaxIdleConns), "database.max_idle_conns")
+	nonNegative(int64(c.Database.ConnMaxLifetime.Duration), "database.conn_max_lifetime")
+	nonNegative(int64(c.Database.ConnMaxIdleTime.Duration), "database.conn_max_idle_time")
+	nonNegative(int64(c.Database.DialTimeout.Duration), "database.dial_timeout")
+	nonNegative(int64(c.Database.ReadTimeout.Duration), "database.read_timeout")
+	nonNegative(int64(c.Database.WriteTimeout.Duration), "database.write_timeout")
+	nonNegative(int64(c.Database.ConnectBackoff.Duration), "database.connect_backoff")
+	nonNegative(int64(c.Database.ConnectMaxBackoff.Duration), "database.connect_max_backoff")
+
+	if c.Database.ConnectAttempts < 1 {
+		errs = append(errs, "database.connect_attempts must be at least 1")
+	}
+
+	switch c.Database.TLS {
+	case "false", "true", "skip-verify", "preferred":
+	default:
+		errs = append(errs, fmt.Sprintf("database.tls must be one of false, true, skip-verify or preferred, got %q", c.Database.TLS))
+	}
+
+	if _, err := os.Stat(c.Database.TLSCAFile); c.Database.TLSCAFile != "" && err != nil {
+		errs = append(errs, fmt.Sprintf("TLS file %q cannot be read: %s", c.Database.TLSCAFile, err))
+	}
+
+	// Router.
+	required(c.Router.Port, "router.port", "ROUTER_PORT")
+	port(c.Router.Port, "router.port")
+	nonNegative(int64(c.Router.ReadTimeout.Duration), "router.read_timeout")
+	nonNegative(int64(c.Router.ReadHeaderTimeout.Duration), "router.read_header_timeout")
+	nonNegative(int64(c.Router.WriteTimeout.Duration), "router.write_timeout")
+	nonNegative(int64(c.Router.IdleTimeout.Duration), "router.idle_timeout")
+	nonNegative(int64(c.Router.ShutdownTimeout.Duration), "router.shutdown_timeout")
+	nonNegative(int64(c.Router.RequestTimeout.Duration), "router.request_timeout")
+	nonNegative(int64(c.Router.TransferTimeout.Duration), "router.transfer_timeout")
+
+	if _, err := ParseRouteTimeouts(c.Router.RequestTimeoutRoutes); err != nil {
+		errs = append(errs, "router.request_timeout_routes: "+err.Error())
+	}
+
+	if (c.Router.TLSCertFile == "") != (c.Router.TLSKeyFile == "") {
+		errs = append(errs, "router.tls_cert_file and router.tls_key_file must be set together")
+	}
+
+	for _, v := range []string{c.Router.TLSCertFile, c.Router.TLSKeyFile} {
+		if _, err := os.Stat(v); v != "" && err != nil {
+			errs = append(errs, fmt.Sprintf("TLS file %q cannot be read: %s", v, err))
+		}
+	}
+
+	// gRPC.
+	if c.Features.GRPC {
+		required(c.GRPC.Port, "grpc.port", "GRPC_PORT")
+		port(c.GRPC.Port, "grpc.port")
+
+		if c.GRPC.Port != "" && c.GRPC.Host == c.Router.Host && c.GRPC.Port == c.Router.Port {
+			errs = append(errs, "grpc.port must differ from router.port")
+		}
+	}
+
+	// GraphQL.
+	nonNegative(int64(c.GraphQL.MaxDepth), "graphql.max_depth")
+	nonNegative(int64(c.GraphQL.MaxComplexity), "graphql.max_complexity")
+
+	// WebSocket.
+	if c.Features.WebSocket {
+		required(c.Auth.TokenSecret, "auth.token_secret", "AUTH_TOKEN_SECRET")
+	}
+
+	if c.WebSocket.PingInterval.Duration <= 0 {
+		errs = append(errs, "websocket.ping_interval must be positive")
+	}
+	if c.WebSocket.WriteTimeout.Duration <= 0 {
+		errs = append(errs, "websocket.write_timeout must be positive")
+	}
+	if c.WebSocket.Buffer < 1 {
+		errs = append(errs, "websocket.buffer must be at least 1")
+	}
+
+	// Events.
+	positive := func(value time.Duration, name string) {
+		if value <= 0 {
+			errs = append(errs, fmt.Sprintf("%s must be positive", name))
+		}
+	}
+
+	positive(c.Events.PollInterval.Duration, "events.poll_interval")
+	positive(c.Events.Lease.Duration, "events.lease")
+	positive(c.Events.RetryBackoff.Duration, "events.retry_backoff")
+	positive(c.Events.WebhookTimeout.Duration, "events.webhook_timeout")
+
+	if c.Events.MaxRetryBackoff.Duration < c.Events.RetryBackoff.Duration {
+		errs = append(errs, "events.max_retry_backoff must not be less than events.retry_backoff")
+	}
+	if c.Events.BatchSize < 1 {
+		errs = append(errs, "events.batch_size must be at least 1")
+	}
+	if c.Events.MaxAttempts < 1 {
+		errs = append(errs, "events.max_attempts must be at least 1")
+	}
+
+	for _, v := range c.EventWebhooks() {
+		if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("events.webhooks must be http or https URLs, got %q", v))
+		}
+	}
+
+	// Webhooks.
+	positive(c.Webhooks.PollInterval.Duration, "webhooks.poll_interval")
+	positive(c.Webhooks.Lease.Duration, "webhooks.lease")
+	positive(c.Webhooks.RetryBackoff.Duration, "webhooks.retry_backoff")
+	positive(c.Webhooks.Timeout.Duration, "webhooks.timeout")
+
+	if c.Webhooks.MaxRetryBackoff.Duration < c.Webhooks.RetryBackoff.Duration {
+		errs = append(errs, "webhooks.max_retry_backoff must not be less than webhooks.retry_backoff")
+	}
+	if c.Webhooks.BatchSize < 1 {
+		errs = append(errs, "webhooks.batch_size must be at least 1")
+	}
+	if c.Webhooks.Concurrency < 1 {
+		errs = append(errs, "webhooks.concurrency must be at least 1")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		errs = append(errs, "webhooks.max_attempts must be at least 1")
+	}
+
+	// Cache.
+	positive(c.Cache.TTL.Duration, "cache.ttl")
+
+	if c.Cache.Size < 1 {
+		errs = append(errs, "cache.size must be at least 1")
+	}
+
+	// Rate limit.
+	switch c.RateLimit.KeyBy {
+	case RATE_LIMIT_KEY_IP, RATE_LIMIT_KEY_API_KEY, RATE_LIMIT_KEY_TEACHER:
+	default:
+		errs = append(errs, fmt.Sprintf("rate_limit.key_by must be one of ip, api_key or teacher, got %q", c.RateLimit.KeyBy))
+	}
+
+	if c.RateLimit.Default != "" {
+		if _, err := ParseRateLimit(c.RateLimit.Default); err != nil {
+			errs = append(errs, "rate_limit.default: "+err.Error())
+		}
+	}
+
+	if _, err := ParseRateLimitRoutes(c.RateLimit.Routes); err != nil {
+		errs = append(errs, "rate_limit.routes: "+err.Error())
+	}
+
+	// Log.
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Sprintf("log.level must be one of debug, info, warn or error, got %q", c.Log.Level))
+	}
+
+	// API.
+	date := func(value string, name string) {
+		if _, err := ParseDate(value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s must be a date such as 2027-01-31, got %q", name, value))
+		}
+	}
+
+	date(c.API.V1Deprecation, "api.v1_deprecation")
+	date(c.API.V1Sunset, "api.v1_sunset")
+
+	// Auth.
+	if c.Auth.AdminKey != "" && len(c.Auth.AdminKey) < MIN_SECRET_LENGTH {
+		errs = append(errs, fmt.Sprintf("auth.admin_key must be at least %d characters long", MIN_SECRET_LENGTH))
+	}
+	if c.Auth.TokenSecret != "" && len(c.Auth.TokenSecret) < MIN_SECRET_LENGTH {
+		errs = append(errs, fmt.Sprintf("auth.token_secret must be at least %d characters long", MIN_SECRET_LENGTH))
+	}
+
+	return errs
+}
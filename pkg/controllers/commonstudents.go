@@ -1,18 +1,18 @@
 package controllers
 
 import (
-	"database/sql"
 	"net/http"
-	"sort"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/go-sql-driver/mysql"
 
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
 	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
 )
 
-func RegisterCommonStudentsEndpoint(r *gin.Engine) {
-	r.GET("/api/commonstudents", CommonStudents)
+func RegisterCommonStudentsEndpoint(r gin.IRouter) {
+	r.GET("/commonstudents", CommonStudents)
 }
 
 /*
@@ -21,48 +21,27 @@ It returns all students common to a given list of teachers.
 */
 func CommonStudents(c *gin.Context) {
 	teachers := c.QueryArray("teacher")
-	db := c.MustGet("db").(*sql.DB)
+	service := c.MustGet("service").(*services.Service)
 
 	// Return error reponse if no "teacher" query parameter is given.
 	if len(teachers) == 0 {
-		var parameters = []string{"teacher"}
-
-		c.JSON(http.StatusBadRequest, gin.H{"message": messages.MissingQueryParamsMessage(parameters)})
+		middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_MISSING_QUERY_PARAMS,
+			problems.Field("teacher", messages.FIELD_CODE_REQUIRED, "")))
 		return
 	}
 
-	var query string
-	var student string
-	var students []string
-
-	// Build query string to get students registered to all teachers in the list.
-	for i, v := range teachers {
-		if i > 0 {
-			query += " INTERSECT "
-		}
-		query += `SELECT student
-				  FROM teaches
-				  WHERE teacher = "` + v + `"`
-	}
-
-	// Query DB to get all students.
-	result, err := db.Query(query)
+	// Query DB to get all students registered to all teachers in the list.
+	students, err := service.CommonStudents(c.Request.Context(), teachers)
 
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"message": messages.MESSAGE_DATABASE_ERROR})
+		databaseError(c, http.StatusBadGateway, err)
 		return
 	}
 
-	for result.Next() {
-		err := result.Scan(&student)
-
-		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"message": messages.MESSAGE_DATABASE_ERROR})
-			return
-		}
-		students = append(students, student)
+	// Return an empty list rather than null if no student is common to the teachers.
+	if students == nil {
+		students = []string{}
 	}
-	sort.Strings(students)
 
 	c.JSON(http.StatusOK, gin.H{"students": students})
 }
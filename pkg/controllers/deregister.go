@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/models/request"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func RegisterDeregisterEndpoint(r gin.IRouter) {
+	r.POST("/deregister", Deregister)
+}
+
+/*
+This function handles a POST request to the "/api/v2/deregister" endpoint.
+It deregisters a list of students from a teacher, and returns the students
+which were registered to the teacher.
+*/
+func Deregister(c *gin.Context) {
+	var request request.DeregisterRequest
+	service := c.MustGet("service").(*services.Service)
+
+	// Return error response if missing or invalid request body fields.
+	if err := c.ShouldBindJSON(&request); err != nil {
+		middlewares.AbortWithProblem(c, problems.FromBindError(err))
+		return
+	}
+
+	// Return error response if the list of students is empty.
+	if len(request.Students) == 0 {
+		middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_VALIDATION_FAILED,
+			problems.Field("students", messages.FIELD_CODE_REQUIRED, "")))
+		return
+	}
+
+	removed, err := service.DeregisterStudents(c.Request.Context(), request.Teacher, request.Students)
+
+	// Return error response if there is an error while querying the DB.
+	if err != nil {
+		databaseError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Return an empty list rather than null if none of the students were registered to the teacher.
+	if removed == nil {
+		removed = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"students": removed})
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/server/openapi"
+)
+
+func RegisterDocsEndpoints(r *gin.Engine) {
+	spec, err := openapi.JSON()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	r.GET("/openapi.json", func(c *gin.Context) {
+		OpenAPI(c, spec)
+	})
+	r.GET("/docs", Docs)
+	r.StaticFS("/docs/assets", http.FS(openapi.SwaggerUIAssets))
+}
+
+/*
+This function handles a GET request to the "/openapi.json" endpoint.
+It returns the OpenAPI 3 document of the API.
+*/
+func OpenAPI(c *gin.Context, spec []byte) {
+	c.Data(http.StatusOK, "application/json", spec)
+}
+
+/*
+This function handles a GET request to the "/docs" endpoint.
+It returns the Swagger UI page of the OpenAPI document.
+*/
+func Docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.SwaggerUI)
+}
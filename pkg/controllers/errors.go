@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+/*
+Writes the error response for an error returned by the store.
+Returns 504 if the deadline of the request was reached, nothing if the client
+has disconnected, and the given status otherwise.
+*/
+func databaseError(c *gin.Context, status int, err error) {
+	if problem := databaseProblem(c, status, err); problem != nil {
+		middlewares.AbortWithProblem(c, problem)
+		return
+	}
+
+	c.Abort()
+}
+
+// Returns the problem for an error returned by the store, or nil if the client has disconnected.
+func databaseProblem(c *gin.Context, status int, err error) *response.Problem {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(c.Request.Context(), "request deadline exceeded", "error", err)
+		return problems.New(http.StatusGatewayTimeout, messages.CODE_TIMEOUT, messages.MESSAGE_TIMEOUT)
+	case errors.Is(err, context.Canceled):
+		slog.InfoContext(c.Request.Context(), "request cancelled by client", "error", err)
+		return nil
+	default:
+		slog.ErrorContext(c.Request.Context(), "database error", "error", err)
+		return problems.New(status, messages.CODE_DATABASE_ERROR, messages.MESSAGE_DATABASE_ERROR)
+	}
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/exports"
+	"govtech/pkg/models/request"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func RegisterExportEndpoints(r gin.IRouter) {
+	for _, v := range exports.RESOURCES {
+		middlewares.RegisterTransferRoute(r, http.MethodGet, "/export/"+v, Export(v))
+	}
+}
+
+/*
+This function returns the handler of a GET request to the "/api/export/{resource}" endpoint of the relation.
+It streams the rows of the relation matching the "teacher", "class" and "suspended" filters,
+as CSV or JSON Lines depending on the "format" query parameter, CSV by default.
+*/
+func Export(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request request.ExportRequest
+		store := c.MustGet("store").(database.Store)
+
+		// Return error response if invalid query parameters.
+		if err := c.ShouldBindQuery(&request); err != nil {
+			middlewares.AbortWithProblem(c, problems.FromBindError(err))
+			return
+		}
+
+		filter := database.ExportFilter{Teacher: request.Teacher, Class: request.Class}
+
+		// Return error response if the suspended filter is not a boolean.
+		if value := c.Query("suspended"); value != "" {
+			suspended, err := strconv.ParseBool(value)
+			if err != nil {
+				middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+					problems.Field("suspended", messages.FIELD_CODE_TYPE, "boolean")))
+				return
+			}
+			filter.Suspended = &suspended
+		}
+
+		format := request.Format
+		if format == "" {
+			format = exports.FORMAT_CSV
+		}
+
+		// The response starts once the first rows are written, so that errors of the query are returned as problems.
+		response := &exportResponse{c: c, format: format, filename: resource + "." + format}
+		n, err := exports.Export(c.Request.Context(), store, resource, filter, exports.NewWriter(format, response))
+		if err != nil && !response.started {
+			databaseError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Abort the connection if the export fails midway, so that the client does not take
+		// the rows written so far for the whole export.
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "export failed", "resource", resource, "rows", n, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+
+		// Write the headers of exports without any bytes, eg. empty JSON Lines.
+		if !response.started {
+			response.Write(nil)
+		}
+	}
+}
+
+// Writer of the body of an export, which writes the headers of the response before its first bytes.
+type exportResponse struct {
+	c        *gin.Context
+	format   string
+	filename string
+	started  bool
+}
+
+func (w *exportResponse) Write(data []byte) (int, error) {
+	if !w.started {
+		w.c.Header("Content-Type", exports.ContentType(w.format))
+		w.c.Header("Content-Disposition", `attachment; filename="`+w.filename+`"`)
+		w.c.Status(http.StatusOK)
+		w.started = true
+	}
+
+	return w.c.Writer.Write(data)
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/gql"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func RegisterGraphQLEndpoint(r *gin.Engine, limits *gql.Limits) {
+	handler, err := gql.NewHandler(*limits)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	r.POST("/graphql", func(c *gin.Context) {
+		GraphQL(c, handler)
+	})
+}
+
+/*
+This function handles a POST request to the "/graphql" endpoint.
+It executes a GraphQL query over teachers, students, classes and notifications.
+Errors of the query are returned in the "errors" of the result with status 200.
+*/
+func GraphQL(c *gin.Context, handler *gql.Handler) {
+	var request gql.Request
+	store := c.MustGet("store").(database.Store)
+	service := c.MustGet("service").(*services.Service)
+
+	// Return error response if the request body is not a GraphQL request.
+	if err := c.ShouldBindJSON(&request); err != nil {
+		middlewares.AbortWithProblem(c, problems.FromBindError(err))
+		return
+	}
+
+	lang := c.GetString("language")
+	if lang == "" {
+		lang = messages.LANGUAGE_DEFAULT
+	}
+
+	c.JSON(http.StatusOK, handler.Execute(c.Request.Context(), store, service, &request, lang))
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Maximum duration of the DB ping of a readiness check.
+const READINESS_TIMEOUT = 2 * time.Second
+
+func RegisterHealthEndpoints(r *gin.Engine) {
+	r.GET("/healthz", Healthz)
+	r.GET("/readyz", Readyz)
+}
+
+/*
+This function handles a GET request to the "/healthz" endpoint.
+It returns status code 200 as long as the server is able to handle requests.
+*/
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+/*
+This function handles a GET request to the "/readyz" endpoint.
+It returns status code 200 if the store can be reached, and 503 otherwise.
+*/
+func Readyz(c *gin.Context) {
+	store := c.MustGet("store").(database.Store)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), READINESS_TIMEOUT)
+	defer cancel()
+
+	if err := store.Ping(ctx); err != nil {
+		slog.WarnContext(c.Request.Context(), "readiness check failed", "error", err)
+		middlewares.AbortWithProblem(c, problems.New(http.StatusServiceUnavailable, messages.CODE_NOT_READY, messages.MESSAGE_NOT_READY))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
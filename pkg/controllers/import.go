@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/imports"
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func RegisterImportEndpoint(r gin.IRouter) {
+	middlewares.RegisterTransferRoute(r, http.MethodPost, "/import/registrations", ImportRegistrations)
+}
+
+/*
+This function handles a POST request to the "/api/import/registrations" endpoint.
+It registers students to teachers from a CSV body of rows "teacher,student[,class]",
+and returns the number of registrations and the errors of invalid rows.
+Nothing is written if the "dry_run" query parameter is true.
+*/
+func ImportRegistrations(c *gin.Context) {
+	service := c.MustGet("service").(*services.Service)
+
+	// Return error response if the body is not CSV.
+	if !middlewares.IsStreamingUpload(c.Request) {
+		middlewares.AbortWithProblem(c, problems.New(http.StatusUnsupportedMediaType, messages.CODE_BAD_REQUEST, messages.MESSAGE_CSV_REQUIRED))
+		return
+	}
+
+	// Return error response if the dry run parameter is not a boolean.
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+				problems.Field("dry_run", messages.FIELD_CODE_TYPE, "boolean")))
+			return
+		}
+	}
+
+	report, err := imports.Registrations(c.Request.Context(), c.Request.Body, service, imports.Options{DryRun: dryRun})
+
+	// Return error response with the report of the rows read so far if there is an error while reading
+	// the body or querying the DB, as the chunks applied before the error stay applied.
+	if err != nil {
+		problem := importProblem(c, err)
+		if problem == nil {
+			c.Abort()
+			return
+		}
+
+		result := report.Response(c.GetString("language"))
+		problem.Report = &result
+		middlewares.AbortWithProblem(c, problem)
+		return
+	}
+
+	c.JSON(http.StatusOK, report.Response(c.GetString("language")))
+}
+
+/*
+Returns the problem for an error of an import, or nil if the client has disconnected.
+Returns 408 if the body was not sent in time, 400 if it could not be read otherwise,
+and the problem of a DB error if applying the rows failed.
+*/
+func importProblem(c *gin.Context, err error) *response.Problem {
+	var readError *imports.ReadError
+	if !errors.As(err, &readError) {
+		return databaseProblem(c, http.StatusInternalServerError, err)
+	}
+
+	// The body cannot be read once the request is cancelled, eg. as the client has disconnected.
+	if ctxErr := c.Request.Context().Err(); ctxErr != nil {
+		return databaseProblem(c, http.StatusInternalServerError, ctxErr)
+	}
+
+	slog.WarnContext(c.Request.Context(), "failed to read import", "error", err)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return problems.New(http.StatusRequestTimeout, messages.CODE_TIMEOUT, messages.MESSAGE_TIMEOUT)
+	}
+
+	return problems.New(http.StatusBadRequest, messages.CODE_BAD_REQUEST, messages.MESSAGE_UNREADABLE_BODY)
+}
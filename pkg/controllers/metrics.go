@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"govtech/pkg/server/metrics"
+)
+
+func RegisterMetricsEndpoint(r *gin.Engine) {
+	r.GET("/metrics", Metrics)
+}
+
+/*
+This function handles a GET request to the "/metrics" endpoint.
+It returns all metrics in the Prometheus text format.
+*/
+func Metrics(c *gin.Context) {
+	handler := promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+	handler.ServeHTTP(c.Writer, c.Request)
+}
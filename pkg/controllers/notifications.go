@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/models/response"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+	"govtech/pkg/utilities/problems"
+)
+
+// Header of the ID of the last event received by a client resuming a stream.
+const HEADER_LAST_EVENT_ID = "Last-Event-ID"
+
+// Name of the events of the notification stream.
+const EVENT_NOTIFICATION = "notification"
+
+// Interval between comments sent to keep idle streams open through proxies.
+const STREAM_HEARTBEAT_INTERVAL = 15 * time.Second
+
+// Delay before clients reconnect to a closed stream.
+const STREAM_RETRY_MILLISECONDS = 1000
+
+func RegisterNotificationStreamEndpoint(r gin.IRouter) {
+	middlewares.RegisterStreamingRoute(r, "/students/:email/notifications/stream", NotificationStream)
+}
+
+/*
+This function handles a GET request to the "/api/students/{email}/notifications/stream" endpoint.
+It streams the notifications received by the student as server-sent events until the client disconnects.
+Clients authenticate as the student with a token, so that only the student reads its notifications.
+Clients resuming the stream with the Last-Event-ID header first receive the notifications they missed.
+*/
+func NotificationStream(c *gin.Context) {
+	student := c.Param("email")
+	service := c.MustGet("service").(*services.Service)
+
+	// Return error response if the student is not an email.
+	if !patterns.IsEmail(student) {
+		middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+			problems.Field("email", messages.FIELD_CODE_EMAIL, "")))
+		return
+	}
+
+	// Return error response if the token does not authenticate the student.
+	if !middlewares.RequireToken(c, student) {
+		return
+	}
+
+	// Return error response if the ID of the last event is not a notification ID.
+	var lastEventID int64 = -1
+	if value := c.GetHeader(HEADER_LAST_EVENT_ID); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 0 {
+			middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+				problems.Field(HEADER_LAST_EVENT_ID, messages.FIELD_CODE_FORMAT, "")))
+			return
+		}
+		lastEventID = id
+	}
+
+	// Subscribe before reading the history, so that no notification is missed in between.
+	subscription := service.SubscribeNotifications(student)
+	defer subscription.Close()
+
+	ctx := c.Request.Context()
+	started := false
+	start := func() {
+		if !started {
+			c.Header("Content-Type", sse.ContentType)
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			started = true
+		}
+	}
+
+	send := func(v database.Notification) error {
+		start()
+		lastEventID = v.ID
+
+		c.Render(-1, sse.Event{
+			Event: EVENT_NOTIFICATION,
+			Id:    strconv.FormatInt(v.ID, 10),
+			Data: response.NotificationEvent{
+				ID:           v.ID,
+				Teacher:      v.Teacher,
+				Notification: v.Text,
+				CreatedAt:    v.CreatedAt,
+			},
+		})
+		c.Writer.Flush()
+
+		return ctx.Err()
+	}
+
+	// Send the notifications missed by the client.
+	if lastEventID >= 0 {
+		if err := service.NotificationsSince(ctx, student, lastEventID, send); err != nil {
+			if !started {
+				databaseError(c, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	// Ask clients to reconnect after a second if the stream is closed.
+	start()
+	c.Writer.WriteString("retry: " + strconv.Itoa(STREAM_RETRY_MILLISECONDS) + "\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(STREAM_HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			c.Writer.WriteString(": heartbeat\n\n")
+			c.Writer.Flush()
+		case v, ok := <-subscription.Events():
+			// The subscription is closed if the client falls behind.
+			// The client reconnects and resumes from the history.
+			if !ok {
+				return
+			}
+
+			// Skip notifications already sent from the history.
+			if v.ID <= lastEventID {
+				continue
+			}
+
+			if send(v) != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,46 +1,66 @@
 package controllers
 
 import (
-	"database/sql"
 	"net/http"
-	"regexp"
-	"sort"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/go-sql-driver/mysql"
 
 	"govtech/pkg/models/request"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
 	"govtech/pkg/utilities/messages"
 	"govtech/pkg/utilities/patterns"
-	"govtech/pkg/utilities/set"
+	"govtech/pkg/utilities/problems"
 )
 
-func RegisterRetrieveForNotificationEndpoint(r *gin.Engine) {
-	r.POST("/api/retrievefornotifications", RetrieveForNotifications)
+func RegisterRetrieveForNotificationEndpoint(r gin.IRouter) {
+	r.POST("/retrievefornotifications", RetrieveForNotifications)
+}
+
+func RegisterRetrieveForNotificationEndpointV2(r gin.IRouter) {
+	r.POST("/retrievefornotifications", RetrieveForNotificationsV2)
+}
+
+/*
+This function handles a POST request to the "/api/v1/retrievefornotifications" endpoint.
+It returns all students who can receive a notification from a teacher as "recipient".
+*/
+func RetrieveForNotifications(c *gin.Context) {
+	recipients, ok := retrieveForNotifications(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipient": recipients})
 }
 
 /*
-This function handles a POST request to the "/api/retrievefornotifications" endpoint.
-It returns all students who can receive a notification from a teacher.
+This function handles a POST request to the "/api/v2/retrievefornotifications" endpoint.
+It returns all students who can receive a notification from a teacher as "recipients".
+*/
+func RetrieveForNotificationsV2(c *gin.Context) {
+	recipients, ok := retrieveForNotifications(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recipients": recipients})
+}
+
+/*
+Returns all students who can receive a notification from a teacher.
 A student can receive a notification if he is not suspended and is registered to the teacher
 or is mentioned in the notification.
+Writes the error response and returns false if the request is invalid or the DB fails.
 */
-
-func RetrieveForNotifications(c *gin.Context) {
+func retrieveForNotifications(c *gin.Context) ([]string, bool) {
 	var request request.ReceieveForNotificationsRequest
-	db := c.MustGet("db").(*sql.DB)
+	service := c.MustGet("service").(*services.Service)
 
 	// Return error response if missing or invalid request body fields.
 	if err := c.ShouldBindJSON(&request); err != nil {
-		bindErr, paramErr := messages.GetErrorMessage(err)
-
-		if bindErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": bindErr})
-			return
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"message": paramErr})
-			return
-		}
+		middlewares.AbortWithProblem(c, problems.FromBindError(err))
+		return nil, false
 	}
 
 	// Check if notifications follow the regexp pattern.
@@ -51,60 +71,16 @@ func RetrieveForNotifications(c *gin.Context) {
 
 	// Return error if notification string does not follow the regexp pattern.
 	if !match {
-		var fields = []string{"notification"}
-
-		c.JSON(http.StatusBadRequest, gin.H{"message": messages.InvalidParamsMessage(fields)})
-		return
+		middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+			problems.Field("notification", messages.FIELD_CODE_FORMAT, "")))
+		return nil, false
 	}
 
-	set := set.New[string]()
-	var student string
-
-	// Get all students registered under the teacher who are not suspended.
-	result, err := db.Query(`SELECT student
-							 FROM students 
-							 INNER JOIN teaches
-							 ON students.email = teaches.student
-							 WHERE students.suspended = 0
-							 AND teaches.teacher = (?)`, request.Teacher)
-
+	recipients, err := service.RetrieveForNotifications(c.Request.Context(), request.Teacher, request.Notification)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": messages.MESSAGE_DATABASE_ERROR})
-		return
-	}
-
-	for result.Next() {
-		err := result.Scan(&student)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": messages.MESSAGE_DATABASE_ERROR})
-			return
-		}
-		set.Add(student)
-	}
-
-	// Get all tagged students in the notification who are not
-	// suspended and are in the database.
-	var count int
-	regex := regexp.MustCompile(patterns.REGEX_PATTERN_EMAIL)
-	taggedStudents := regex.FindAllString(request.Notification, -1)
-	for _, v := range taggedStudents {
-		err := db.QueryRow(`SELECT COUNT(*)
-							FROM students
-							WHERE suspended = 0
-							AND email = (?)`, v).Scan(&count)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": messages.MESSAGE_DATABASE_ERROR})
-			return
-		}
-
-		// Add student to set if it exists.
-		if count == 1 {
-			set.Add(v)
-		}
+		databaseError(c, http.StatusInternalServerError, err)
+		return nil, false
 	}
-	array := set.ToArray()
-	sort.Strings(array)
 
-	c.JSON(http.StatusOK, gin.H{"recipient": array})
+	return recipients, true
 }
@@ -1,18 +1,18 @@
 package controllers
 
 import (
-	"database/sql"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/go-sql-driver/mysql"
 
 	"govtech/pkg/models/request"
-	"govtech/pkg/utilities/messages"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/problems"
 )
 
-func RegisterSuspendEndpoint(r *gin.Engine) {
-	r.POST("/api/suspend", Suspend)
+func RegisterSuspendEndpoint(r gin.IRouter) {
+	r.POST("/suspend", Suspend)
 }
 
 /*
@@ -21,29 +21,20 @@ It suspends the specified student.
 */
 func Suspend(c *gin.Context) {
 	var request request.SuspendRequest
-	db := c.MustGet("db").(*sql.DB)
+	service := c.MustGet("service").(*services.Service)
 
 	// Return error response if missing or invalid request body fields.
 	if err := c.ShouldBindJSON(&request); err != nil {
-		bindErr, paramErr := messages.GetErrorMessage(err)
-
-		if bindErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"message": bindErr})
-			return
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"message": paramErr})
-			return
-		}
+		middlewares.AbortWithProblem(c, problems.FromBindError(err))
+		return
 	}
 
 	// Update `suspended` field of specified student to 1 to indicate suspension.
-	_, err := db.Query(`UPDATE students
-						SET suspended = 1
-						WHERE email = (?)`, request.Student)
+	err := service.Suspend(c.Request.Context(), request.Student)
 
 	// Return error response if there is an error while querying the DB.
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": messages.MESSAGE_DATABASE_ERROR})
+		databaseError(c, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/ws"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func RegisterTeacherEventsEndpoint(r *gin.Engine, config *ws.Config) {
+	upgrader := websocket.Upgrader{
+		// Subscriptions are authenticated by tokens in messages instead of cookies,
+		// so dashboards can be served from any origin.
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	middlewares.RegisterStreamingRoute(r, "/ws/teachers", func(c *gin.Context) {
+		TeacherEvents(c, &upgrader, config)
+	})
+}
+
+/*
+This function handles a GET request to the "/ws/teachers" endpoint.
+It upgrades the request to a WebSocket, on which clients subscribe to the changes
+to the students of teachers with a token of each teacher.
+*/
+func TeacherEvents(c *gin.Context, upgrader *websocket.Upgrader, config *ws.Config) {
+	service := c.MustGet("service").(*services.Service)
+
+	// Return error response if the request is not a WebSocket handshake.
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		c.Header("Upgrade", "websocket")
+		middlewares.AbortWithProblem(c, problems.New(http.StatusUpgradeRequired, messages.CODE_BAD_REQUEST, messages.MESSAGE_WEBSOCKET_REQUIRED))
+		return
+	}
+
+	// The upgrader writes the error response if the handshake fails.
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	// The context of streaming routes is done once the server shuts down.
+	ws.Serve(c.Request.Context(), conn, service, c.GetString("language"), config)
+}
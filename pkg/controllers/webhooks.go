@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/models/request"
+	"govtech/pkg/models/response"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+	"govtech/pkg/webhooks"
+)
+
+// Number of the latest deliveries returned for a webhook.
+const WEBHOOK_DELIVERIES_LIMIT = 50
+
+// Client sending pings to webhooks, which refuses private addresses unless replaced with UseWebhookPingClient.
+var webhookPingClient = webhooks.NewClient(webhooks.PING_TIMEOUT, false)
+
+// Sets the client sending pings to webhooks. Must be called before the router serves requests.
+func UseWebhookPingClient(client *http.Client) {
+	webhookPingClient = client
+}
+
+// Registers the endpoints managing webhooks, which require the admin key.
+func RegisterWebhookEndpoints(r gin.IRouter) {
+	g := r.Group("/webhooks", middlewares.RequireAdminKey)
+	g.POST("", CreateWebhook)
+	g.GET("", ListWebhooks)
+	g.GET("/:id", GetWebhook)
+	g.DELETE("/:id", DeleteWebhook)
+	g.GET("/:id/deliveries", WebhookDeliveries)
+	g.POST("/:id/ping", PingWebhook)
+}
+
+/*
+This function handles a POST request to the "/api/webhooks" endpoint.
+It registers a webhook receiving the events of the given types, signed with the given secret.
+*/
+func CreateWebhook(c *gin.Context) {
+	var request request.CreateWebhookRequest
+	store := c.MustGet("store").(database.Store)
+
+	// Return error response if missing or invalid request body fields.
+	if err := c.ShouldBindJSON(&request); err != nil {
+		middlewares.AbortWithProblem(c, problems.FromBindError(err))
+		return
+	}
+
+	// Return error response if the URL is not an http or https URL.
+	if !webhooks.IsHTTPURL(request.URL) {
+		middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_VALIDATION_FAILED,
+			problems.Field("url", messages.FIELD_CODE_FORMAT, "")))
+		return
+	}
+
+	webhook, err := store.CreateWebhook(c.Request.Context(), request.URL, request.Events, request.Secret)
+
+	// Return error response if there is an error while querying the DB.
+	if err != nil {
+		databaseError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Location", c.Request.URL.Path+"/"+strconv.FormatInt(webhook.ID, 10))
+	c.JSON(http.StatusCreated, webhookResponse(webhook))
+}
+
+/*
+This function handles a GET request to the "/api/webhooks" endpoint.
+It returns every registered webhook.
+*/
+func ListWebhooks(c *gin.Context) {
+	store := c.MustGet("store").(database.Store)
+
+	list, err := store.Webhooks(c.Request.Context())
+
+	// Return error response if there is an error while querying the DB.
+	if err != nil {
+		databaseError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]response.Webhook, len(list))
+	for i, v := range list {
+		result[i] = webhookResponse(v)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": result})
+}
+
+/*
+This function handles a GET request to the "/api/webhooks/{id}" endpoint.
+It returns the specified webhook.
+*/
+func GetWebhook(c *gin.Context) {
+	store := c.MustGet("store").(database.Store)
+
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := store.Webhook(c.Request.Context(), id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookResponse(webhook))
+}
+
+/*
+This function handles a DELETE request to the "/api/webhooks/{id}" endpoint.
+It deletes the specified webhook, which no longer receives events, and its deliveries.
+*/
+func DeleteWebhook(c *gin.Context) {
+	store := c.MustGet("store").(database.Store)
+
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := store.DeleteWebhook(c.Request.Context(), id)
+	if err == nil && !deleted {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+/*
+This function handles a GET request to the "/api/webhooks/{id}/deliveries" endpoint.
+It returns the latest deliveries of the specified webhook with their attempts, latest first.
+*/
+func WebhookDeliveries(c *gin.Context) {
+	store := c.MustGet("store").(database.Store)
+	ctx := c.Request.Context()
+
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	// Return error response if there is no such webhook.
+	if _, err := store.Webhook(ctx, id); err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	deliveries, err := store.DeliveriesOf(ctx, id, WEBHOOK_DELIVERIES_LIMIT)
+	if err != nil {
+		databaseError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]response.WebhookDelivery, len(deliveries))
+	for i, v := range deliveries {
+		result[i] = deliveryResponse(v)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": result})
+}
+
+/*
+This function handles a POST request to the "/api/webhooks/{id}/ping" endpoint.
+It sends a signed "ping" event to the specified webhook once, and returns the delivery with its attempt.
+The response is 200 whether or not the webhook accepted the ping, as reported by the status of the delivery.
+*/
+func PingWebhook(c *gin.Context) {
+	store := c.MustGet("store").(database.Store)
+	ctx := c.Request.Context()
+
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	payload, err := webhooks.PingPayload()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode ping", "error", err)
+		middlewares.AbortWithProblem(c, problems.New(http.StatusInternalServerError, messages.CODE_INTERNAL_ERROR, messages.MESSAGE_INTERNAL_ERROR))
+		return
+	}
+
+	delivery, err := store.CreatePingDelivery(ctx, id, payload, time.Now().UTC().Add(webhooks.PING_LEASE))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	attempt, err := webhooks.Ping(ctx, store, webhookPingClient, delivery)
+	if err != nil {
+		databaseError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	delivery.Status = webhooks.STATUS_SUCCEEDED
+	if !attempt.Succeeded() {
+		delivery.Status = webhooks.STATUS_FAILED
+	}
+	delivery.Attempts++
+	delivery.History = []webhooks.Attempt{attempt}
+
+	c.JSON(http.StatusOK, deliveryResponse(delivery))
+}
+
+// Returns the ID of the webhook of the path, or writes the error response if it is not an ID.
+func webhookID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		middlewares.AbortWithProblem(c, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+			problems.Field("id", messages.FIELD_CODE_FORMAT, "")))
+		return 0, false
+	}
+
+	return id, true
+}
+
+// Writes the error response for an error returned by the store for a webhook.
+func webhookError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		middlewares.AbortWithProblem(c, problems.New(http.StatusNotFound, messages.CODE_NOT_FOUND, messages.MESSAGE_WEBHOOK_NOT_FOUND))
+		return
+	}
+
+	databaseError(c, http.StatusInternalServerError, err)
+}
+
+func webhookResponse(webhook webhooks.Webhook) response.Webhook {
+	return response.Webhook{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+func deliveryResponse(delivery webhooks.Delivery) response.WebhookDelivery {
+	result := response.WebhookDelivery{
+		ID:        delivery.ID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Status:    delivery.Status,
+		CreatedAt: delivery.CreatedAt,
+		Attempts:  make([]response.WebhookAttempt, len(delivery.History)),
+	}
+	if delivery.Status == webhooks.STATUS_PENDING {
+		result.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	for i, v := range delivery.History {
+		result.Attempts[i] = response.WebhookAttempt{
+			AttemptedAt:    v.AttemptedAt,
+			ResponseStatus: v.ResponseStatus,
+			Error:          v.Error,
+			DurationMs:     v.Duration.Milliseconds(),
+		}
+	}
+
+	return result
+}
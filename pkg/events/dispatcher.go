@@ -0,0 +1,218 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"govtech/pkg/config"
+	"govtech/pkg/server/metrics"
+)
+
+// Results of deliveries, used to label metrics.
+const RESULT_SUCCESS = "success"
+const RESULT_FAILURE = "failure"
+
+// Handles an event delivered to a subscriber.
+// Events are delivered at least once, so handlers must ignore events they have already handled.
+type Handler func(ctx context.Context, event Event) error
+
+// Structure for an event claimed from the outbox to be dispatched.
+type Pending struct {
+	Event Event
+	// Number of previous attempts to dispatch the event.
+	Attempts int
+	// Subscribers which have already handled the event.
+	Delivered []string
+}
+
+// Outbox of the events to dispatch, eg. the store.
+type Outbox interface {
+	// Claims up to limit events which are due, in order, so that other dispatchers skip them until lease.
+	ClaimEvents(ctx context.Context, now time.Time, lease time.Time, limit int) ([]Pending, error)
+	// Records that the subscriber has handled the event.
+	MarkDelivered(ctx context.Context, id int64, subscriber string) error
+	// Records that every subscriber has handled the event.
+	MarkDispatched(ctx context.Context, id int64) error
+	// Records a failed attempt, the event is due again at retryAt.
+	RetryEvent(ctx context.Context, id int64, retryAt time.Time, reason string) error
+	// Records the last failed attempt, after which the event is dead and no longer dispatched.
+	KillEvent(ctx context.Context, id int64, reason string) error
+	// Returns the number of dead events.
+	CountDeadEvents(ctx context.Context) (int, error)
+}
+
+// Structure for the configuration of the dispatcher.
+type DispatcherConfig struct {
+	// Interval between polls of the outbox, events written by this server are dispatched without waiting.
+	PollInterval time.Duration
+	// Maximum number of events claimed at once.
+	BatchSize int
+	// Duration of a claim, after which events are dispatched again if they could not be recorded.
+	Lease time.Duration
+	// Delay before the first retry of an event, doubled on every attempt up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Number of attempts after which an event is dead, and no longer dispatched to the subscribers which failed.
+	MaxAttempts int
+}
+
+// Returns the configuration of the dispatch of domain events.
+func NewDispatcherConfig(c config.EventsConfig) DispatcherConfig {
+	return DispatcherConfig{
+		PollInterval:    c.PollInterval.Duration,
+		BatchSize:       c.BatchSize,
+		Lease:           c.Lease.Duration,
+		RetryBackoff:    c.RetryBackoff.Duration,
+		MaxRetryBackoff: c.MaxRetryBackoff.Duration,
+		MaxAttempts:     c.MaxAttempts,
+	}
+}
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+/*
+Structure for the delivery of the events of the outbox to subscribers.
+An event is retried with backoff until every subscriber has handled it, or MaxAttempts have failed,
+and is not delivered again to the subscribers which have.
+*/
+type Dispatcher struct {
+	outbox      Outbox
+	config      DispatcherConfig
+	subscribers []subscriber
+	wake        chan struct{}
+}
+
+// Returns a dispatcher of the events of the outbox.
+func NewDispatcher(outbox Outbox, config DispatcherConfig) *Dispatcher {
+	return &Dispatcher{outbox: outbox, config: config, wake: make(chan struct{}, 1)}
+}
+
+/*
+Subscribes the handler to every event under a unique name, which records its deliveries.
+Subscribers must be added before the dispatcher runs.
+*/
+func (d *Dispatcher) Subscribe(name string, handler Handler) {
+	d.subscribers = append(d.subscribers, subscriber{name: name, handler: handler})
+}
+
+// Wakes the dispatcher up to dispatch events which were just written. Never blocks.
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatches events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	poll := time.NewTicker(d.config.PollInterval)
+	defer poll.Stop()
+
+	for {
+		n, err := d.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to dispatch events", "error", err)
+		}
+
+		// Continue without waiting while there may be more events due.
+		if err == nil && n == d.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Dispatches one batch of the events which are due, and returns the number of events claimed.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	pending, err := d.outbox.ClaimEvents(ctx, now, now.Add(d.config.Lease), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, v := range pending {
+		if err := d.dispatch(ctx, v); err != nil {
+			return len(pending), err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// Delivers the event to the subscribers which have not handled it, and records the result.
+func (d *Dispatcher) dispatch(ctx context.Context, pending Pending) error {
+	delivered := make(map[string]bool, len(pending.Delivered))
+	for _, v := range pending.Delivered {
+		delivered[v] = true
+	}
+
+	var failed error
+	for _, v := range d.subscribers {
+		if delivered[v.name] {
+			continue
+		}
+
+		if err := deliver(ctx, v.handler, pending.Event); err != nil {
+			metrics.EventDeliveries.WithLabelValues(v.name, RESULT_FAILURE).Inc()
+			slog.WarnContext(ctx, "failed to deliver event",
+				"event_id", pending.Event.ID, "type", pending.Event.Type, "subscriber", v.name,
+				"attempt", pending.Attempts+1, "error", err)
+			if failed == nil {
+				failed = fmt.Errorf("%s: %w", v.name, err)
+			}
+			continue
+		}
+		metrics.EventDeliveries.WithLabelValues(v.name, RESULT_SUCCESS).Inc()
+
+		if err := d.outbox.MarkDelivered(ctx, pending.Event.ID, v.name); err != nil {
+			return err
+		}
+	}
+
+	if failed != nil {
+		if pending.Attempts+1 >= d.config.MaxAttempts {
+			slog.ErrorContext(ctx, "event is dead after its last attempt",
+				"event_id", pending.Event.ID, "type", pending.Event.Type, "attempts", pending.Attempts+1, "error", failed)
+			return d.outbox.KillEvent(ctx, pending.Event.ID, failed.Error())
+		}
+
+		retryAt := time.Now().UTC().Add(Backoff(d.config.RetryBackoff, d.config.MaxRetryBackoff, pending.Attempts))
+		return d.outbox.RetryEvent(ctx, pending.Event.ID, retryAt, failed.Error())
+	}
+
+	return d.outbox.MarkDispatched(ctx, pending.Event.ID)
+}
+
+// Returns the delay before a retry after the given number of previous attempts,
+// starting at initial and doubled on every attempt up to maxBackoff.
+func Backoff(initial time.Duration, maxBackoff time.Duration, attempts int) time.Duration {
+	backoff := initial
+	for i := 0; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxBackoff)
+}
+
+// Calls the handler with the event, returning panics of the handler as errors.
+func deliver(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+
+	return handler(ctx, event)
+}
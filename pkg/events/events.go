@@ -0,0 +1,53 @@
+package events
+
+import "time"
+
+// Types of domain events.
+const TYPE_STUDENT_REGISTERED = "student_registered"
+const TYPE_STUDENT_DEREGISTERED = "student_deregistered"
+const TYPE_STUDENT_SUSPENDED = "student_suspended"
+const TYPE_STUDENT_UNSUSPENDED = "student_unsuspended"
+const TYPE_NOTIFICATION_ISSUED = "notification_issued"
+
+// Types of events of changes to the students of teachers.
+var STUDENT_TYPES = []string{TYPE_STUDENT_REGISTERED, TYPE_STUDENT_DEREGISTERED, TYPE_STUDENT_SUSPENDED, TYPE_STUDENT_UNSUSPENDED}
+
+/*
+Structure for a domain event, written to the outbox in the same transaction as the change.
+Events of changes to students have the teachers whose students changed, eg. the suspension of a
+student has every teacher the student is registered to.
+Notifications have the teacher who sent them, and their recipients as the students.
+*/
+type Event struct {
+	// ID of the event in the outbox, increasing in the order events are written.
+	// Subscribers use it to ignore events delivered more than once.
+	ID       int64    `json:"id"`
+	Type     string   `json:"type"`
+	Teachers []string `json:"teachers"`
+	Students []string `json:"students"`
+	// Notification of notification events.
+	NotificationID int64     `json:"notification_id,omitempty"`
+	Notification   string    `json:"notification,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// Returns an event of the given type which occurred now.
+func New(eventType string, teachers []string, students []string) Event {
+	return Event{
+		Type:       eventType,
+		Teachers:   teachers,
+		Students:   students,
+		OccurredAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// Returns true if the event is a change to the students of its teachers.
+func (e Event) IsStudentChange() bool {
+	for _, v := range STUDENT_TYPES {
+		if e.Type == v {
+			return true
+		}
+	}
+
+	return false
+}
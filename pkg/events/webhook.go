@@ -0,0 +1,56 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Headers of the requests delivering events to webhooks.
+const HEADER_EVENT_ID = "X-Event-ID"
+const HEADER_EVENT_TYPE = "X-Event-Type"
+
+// Returns the name of the subscriber of the webhook at url, which stays the same across restarts.
+func WebhookName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+
+	return "webhook:" + hex.EncodeToString(sum[:8])
+}
+
+/*
+Returns a handler posting events as JSON to the URL of a webhook.
+The delivery fails unless the webhook responds with a 2xx status.
+*/
+func Webhook(client *http.Client, url string) Handler {
+	return func(ctx context.Context, event Event) error {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(HEADER_EVENT_ID, strconv.FormatInt(event.ID, 10))
+		req.Header.Set(HEADER_EVENT_TYPE, event.Type)
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+		}
+
+		return nil
+	}
+}
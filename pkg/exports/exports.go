@@ -0,0 +1,210 @@
+package exports
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	database "govtech/pkg/server/databases"
+)
+
+// Formats of exports.
+const FORMAT_CSV = "csv"
+const FORMAT_NDJSON = "ndjson"
+
+// Content types of the formats.
+const MIME_CSV = "text/csv; charset=utf-8"
+const MIME_NDJSON = "application/x-ndjson"
+
+// Relations which are exported.
+const RESOURCE_TEACHERS = "teachers"
+const RESOURCE_STUDENTS = "students"
+const RESOURCE_TEACHES = "teaches"
+const RESOURCE_NOTIFICATIONS = "notifications"
+
+// Relations which are exported, in order.
+var RESOURCES = []string{RESOURCE_TEACHERS, RESOURCE_STUDENTS, RESOURCE_TEACHES, RESOURCE_NOTIFICATIONS}
+
+// Columns of the rows of each relation, in order. They are the header of CSV and the keys of NDJSON.
+var columns = map[string][]string{
+	RESOURCE_TEACHERS:      {"email"},
+	RESOURCE_STUDENTS:      {"email", "suspended"},
+	RESOURCE_TEACHES:       {"teacher", "student", "class", "suspended"},
+	RESOURCE_NOTIFICATIONS: {"id", "teacher", "notification", "created_at", "student"},
+}
+
+// Source of the rows of the relations, eg. the store.
+type Source interface {
+	ExportTeachers(ctx context.Context, filter database.ExportFilter, fn func(string) error) error
+	ExportStudents(ctx context.Context, filter database.ExportFilter, fn func(database.Student) error) error
+	ExportTeaches(ctx context.Context, filter database.ExportFilter, fn func(database.RosterEntry) error) error
+	ExportNotifications(ctx context.Context, filter database.ExportFilter, fn func(database.NotificationRecipient) error) error
+}
+
+// Writes rows in a format, buffered until Flush.
+type Writer interface {
+	// Writes the columns of the rows, before any row.
+	WriteHeader(columns []string) error
+	// Writes a row of values of the columns, which are strings, booleans, integers or times.
+	WriteRow(values []any) error
+	Flush() error
+}
+
+// Returns true if the format is supported.
+func IsFormat(format string) bool {
+	return format == FORMAT_CSV || format == FORMAT_NDJSON
+}
+
+// Returns true if the relation is exported.
+func IsResource(resource string) bool {
+	_, ok := columns[resource]
+	return ok
+}
+
+// Returns the content type of the format.
+func ContentType(format string) string {
+	if format == FORMAT_NDJSON {
+		return MIME_NDJSON
+	}
+
+	return MIME_CSV
+}
+
+// Returns a writer of the format to w. The format must be supported.
+func NewWriter(format string, w io.Writer) Writer {
+	if format == FORMAT_NDJSON {
+		return &ndjsonWriter{w: bufio.NewWriter(w)}
+	}
+
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+/*
+Writes the rows of the relation matching the filter to the writer, one by one as they are read,
+so that memory stays flat for any number of rows. Returns the number of rows written.
+The relation must be exported. The writer is flushed, whether or not writing the rows fails.
+*/
+func Export(ctx context.Context, source Source, resource string, filter database.ExportFilter, w Writer) (int, error) {
+	n := 0
+	write := func(values ...any) error {
+		n++
+		return w.WriteRow(values)
+	}
+
+	err := w.WriteHeader(columns[resource])
+	if err == nil {
+		switch resource {
+		case RESOURCE_TEACHERS:
+			err = source.ExportTeachers(ctx, filter, func(v string) error {
+				return write(v)
+			})
+		case RESOURCE_STUDENTS:
+			err = source.ExportStudents(ctx, filter, func(v database.Student) error {
+				return write(v.Email, v.Suspended)
+			})
+		case RESOURCE_TEACHES:
+			err = source.ExportTeaches(ctx, filter, func(v database.RosterEntry) error {
+				return write(v.Teacher, v.Student, v.Class, v.Suspended)
+			})
+		case RESOURCE_NOTIFICATIONS:
+			err = source.ExportNotifications(ctx, filter, func(v database.NotificationRecipient) error {
+				return write(v.ID, v.Teacher, v.Text, v.CreatedAt, v.Student)
+			})
+		}
+	}
+
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return n, err
+}
+
+// Writer of CSV, with a header row.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (w *csvWriter) WriteHeader(columns []string) error {
+	return w.w.Write(columns)
+}
+
+func (w *csvWriter) WriteRow(values []any) error {
+	w.record = w.record[:0]
+	for _, v := range values {
+		w.record = append(w.record, csvValue(v))
+	}
+
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+/*
+Returns the value as a CSV field.
+Strings starting with a character which spreadsheets read as a formula are prefixed with a quote,
+so that text written by clients, eg. notifications, is never run when the file is opened.
+Leading "+" and "-" are kept, as emails can start with them and would be corrupted by the quote.
+*/
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+
+	panic("unsupported export value")
+}
+
+// Writer of JSON Lines, with one object per row keyed by the columns, in order.
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func (w *ndjsonWriter) WriteHeader(columns []string) error {
+	w.columns = columns
+	return nil
+}
+
+func (w *ndjsonWriter) WriteRow(values []any) error {
+	w.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.w.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(w.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.w.Write(key)
+		w.w.WriteByte(':')
+		w.w.Write(value)
+	}
+	w.w.WriteByte('}')
+
+	return w.w.WriteByte('\n')
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,197 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/utilities/set"
+)
+
+// Default number of students in each class.
+const DEFAULT_CLASS_SIZE = 30
+
+// Default number of subject teachers of each class, besides its form teacher.
+const DEFAULT_SUBJECT_TEACHERS = 4
+
+// Default fractions of students registered to a teacher outside of their class, and of suspended students.
+const DEFAULT_ELECTIVE_RATE = 0.1
+const DEFAULT_SUSPENDED_RATE = 0.02
+
+// Default domain of the emails, students have emails at the "students" subdomain.
+const DEFAULT_DOMAIN = "school.edu.sg"
+
+// Number of registrations applied in each transaction when a school is loaded.
+const CHUNK_SIZE = 500
+
+// Names the emails are made of, with a number so that every email is unique.
+var firstNames = []string{
+	"wei", "jun", "hui", "ling", "ahmad", "nur", "siti", "arjun", "priya", "kumar",
+	"mei", "jia", "hafiz", "aisyah", "ravi", "divya", "ethan", "chloe", "ryan", "sarah",
+}
+var lastNames = []string{
+	"tan", "lim", "lee", "ng", "ong", "wong", "goh", "chua", "koh", "teo",
+	"rahman", "ismail", "abdullah", "pillai", "nair", "singh", "fernandez", "yeo", "chan", "low",
+}
+
+// Structure for the shape of a generated school.
+type Config struct {
+	// Schools generated with the same seed and config are the same.
+	Seed     int64
+	Teachers int
+	Students int
+	// Number of students in each class, DEFAULT_CLASS_SIZE if 0.
+	ClassSize int
+	// Number of subject teachers of each class besides its form teacher, DEFAULT_SUBJECT_TEACHERS if 0.
+	SubjectTeachers int
+	// Fractions of students registered to a teacher outside of their class, and of suspended students.
+	ElectiveRate  float64
+	SuspendedRate float64
+	// Domain of the emails, DEFAULT_DOMAIN if empty.
+	Domain string
+}
+
+// Structure for a generated school.
+type School struct {
+	Teachers []string
+	Students []database.Student
+	// Names of the classes, in order.
+	Classes []string
+	// Registrations of the students to the teachers of their class, in the class, and to the teachers of their electives.
+	Registrations []database.Registration
+}
+
+// Writes a school, eg. the store.
+type Loader interface {
+	// Applies the registrations in one transaction, and returns the number of students newly registered.
+	ImportRegistrations(ctx context.Context, registrations []database.Registration, dryRun bool) (int, error)
+	SuspendStudent(ctx context.Context, student string) (bool, error)
+}
+
+/*
+Generates a school from the config.
+Students are split into classes of ClassSize in order, and each class is taught by a form teacher and
+SubjectTeachers subject teachers, so that students have a few teachers and teachers have a few classes.
+Teachers are given classes in turn, so teachers beyond the number of teaching slots teach electives only,
+and may have no students. Some students also take an elective with another teacher, and some are suspended.
+*/
+func Generate(config Config) School {
+	classSize := config.ClassSize
+	if classSize <= 0 {
+		classSize = DEFAULT_CLASS_SIZE
+	}
+	subjectTeachers := config.SubjectTeachers
+	if subjectTeachers <= 0 {
+		subjectTeachers = DEFAULT_SUBJECT_TEACHERS
+	}
+	domain := config.Domain
+	if domain == "" {
+		domain = DEFAULT_DOMAIN
+	}
+
+	r := rand.New(rand.NewSource(config.Seed))
+	school := School{
+		Teachers: make([]string, config.Teachers),
+		Students: make([]database.Student, config.Students),
+	}
+
+	for i := range school.Teachers {
+		school.Teachers[i] = email(r, i, domain)
+	}
+	for i := range school.Students {
+		school.Students[i] = database.Student{Email: email(r, i, "students."+domain)}
+	}
+	if config.Teachers == 0 {
+		return school
+	}
+
+	// Teachers of each class, taken in turn from a shuffled order so that every teacher is given classes.
+	order := r.Perm(config.Teachers)
+	next := 0
+	teachersPerClass := min(1+subjectTeachers, config.Teachers)
+
+	for start := 0; start < config.Students; start += classSize {
+		class := className(len(school.Classes))
+		school.Classes = append(school.Classes, class)
+
+		teachers := make([]string, 0, teachersPerClass)
+		taught := set.New[string]()
+		for len(teachers) < teachersPerClass {
+			teacher := school.Teachers[order[next%config.Teachers]]
+			next++
+			if !taught.Contains(teacher) {
+				taught.Add(teacher)
+				teachers = append(teachers, teacher)
+			}
+		}
+
+		for i := start; i < min(start+classSize, config.Students); i++ {
+			student := &school.Students[i]
+			for _, teacher := range teachers {
+				school.Registrations = append(school.Registrations, database.Registration{Teacher: teacher, Student: student.Email, Class: class})
+			}
+
+			if r.Float64() < config.ElectiveRate && len(teachers) < config.Teachers {
+				teacher := school.Teachers[r.Intn(config.Teachers)]
+				for taught.Contains(teacher) {
+					teacher = school.Teachers[r.Intn(config.Teachers)]
+				}
+				school.Registrations = append(school.Registrations, database.Registration{Teacher: teacher, Student: student.Email})
+			}
+
+			student.Suspended = r.Float64() < config.SuspendedRate
+		}
+	}
+
+	return school
+}
+
+// Returns the suspended students of the school.
+func (s *School) Suspended() []string {
+	var students []string
+	for _, v := range s.Students {
+		if v.Suspended {
+			students = append(students, v.Email)
+		}
+	}
+
+	return students
+}
+
+/*
+Writes the registrations of the school in chunks of CHUNK_SIZE, then suspends its suspended students.
+Teachers and students are written with their registrations, so those without any are not written.
+Returns the number of students newly registered.
+*/
+func Load(ctx context.Context, loader Loader, school School) (int, error) {
+	registered := 0
+	for start := 0; start < len(school.Registrations); start += CHUNK_SIZE {
+		n, err := loader.ImportRegistrations(ctx, school.Registrations[start:min(start+CHUNK_SIZE, len(school.Registrations))], false)
+		if err != nil {
+			return registered, err
+		}
+		registered += n
+	}
+
+	for _, v := range school.Suspended() {
+		if _, err := loader.SuspendStudent(ctx, v); err != nil {
+			return registered, err
+		}
+	}
+
+	return registered, nil
+}
+
+// Returns the email of the i-th person at the domain.
+func email(r *rand.Rand, i int, domain string) string {
+	first := firstNames[r.Intn(len(firstNames))]
+	last := lastNames[r.Intn(len(lastNames))]
+
+	return fmt.Sprintf("%s.%s%d@%s", first, last, i+1, domain)
+}
+
+// Returns the name of the i-th class, eg. "1A" to "1J", then "2A".
+func className(i int) string {
+	return fmt.Sprintf("%d%c", i/10+1, 'A'+i%10)
+}
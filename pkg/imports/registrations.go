@@ -0,0 +1,243 @@
+package imports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"govtech/pkg/models/response"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+)
+
+// Number of valid rows applied in each transaction.
+const CHUNK_SIZE = 500
+
+// Maximum number of row errors reported, further errors are counted only.
+const MAX_ERRORS = 100
+
+// Maximum lengths of the fields of a row, the same as those of the register endpoint.
+const MAX_EMAIL_LENGTH = 60
+const MAX_CLASS_LENGTH = 60
+
+// Names of the columns of a row, in order, reported as the fields of row errors.
+const COLUMN_TEACHER = "teacher"
+const COLUMN_STUDENT = "student"
+const COLUMN_CLASS = "class"
+
+// Field of the errors of rows which cannot be parsed or have the wrong number of columns.
+const FIELD_ROW = "row"
+
+// Applies chunks of registrations, eg. the service.
+type Applier interface {
+	// Applies the registrations in one transaction, and returns the number of students newly registered.
+	ImportRegistrations(ctx context.Context, registrations []database.Registration, dryRun bool) (int, error)
+}
+
+// Structure for the options of an import.
+type Options struct {
+	// Validates and applies every chunk without writing anything.
+	DryRun bool
+	// Number of valid rows applied in each transaction, CHUNK_SIZE if 0.
+	ChunkSize int
+}
+
+// Structure for an invalid row, with the code and parameter of the field error as in problems.
+type RowError struct {
+	// Line of the row in the file, starting at 1.
+	Line  int
+	Field string
+	Code  string
+	Param string
+}
+
+// Error of reading the rows of an import, eg. as the client has stopped sending them.
+type ReadError struct {
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	return "failed to read rows: " + e.Err.Error()
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// Structure for the report of an import.
+type Report struct {
+	DryRun bool
+	// Number of rows read, without the header.
+	Rows int
+	// Number of students newly registered to teachers, and of valid rows which were already registered.
+	Registered int
+	Existing   int
+	// Number of invalid rows, which are not applied.
+	Invalid int
+	// Errors of the first MAX_ERRORS invalid rows, in order.
+	Errors          []RowError
+	ErrorsTruncated bool
+}
+
+/*
+Imports registrations from CSV rows of the form "teacher,student[,class]", with an optional header row.
+Rows are parsed and validated one by one as they are read, and valid rows are applied in chunks of
+one transaction each, so that memory stays flat for files of any size. Invalid rows are reported and skipped.
+If reading the rows or applying a chunk fails, the chunks applied before stay applied, and the report of the
+rows read so far is returned with the error, counting only the applied chunks as registered or existing.
+Errors of reading the rows are returned as a ReadError.
+In a dry run, chunks are applied independently and rolled back, so registrations repeated across chunks
+are reported as registered once per chunk.
+*/
+func Registrations(ctx context.Context, r io.Reader, applier Applier, options Options) (Report, error) {
+	report := Report{DryRun: options.DryRun}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = CHUNK_SIZE
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	chunk := make([]database.Registration, 0, chunkSize)
+	apply := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		n, err := applier.ImportRegistrations(ctx, chunk, options.DryRun)
+		if err != nil {
+			return err
+		}
+		report.Registered += n
+		report.Existing += len(chunk) - n
+		chunk = chunk[:0]
+
+		return nil
+	}
+
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			report.Rows++
+			report.addError(RowError{Line: parseError.StartLine, Field: FIELD_ROW, Code: messages.FIELD_CODE_FORMAT})
+			first = false
+			continue
+		} else if err != nil {
+			return report, &ReadError{Err: err}
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		// Skip the header row.
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), COLUMN_TEACHER) {
+			first = false
+			continue
+		}
+		first = false
+		report.Rows++
+
+		registration, errs := parseRow(line, record)
+		if len(errs) > 0 {
+			report.addError(errs...)
+			continue
+		}
+
+		chunk = append(chunk, registration)
+		if len(chunk) == chunkSize {
+			if err := apply(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, apply()
+}
+
+// Returns the registration of the row at the line, or the errors of its fields.
+func parseRow(line int, record []string) (database.Registration, []RowError) {
+	if len(record) < 2 || len(record) > 3 {
+		return database.Registration{}, []RowError{{Line: line, Field: FIELD_ROW, Code: messages.FIELD_CODE_FORMAT}}
+	}
+
+	registration := database.Registration{
+		Teacher: strings.TrimSpace(record[0]),
+		Student: strings.TrimSpace(record[1]),
+	}
+	if len(record) == 3 {
+		registration.Class = strings.TrimSpace(record[2])
+	}
+
+	var errs []RowError
+	for _, v := range []struct {
+		field string
+		value string
+	}{
+		{COLUMN_TEACHER, registration.Teacher},
+		{COLUMN_STUDENT, registration.Student},
+	} {
+		switch {
+		case v.value == "":
+			errs = append(errs, RowError{Line: line, Field: v.field, Code: messages.FIELD_CODE_REQUIRED})
+		case !patterns.IsEmail(v.value):
+			errs = append(errs, RowError{Line: line, Field: v.field, Code: messages.FIELD_CODE_EMAIL})
+		case len(v.value) > MAX_EMAIL_LENGTH:
+			errs = append(errs, RowError{Line: line, Field: v.field, Code: "max", Param: strconv.Itoa(MAX_EMAIL_LENGTH)})
+		}
+	}
+
+	if len(registration.Class) > MAX_CLASS_LENGTH {
+		errs = append(errs, RowError{Line: line, Field: COLUMN_CLASS, Code: "max", Param: strconv.Itoa(MAX_CLASS_LENGTH)})
+	}
+
+	return registration, errs
+}
+
+// Counts an invalid row, and reports its errors unless MAX_ERRORS errors are already reported.
+func (r *Report) addError(errs ...RowError) {
+	r.Invalid++
+
+	for _, v := range errs {
+		if len(r.Errors) == MAX_ERRORS {
+			r.ErrorsTruncated = true
+			return
+		}
+		r.Errors = append(r.Errors, v)
+	}
+}
+
+// Returns the report as a response, with the details of the errors in the given language.
+func (r *Report) Response(lang string) response.ImportReport {
+	result := response.ImportReport{
+		DryRun:          r.DryRun,
+		Rows:            r.Rows,
+		Registered:      r.Registered,
+		Existing:        r.Existing,
+		Invalid:         r.Invalid,
+		Errors:          make([]response.ImportRowError, len(r.Errors)),
+		ErrorsTruncated: r.ErrorsTruncated,
+	}
+	for i, v := range r.Errors {
+		result.Errors[i] = response.ImportRowError{
+			Row:    v.Line,
+			Field:  v.Field,
+			Code:   v.Code,
+			Detail: messages.FieldErrorMessage(lang, v.Code, v.Param),
+			Param:  v.Param,
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,10 @@
+package request
+
+/*
+Structure for "/api/v2/deregister" endpoint request body.
+At most 50 students can be deregistered in one request.
+*/
+type DeregisterRequest struct {
+	Teacher  string   `json:"teacher" binding:"required,email,max=60"`
+	Students []string `json:"students" binding:"required,max=50,dive,email,max=60"`
+}
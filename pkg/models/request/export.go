@@ -0,0 +1,11 @@
+package request
+
+/*
+Structure for the query parameters of the "/api/export/{resource}" endpoints.
+The suspended filter is parsed by the controller, as a boolean which may be absent.
+*/
+type ExportRequest struct {
+	Format  string `form:"format" binding:"omitempty,oneof=csv ndjson"`
+	Teacher string `form:"teacher" binding:"omitempty,email,max=60"`
+	Class   string `form:"class" binding:"omitempty,max=60"`
+}
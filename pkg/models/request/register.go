@@ -1,9 +1,59 @@
 package request
 
-// Structure for "/api/register" endpoint request body.
+import (
+	"github.com/go-playground/validator/v10"
+
+	"govtech/pkg/utilities/messages"
+)
+
+/*
+Structure for "/api/register" endpoint request body.
+The request body must have exactly one of the two shapes:
+a teacher with a list of students, or a student with a list of teachers.
+At most 50 emails can be registered in one request.
+*/
 type RegisterRequest struct {
-	Teacher  string   `json:"teacher"`
-	Teachers []string `json:"teachers"`
-	Student  string   `json:"student"`
-	Students []string `json:"students"`
+	Teacher  string   `json:"teacher" binding:"omitempty,email,max=60"`
+	Teachers []string `json:"teachers" binding:"omitempty,max=50,dive,email,max=60"`
+	Student  string   `json:"student" binding:"omitempty,email,max=60"`
+	Students []string `json:"students" binding:"omitempty,max=50,dive,email,max=60"`
+}
+
+// Returns true if the request body registers a list of students to a teacher.
+func (r *RegisterRequest) IsTeacherShape() bool {
+	return r.Teacher != "" || len(r.Students) > 0
+}
+
+// Returns true if the request body registers a list of teachers to a student.
+func (r *RegisterRequest) IsStudentShape() bool {
+	return r.Student != "" || len(r.Teachers) > 0
+}
+
+/*
+Struct-level validation of the register request body.
+Reports the fields of the second shape if both shapes are given, and the missing
+field of the pair if only one field of a shape is given.
+*/
+func validateRegisterRequest(sl validator.StructLevel) {
+	r := sl.Current().Interface().(RegisterRequest)
+
+	switch {
+	case r.IsTeacherShape() && r.IsStudentShape():
+		sl.ReportError(r.Student, "Student", "Student", messages.FIELD_CODE_EXCLUSIVE, "teacher")
+		sl.ReportError(r.Teachers, "Teachers", "Teachers", messages.FIELD_CODE_EXCLUSIVE, "teacher")
+	case r.IsStudentShape():
+		if r.Student == "" {
+			sl.ReportError(r.Student, "Student", "Student", messages.FIELD_CODE_REQUIRED, "")
+		}
+		if len(r.Teachers) == 0 {
+			sl.ReportError(r.Teachers, "Teachers", "Teachers", messages.FIELD_CODE_REQUIRED, "")
+		}
+	default:
+		if r.Teacher == "" {
+			sl.ReportError(r.Teacher, "Teacher", "Teacher", messages.FIELD_CODE_REQUIRED, "")
+		}
+		if len(r.Students) == 0 {
+			sl.ReportError(r.Students, "Students", "Students", messages.FIELD_CODE_REQUIRED, "")
+		}
+	}
 }
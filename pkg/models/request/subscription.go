@@ -0,0 +1,13 @@
+package request
+
+// Types of the messages sent by clients of the "/ws/teachers" endpoint.
+const MESSAGE_SUBSCRIBE = "subscribe"
+const MESSAGE_UNSUBSCRIBE = "unsubscribe"
+
+// Structure for the messages sent by clients of the "/ws/teachers" endpoint.
+type SubscriptionMessage struct {
+	Type    string `json:"type" binding:"required,oneof=subscribe unsubscribe"`
+	Teacher string `json:"teacher" binding:"required,email,max=60"`
+	// Token authenticating the client as the teacher, required to subscribe.
+	Token string `json:"token"`
+}
@@ -0,0 +1,29 @@
+package request
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+)
+
+/*
+Registers the validators of request bodies with the validator used by gin.
+The "email" tag is replaced so that requests accept the same emails as the OpenAPI document,
+imports and streams, rather than those of the built-in tag, eg. without a top-level domain.
+*/
+func init() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	engine.RegisterValidation(messages.FIELD_CODE_EMAIL, validateEmail)
+	engine.RegisterStructValidation(validateRegisterRequest, RegisterRequest{})
+}
+
+// Returns true if the field is an email following patterns.REGEX_PATTERN_EMAIL.
+func validateEmail(fl validator.FieldLevel) bool {
+	return patterns.IsEmail(fl.Field().String())
+}
@@ -0,0 +1,12 @@
+package request
+
+/*
+Structure for "/api/webhooks" endpoint request body.
+The webhook receives the events of the given types, or every event if there are none.
+The secret is shared with the receiver to check the signatures of deliveries, and is never returned.
+*/
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"omitempty,max=10,dive,oneof=student_registered student_deregistered student_suspended student_unsuspended notification_issued"`
+	Secret string   `json:"secret" binding:"required,min=16,max=255"`
+}
@@ -0,0 +1,27 @@
+package response
+
+// Structure for the report of an import of registrations.
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// Number of rows read, without the header.
+	Rows int `json:"rows"`
+	// Number of students newly registered to teachers, or which would be in a dry run.
+	Registered int `json:"registered"`
+	// Number of valid rows which were already registered.
+	Existing int `json:"existing"`
+	// Number of invalid rows, which are not applied.
+	Invalid int `json:"invalid"`
+	// Errors of the first invalid rows, in order.
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated"`
+}
+
+// Structure for an error of a row of an import, with the field error as in problems.
+type ImportRowError struct {
+	// Line of the row in the file, starting at 1.
+	Row    int    `json:"row"`
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+	Param  string `json:"param,omitempty"`
+}
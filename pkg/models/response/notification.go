@@ -0,0 +1,12 @@
+package response
+
+import "time"
+
+// Structure for the data of a notification event of the notification stream of a student.
+type NotificationEvent struct {
+	// ID of the notification, also the ID of the event used to resume the stream.
+	ID           int64     `json:"id"`
+	Teacher      string    `json:"teacher"`
+	Notification string    `json:"notification"`
+	CreatedAt    time.Time `json:"created_at"`
+}
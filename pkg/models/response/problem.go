@@ -0,0 +1,30 @@
+package response
+
+// Structure for error response bodies, following RFC 7807 "application/problem+json".
+type Problem struct {
+	// URI reference identifying the problem type, eg. "/problems/validation_failed".
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Stable machine-readable code of the problem type.
+	Code      string       `json:"code"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Report of an import which failed, with the rows read and applied before the failure.
+	Report *ImportReport `json:"report,omitempty"`
+	// Message code of the detail, translated into the language of the client.
+	DetailCode string `json:"-"`
+}
+
+// Structure for a problem with a single field of the request.
+type FieldError struct {
+	// Name of the field in the request, eg. "teacher" or "students[1]".
+	Field string `json:"field"`
+	// Stable machine-readable code of the problem, eg. "required" or "email".
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+	// Parameter of the validation, eg. "60" for "max=60", used in the detail.
+	Param string `json:"-"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+// Types of the messages sent to clients of the "/ws/teachers" endpoint, besides the types of events.
+const MESSAGE_SUBSCRIBED = "subscribed"
+const MESSAGE_UNSUBSCRIBED = "unsubscribed"
+const MESSAGE_ERROR = "error"
+
+/*
+Structure for the messages sent to clients of the "/ws/teachers" endpoint.
+Events have the type of the event, eg. "student_registered", and the students of the teacher which changed.
+Errors have the problem with the message of the client.
+*/
+type TeacherMessage struct {
+	Type       string     `json:"type"`
+	Teacher    string     `json:"teacher,omitempty"`
+	Students   []string   `json:"students,omitempty"`
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+	Problem    *Problem   `json:"problem,omitempty"`
+}
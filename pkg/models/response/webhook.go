@@ -0,0 +1,33 @@
+package response
+
+import "time"
+
+// Structure for a registered webhook, without its secret.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Structure for the delivery of an event to a webhook, with its attempts in order.
+type WebhookDelivery struct {
+	ID int64 `json:"id"`
+	// ID of the event in the outbox, absent for pings.
+	EventID   int64  `json:"event_id,omitempty"`
+	EventType string `json:"event_type"`
+	Status    string `json:"status"`
+	// Time of the next attempt of pending deliveries.
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+}
+
+// Structure for an attempt to deliver an event to a webhook.
+type WebhookAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	// Status of the response of the webhook, absent if there was none.
+	ResponseStatus int    `json:"response_status,omitempty"`
+	Error          string `json:"error,omitempty"`
+	DurationMs     int64  `json:"duration_ms"`
+}
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// Error returned for keys which are not the expected key.
+var ErrInvalidKey = errors.New("invalid key")
+
+// Returns an error unless the key is the expected key, which is never the case if the expected key is empty.
+func VerifyKey(expected string, key string) error {
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(key)) != 1 {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
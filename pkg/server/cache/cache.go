@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"govtech/pkg/config"
+	"govtech/pkg/server/metrics"
+)
+
+// Results of lookups, used to label metrics.
+const RESULT_HIT = "hit"
+const RESULT_MISS = "miss"
+
+/*
+Interface of a read-through cache of values by key.
+Values are tagged with what they are read from, eg. the teachers of a roster, so that they are
+invalidated once it changes.
+*/
+type Cache[V any] interface {
+	// Returns the cached value of the key, or the value returned by load, cached with the given tags.
+	// The value is not cached if load fails.
+	Load(ctx context.Context, key string, tags []string, load func(context.Context) (V, error)) (V, error)
+	// Removes the values with any of the tags.
+	Invalidate(tags ...string)
+}
+
+// Structure for the configuration of a cache in memory.
+type Config struct {
+	// Maximum number of cached values.
+	Size int
+	// Duration for which values are cached, bounding how long changes not invalidated are missed.
+	TTL time.Duration
+}
+
+// Returns the configuration of the caches of the service.
+func NewConfig(c config.CacheConfig) Config {
+	return Config{
+		Size: c.Size,
+		TTL:  c.TTL.Duration,
+	}
+}
+
+/*
+Structure for a cache in memory of up to a number of values, each cached until its TTL ends.
+The least recently used value is evicted to cache another one once the cache is full.
+*/
+type LRU[V any] struct {
+	// Name of the cache, used to label metrics.
+	name     string
+	capacity int
+	ttl      time.Duration
+
+	mu sync.Mutex
+	// Values by key, with the most recently used at the front of the list.
+	entries map[string]*list.Element
+	order   *list.List
+	// Keys of the values with each tag.
+	tagged map[string]map[string]struct{}
+	// Number of invalidations, so that values loaded during an invalidation are not cached.
+	version uint64
+}
+
+// Structure for a cached value.
+type entry[V any] struct {
+	key     string
+	value   V
+	tags    []string
+	expires time.Time
+}
+
+// Returns a cache of up to config.Size values, each cached for config.TTL.
+func NewLRU[V any](name string, config Config) *LRU[V] {
+	return &LRU[V]{
+		name:     name,
+		capacity: config.Size,
+		ttl:      config.TTL,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		tagged:   make(map[string]map[string]struct{}),
+	}
+}
+
+/*
+Returns the cached value of the key, or the value returned by load, cached with the given tags.
+The value is not cached if load fails, or if the cache was invalidated while it was loaded, as it
+may have been read before the change.
+*/
+func (c *LRU[V]) Load(ctx context.Context, key string, tags []string, load func(context.Context) (V, error)) (V, error) {
+	c.mu.Lock()
+	value, ok := c.get(key)
+	version := c.version
+	c.mu.Unlock()
+
+	if ok {
+		metrics.CacheLookups.WithLabelValues(c.name, RESULT_HIT).Inc()
+		return value, nil
+	}
+	metrics.CacheLookups.WithLabelValues(c.name, RESULT_MISS).Inc()
+
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.version == version {
+		c.set(key, value, tags)
+	}
+
+	return value, nil
+}
+
+// Removes the values with any of the tags.
+func (c *LRU[V]) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+
+	removed := 0
+	for _, tag := range tags {
+		for key := range c.tagged[tag] {
+			c.remove(c.entries[key])
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		metrics.CacheInvalidations.WithLabelValues(c.name).Add(float64(removed))
+	}
+}
+
+// Returns the number of cached values, including those whose TTL has ended.
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Returns the value of the key, and false if it is not cached or its TTL has ended.
+func (c *LRU[V]) get(key string) (V, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	e := element.Value.(*entry[V])
+	if !time.Now().Before(e.expires) {
+		c.remove(element)
+
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+
+	return e.value, true
+}
+
+// Caches the value of the key with the tags, and evicts the least recently used value if the cache is full.
+func (c *LRU[V]) set(key string, value V, tags []string) {
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, tags: tags, expires: time.Now().Add(c.ttl)})
+	for _, v := range tags {
+		if c.tagged[v] == nil {
+			c.tagged[v] = make(map[string]struct{})
+		}
+		c.tagged[v][key] = struct{}{}
+	}
+
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Removes the cached value of the element.
+func (c *LRU[V]) remove(element *list.Element) {
+	e := element.Value.(*entry[V])
+
+	c.order.Remove(element)
+	delete(c.entries, e.key)
+	for _, v := range e.tags {
+		delete(c.tagged[v], e.key)
+		if len(c.tagged[v]) == 0 {
+			delete(c.tagged, v)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Operations of the exports of the relations, used to label query logs and metrics.
+const OPERATION_EXPORT_TEACHERS = "export_teachers"
+const OPERATION_EXPORT_STUDENTS = "export_students"
+const OPERATION_EXPORT_TEACHES = "export_teaches"
+const OPERATION_EXPORT_NOTIFICATIONS = "export_notifications"
+
+/*
+Structure for the filters of an export, which are ignored if empty.
+Rows are exported if they are related to a registration of the given teacher,
+in the given class, of a student with the given suspended state.
+*/
+type ExportFilter struct {
+	Teacher   string
+	Class     string
+	Suspended *bool
+}
+
+// Structure for a registration of a student to a teacher, with the class of the registration if any.
+type RosterEntry struct {
+	Teacher   string
+	Student   string
+	Class     string
+	Suspended bool
+}
+
+// Structure for a recipient of a notification. The student is empty if the notification has no recipients.
+type NotificationRecipient struct {
+	Notification
+	Student string
+}
+
+/*
+Calls fn with every teacher, sorted by email.
+With a class or suspended filter, only teachers with a registration matching the filters are exported.
+*/
+func (s *MySQLStore) ExportTeachers(ctx context.Context, filter ExportFilter, fn func(string) error) error {
+	defer s.recordQuery(ctx, OPERATION_EXPORT_TEACHERS, time.Now())
+
+	var conditions []string
+	var args []any
+	if filter.Teacher != "" {
+		conditions = append(conditions, "teachers.email = ?")
+		args = append(args, filter.Teacher)
+	}
+	if filter.Class != "" || filter.Suspended != nil {
+		registrations, registrationArgs := registrationConditions(ExportFilter{Class: filter.Class, Suspended: filter.Suspended})
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM teaches
+							  INNER JOIN students ON students.email = teaches.student
+							  LEFT JOIN classes ON classes.teacher = teaches.teacher AND classes.student = teaches.student
+							  WHERE teaches.teacher = teachers.email AND `+registrations+`)`)
+		args = append(args, registrationArgs...)
+	}
+
+	return s.eachRow(ctx, `SELECT email
+				 FROM teachers`+where(conditions)+`
+				 ORDER BY email`, args, func(rows *sql.Rows) error {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+/*
+Calls fn with every student, sorted by email.
+With a teacher or class filter, only students with a registration matching the filters are exported.
+*/
+func (s *MySQLStore) ExportStudents(ctx context.Context, filter ExportFilter, fn func(Student) error) error {
+	defer s.recordQuery(ctx, OPERATION_EXPORT_STUDENTS, time.Now())
+
+	var conditions []string
+	var args []any
+	if filter.Suspended != nil {
+		conditions = append(conditions, "students.suspended = ?")
+		args = append(args, *filter.Suspended)
+	}
+	if filter.Teacher != "" || filter.Class != "" {
+		registrations, registrationArgs := registrationConditions(ExportFilter{Teacher: filter.Teacher, Class: filter.Class})
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM teaches
+							  LEFT JOIN classes ON classes.teacher = teaches.teacher AND classes.student = teaches.student
+							  WHERE teaches.student = students.email AND `+registrations+`)`)
+		args = append(args, registrationArgs...)
+	}
+
+	return s.eachRow(ctx, `SELECT email, suspended
+				 FROM students`+where(conditions)+`
+				 ORDER BY email`, args, func(rows *sql.Rows) error {
+		var v Student
+		if err := rows.Scan(&v.Email, &v.Suspended); err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+// Calls fn with every registration matching the filters, sorted by teacher then student.
+func (s *MySQLStore) ExportTeaches(ctx context.Context, filter ExportFilter, fn func(RosterEntry) error) error {
+	defer s.recordQuery(ctx, OPERATION_EXPORT_TEACHES, time.Now())
+
+	var conditions []string
+	var args []any
+	if filter != (ExportFilter{}) {
+		condition, conditionArgs := registrationConditions(filter)
+		conditions = append(conditions, condition)
+		args = conditionArgs
+	}
+
+	return s.eachRow(ctx, `SELECT teaches.teacher, teaches.student, COALESCE(classes.name, ''), students.suspended
+				 FROM teaches
+				 INNER JOIN students ON students.email = teaches.student
+				 LEFT JOIN classes ON classes.teacher = teaches.teacher AND classes.student = teaches.student`+
+		where(conditions)+`
+				 ORDER BY teaches.teacher, teaches.student`, args, func(rows *sql.Rows) error {
+		var v RosterEntry
+		if err := rows.Scan(&v.Teacher, &v.Student, &v.Class, &v.Suspended); err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+/*
+Calls fn with every recipient of every notification, sorted by notification then student.
+Notifications without recipients are exported once with an empty student, unless filtered by class or suspended.
+The teacher filter matches the teacher of the notification, the class filter matches recipients
+in the class of that teacher, and the suspended filter matches the current state of recipients.
+*/
+func (s *MySQLStore) ExportNotifications(ctx context.Context, filter ExportFilter, fn func(NotificationRecipient) error) error {
+	defer s.recordQuery(ctx, OPERATION_EXPORT_NOTIFICATIONS, time.Now())
+
+	var conditions []string
+	var args []any
+	if filter.Teacher != "" {
+		conditions = append(conditions, "notifications.teacher = ?")
+		args = append(args, filter.Teacher)
+	}
+	if filter.Class != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM classes
+							  WHERE classes.teacher = notifications.teacher
+							  AND classes.student = notification_recipients.student
+							  AND classes.name = ?)`)
+		args = append(args, filter.Class)
+	}
+	if filter.Suspended != nil {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM students
+							  WHERE students.email = notification_recipients.student
+							  AND students.suspended = ?)`)
+		args = append(args, *filter.Suspended)
+	}
+
+	return s.eachRow(ctx, `SELECT notifications.id, notifications.teacher, notifications.text,
+					notifications.created_at, COALESCE(notification_recipients.student, '')
+				 FROM notifications
+				 LEFT JOIN notification_recipients
+				 ON notification_recipients.notification_id = notifications.id`+where(conditions)+`
+				 ORDER BY notifications.id, notification_recipients.student`, args, func(rows *sql.Rows) error {
+		var v NotificationRecipient
+		if err := rows.Scan(&v.ID, &v.Teacher, &v.Text, &v.CreatedAt, &v.Student); err != nil {
+			return err
+		}
+		return fn(v)
+	})
+}
+
+/*
+Calls fn with every row of the query as it is read, so that memory stays flat for any number of rows.
+Stops at the first error returned by fn.
+*/
+func (s *MySQLStore) eachRow(ctx context.Context, query string, args []any, fn func(*sql.Rows) error) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Returns the conditions of the filter on the teaches, classes and students relations, joined by AND.
+func registrationConditions(filter ExportFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	if filter.Teacher != "" {
+		conditions = append(conditions, "teaches.teacher = ?")
+		args = append(args, filter.Teacher)
+	}
+	if filter.Class != "" {
+		conditions = append(conditions, "classes.name = ?")
+		args = append(args, filter.Class)
+	}
+	if filter.Suspended != nil {
+		conditions = append(conditions, "students.suspended = ?")
+		args = append(args, *filter.Suspended)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// Returns the WHERE clause of the conditions, or nothing if there are none.
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "\n				 WHERE " + strings.Join(conditions, "\n				 AND ")
+}
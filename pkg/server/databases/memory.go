@@ -0,0 +1,1011 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"govtech/pkg/events"
+	"govtech/pkg/webhooks"
+)
+
+/*
+Store of relations in memory, with the same results and events as the MySQL store.
+Every store is isolated, so that tests can each use their own store in parallel without a DB.
+Operations are atomic, and fail if their context is done before they start.
+Emails are compared case-insensitively like in MySQL, and returned as spelled when first stored.
+*/
+type MemoryStore struct {
+	mu       sync.Mutex
+	onEvents []func([]events.Event)
+	// Events written by the operation holding the lock, passed to the hooks once it unlocks.
+	written []events.Event
+
+	// Relations are keyed by the keys of emails, see emailKey.
+	teachers map[string]bool
+	// Suspended state of the students, by email.
+	students map[string]bool
+	// Students of the teachers, and classes of the registrations.
+	teaches map[string]map[string]bool
+	classes map[teaching]string
+	// Emails by key, as spelled when first stored.
+	emails map[string]string
+
+	notifications []memoryNotification
+	outbox        []memoryEvent
+
+	webhooks   []webhooks.Webhook
+	deliveries []webhooks.Delivery
+	// Last IDs of webhooks and deliveries.
+	webhookID  int64
+	deliveryID int64
+}
+
+// Structure for a registration of a student to a teacher, as a key.
+type teaching struct {
+	teacher string
+	student string
+}
+
+// Structure for a notification and its recipients, sorted by email.
+type memoryNotification struct {
+	Notification
+	recipients []string
+}
+
+// Structure for an event of the outbox, kept as JSON like in the DB.
+type memoryEvent struct {
+	id          int64
+	payload     []byte
+	availableAt time.Time
+	attempts    int
+	lastError   string
+	dispatched  bool
+	dead        bool
+	delivered   []string
+}
+
+// Returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		teachers: make(map[string]bool),
+		students: make(map[string]bool),
+		teaches:  make(map[string]map[string]bool),
+		classes:  make(map[teaching]string),
+		emails:   make(map[string]string),
+	}
+}
+
+// Returns nil, as the store is always reachable.
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+/*
+Adds fn to the functions called with the events written to the outbox by each operation,
+eg. to wake the dispatcher up or to invalidate caches.
+Must be called before the store is used.
+*/
+func (s *MemoryStore) OnEventsWritten(fn func([]events.Event)) {
+	s.onEvents = append(s.onEvents, fn)
+}
+
+// Registers a list of students to a teacher, and writes the students which were not yet registered to the outbox.
+func (s *MemoryStore) RegisterStudents(ctx context.Context, teacher string, students []string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	s.teachers[s.keep(teacher)] = true
+
+	var registered []string
+	for _, v := range students {
+		if s.register(teacher, v) {
+			registered = append(registered, v)
+		}
+	}
+	if len(registered) > 0 {
+		s.writeEvent(events.New(events.TYPE_STUDENT_REGISTERED, []string{teacher}, registered))
+	}
+
+	s.unlock()
+	return nil
+}
+
+// Registers a list of teachers to a student, and writes the teachers which were not yet registered to the outbox.
+func (s *MemoryStore) RegisterTeachers(ctx context.Context, student string, teachers []string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	key := s.keep(student)
+	s.students[key] = s.students[key]
+
+	var registered []string
+	for _, v := range teachers {
+		if s.register(v, student) {
+			registered = append(registered, v)
+		}
+	}
+	if len(registered) > 0 {
+		s.writeEvent(events.New(events.TYPE_STUDENT_REGISTERED, registered, []string{student}))
+	}
+
+	s.unlock()
+	return nil
+}
+
+/*
+Applies the registrations, and returns the number of students newly registered to teachers.
+The classes of registrations are set, replacing the previous class of the student with the teacher.
+Writes the students newly registered to each teacher to the outbox.
+If dryRun is true, nothing is written.
+*/
+func (s *MemoryStore) ImportRegistrations(ctx context.Context, registrations []Registration, dryRun bool) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+
+	// Students newly registered to each teacher, in the order of the teachers.
+	var teachers []string
+	registered := make(map[string][]string)
+	seen := make(map[teaching]bool)
+	n := 0
+
+	for _, v := range registrations {
+		key := teaching{emailKey(v.Teacher), emailKey(v.Student)}
+		inserted := !s.teaches[key.teacher][key.student] && !seen[key]
+		seen[key] = true
+		if inserted {
+			if _, ok := registered[key.teacher]; !ok {
+				teachers = append(teachers, key.teacher)
+			}
+			registered[key.teacher] = append(registered[key.teacher], v.Student)
+			n++
+		}
+	}
+
+	if dryRun {
+		s.unlock()
+		return n, nil
+	}
+
+	for _, v := range registrations {
+		s.register(v.Teacher, v.Student)
+		if v.Class != "" {
+			s.classes[teaching{emailKey(v.Teacher), emailKey(v.Student)}] = v.Class
+		}
+	}
+	for _, v := range teachers {
+		s.writeEvent(events.New(events.TYPE_STUDENT_REGISTERED, []string{s.emails[v]}, registered[v]))
+	}
+
+	s.unlock()
+	return n, nil
+}
+
+// Deregisters a list of students from a teacher, and returns the students which were registered, sorted by email.
+func (s *MemoryStore) DeregisterStudents(ctx context.Context, teacher string, students []string) ([]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+
+	key := emailKey(teacher)
+	var keys []string
+	for _, v := range students {
+		if k := emailKey(v); s.teaches[key][k] && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		s.unlock()
+		return nil, nil
+	}
+
+	sort.Strings(keys)
+	removed := s.emailsOf(keys)
+	for _, v := range keys {
+		delete(s.teaches[key], v)
+		delete(s.classes, teaching{key, v})
+	}
+	s.writeEvent(events.New(events.TYPE_STUDENT_DEREGISTERED, []string{teacher}, removed))
+
+	s.unlock()
+	return removed, nil
+}
+
+// Returns students registered to all of the given teachers, sorted by email.
+func (s *MemoryStore) CommonStudents(ctx context.Context, teachers []string) ([]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if len(teachers) == 0 {
+		return nil, nil
+	}
+
+	var result []string
+	for _, v := range sortedKeys(s.teaches[emailKey(teachers[0])]) {
+		common := true
+		for _, teacher := range teachers[1:] {
+			common = common && s.teaches[emailKey(teacher)][v]
+		}
+		if common {
+			result = append(result, s.emails[v])
+		}
+	}
+
+	return result, nil
+}
+
+// Suspends the given student, and writes the suspension to the outbox and returns true unless it changed nothing.
+func (s *MemoryStore) SuspendStudent(ctx context.Context, student string) (bool, error) {
+	return s.setSuspended(ctx, student, true, events.TYPE_STUDENT_SUSPENDED)
+}
+
+// Unsuspends the given student, and writes the unsuspension to the outbox and returns true unless it changed nothing.
+func (s *MemoryStore) UnsuspendStudent(ctx context.Context, student string) (bool, error) {
+	return s.setSuspended(ctx, student, false, events.TYPE_STUDENT_UNSUSPENDED)
+}
+
+// Sets the suspended state of the student, and writes an event of the given type and returns true if it changed.
+func (s *MemoryStore) setSuspended(ctx context.Context, student string, suspended bool, eventType string) (bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+
+	key := emailKey(student)
+	current, ok := s.students[key]
+	if !ok || current == suspended {
+		s.unlock()
+		return false, nil
+	}
+	s.students[key] = suspended
+
+	var teachers []string
+	for _, v := range sortedKeys(s.teaches) {
+		if s.teaches[v][key] {
+			teachers = append(teachers, s.emails[v])
+		}
+	}
+	s.writeEvent(events.New(eventType, teachers, []string{student}))
+
+	s.unlock()
+	return true, nil
+}
+
+// Returns students registered to the teacher who are not suspended, sorted by email.
+func (s *MemoryStore) ActiveStudentsOf(ctx context.Context, teacher string) ([]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var result []string
+	for _, v := range sortedKeys(s.teaches[emailKey(teacher)]) {
+		if !s.students[v] {
+			result = append(result, s.emails[v])
+		}
+	}
+
+	return result, nil
+}
+
+// Returns true if the student exists and is not suspended.
+func (s *MemoryStore) IsActiveStudent(ctx context.Context, student string) (bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	suspended, ok := s.students[emailKey(student)]
+
+	return ok && !suspended, nil
+}
+
+// Returns every teacher, sorted by email.
+func (s *MemoryStore) Teachers(ctx context.Context) ([]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	return s.emailsOf(sortedKeys(s.teachers)), nil
+}
+
+// Returns the given teachers which exist, sorted by email.
+func (s *MemoryStore) FindTeachers(ctx context.Context, teachers []string) ([]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var keys []string
+	for _, v := range teachers {
+		if k := emailKey(v); s.teachers[k] && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return s.emailsOf(keys), nil
+}
+
+// Returns every student, sorted by email.
+func (s *MemoryStore) Students(ctx context.Context) ([]Student, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var result []Student
+	for _, v := range sortedKeys(s.students) {
+		result = append(result, Student{Email: s.emails[v], Suspended: s.students[v]})
+	}
+
+	return result, nil
+}
+
+// Returns the given students which exist, sorted by email.
+func (s *MemoryStore) FindStudents(ctx context.Context, students []string) ([]Student, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	keys := make(map[string]bool, len(students))
+	for _, v := range students {
+		keys[emailKey(v)] = true
+	}
+
+	var result []Student
+	for _, v := range sortedKeys(s.students) {
+		if keys[v] {
+			result = append(result, Student{Email: s.emails[v], Suspended: s.students[v]})
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the students registered to each of the given teachers, sorted by email.
+func (s *MemoryStore) StudentsOfTeachers(ctx context.Context, teachers []string) (map[string][]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	result := make(map[string][]string)
+	for _, v := range teachers {
+		if students := sortedKeys(s.teaches[emailKey(v)]); len(students) > 0 {
+			result[v] = s.emailsOf(students)
+		}
+	}
+
+	return result, nil
+}
+
+// Returns the teachers registered to each of the given students, sorted by email.
+func (s *MemoryStore) TeachersOfStudents(ctx context.Context, students []string) (map[string][]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	result := make(map[string][]string)
+	for _, teacher := range sortedKeys(s.teaches) {
+		for _, v := range students {
+			if s.teaches[teacher][emailKey(v)] && !slices.Contains(result[v], s.emails[teacher]) {
+				result[v] = append(result[v], s.emails[teacher])
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Saves a notification and its recipients, and returns it with its ID. Writes the notification to the outbox.
+func (s *MemoryStore) SaveNotification(ctx context.Context, teacher string, text string, recipients []string) (Notification, error) {
+	notification := Notification{Teacher: teacher, Text: text, CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}
+	if err := s.lock(ctx); err != nil {
+		return notification, err
+	}
+
+	notification.ID = int64(len(s.notifications) + 1)
+
+	saved := memoryNotification{Notification: notification}
+	for _, v := range recipients {
+		if !slices.ContainsFunc(saved.recipients, func(recipient string) bool { return emailKey(recipient) == emailKey(v) }) {
+			saved.recipients = append(saved.recipients, v)
+		}
+	}
+	sort.Slice(saved.recipients, func(i, j int) bool {
+		return emailKey(saved.recipients[i]) < emailKey(saved.recipients[j])
+	})
+	s.notifications = append(s.notifications, saved)
+
+	event := events.New(events.TYPE_NOTIFICATION_ISSUED, []string{teacher}, recipients)
+	event.NotificationID = notification.ID
+	event.Notification = text
+	event.OccurredAt = notification.CreatedAt
+	s.writeEvent(event)
+
+	s.unlock()
+	return notification, nil
+}
+
+// Returns at most limit notifications received by the student after the notification with the given ID, in order.
+func (s *MemoryStore) NotificationsOf(ctx context.Context, student string, afterID int64, limit int) ([]Notification, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var result []Notification
+	for _, v := range s.notifications {
+		if len(result) == limit {
+			break
+		}
+		if v.ID > afterID && slices.ContainsFunc(v.recipients, func(recipient string) bool { return emailKey(recipient) == emailKey(student) }) {
+			result = append(result, v.Notification)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+Calls fn with every teacher, sorted by email.
+With a class or suspended filter, only teachers with a registration matching the filters are exported.
+*/
+func (s *MemoryStore) ExportTeachers(ctx context.Context, filter ExportFilter, fn func(string) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	var rows []string
+	for _, v := range sortedKeys(s.teachers) {
+		if filter.Teacher != "" && v != emailKey(filter.Teacher) {
+			continue
+		}
+		if filter.Class != "" || filter.Suspended != nil {
+			if !s.anyRegistration(ExportFilter{Teacher: v, Class: filter.Class, Suspended: filter.Suspended}) {
+				continue
+			}
+		}
+		rows = append(rows, s.emails[v])
+	}
+	s.mu.Unlock()
+
+	return each(rows, fn)
+}
+
+/*
+Calls fn with every student, sorted by email.
+With a teacher or class filter, only students with a registration matching the filters are exported.
+*/
+func (s *MemoryStore) ExportStudents(ctx context.Context, filter ExportFilter, fn func(Student) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	var rows []Student
+	for _, v := range sortedKeys(s.students) {
+		if filter.Suspended != nil && s.students[v] != *filter.Suspended {
+			continue
+		}
+		if filter.Teacher != "" || filter.Class != "" {
+			matches := false
+			for _, teacher := range sortedKeys(s.teaches) {
+				matches = matches || s.teaches[teacher][v] && s.matches(teacher, v, ExportFilter{Teacher: filter.Teacher, Class: filter.Class})
+			}
+			if !matches {
+				continue
+			}
+		}
+		rows = append(rows, Student{Email: s.emails[v], Suspended: s.students[v]})
+	}
+	s.mu.Unlock()
+
+	return each(rows, fn)
+}
+
+// Calls fn with every registration matching the filters, sorted by teacher then student.
+func (s *MemoryStore) ExportTeaches(ctx context.Context, filter ExportFilter, fn func(RosterEntry) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	var rows []RosterEntry
+	for _, teacher := range sortedKeys(s.teaches) {
+		for _, v := range sortedKeys(s.teaches[teacher]) {
+			if s.matches(teacher, v, filter) {
+				rows = append(rows, RosterEntry{Teacher: s.emails[teacher], Student: s.emails[v], Class: s.classes[teaching{teacher, v}], Suspended: s.students[v]})
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	return each(rows, fn)
+}
+
+/*
+Calls fn with every recipient of every notification, sorted by notification then student.
+Notifications without recipients are exported once with an empty student, unless filtered by class or suspended.
+*/
+func (s *MemoryStore) ExportNotifications(ctx context.Context, filter ExportFilter, fn func(NotificationRecipient) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+
+	var rows []NotificationRecipient
+	for _, v := range s.notifications {
+		if filter.Teacher != "" && emailKey(v.Teacher) != emailKey(filter.Teacher) {
+			continue
+		}
+		if len(v.recipients) == 0 && filter.Class == "" && filter.Suspended == nil {
+			rows = append(rows, NotificationRecipient{Notification: v.Notification})
+		}
+		for _, student := range v.recipients {
+			if filter.Class != "" && s.classes[teaching{emailKey(v.Teacher), emailKey(student)}] != filter.Class {
+				continue
+			}
+			if suspended, ok := s.students[emailKey(student)]; filter.Suspended != nil && (!ok || suspended != *filter.Suspended) {
+				continue
+			}
+			rows = append(rows, NotificationRecipient{Notification: v.Notification, Student: student})
+		}
+	}
+	s.mu.Unlock()
+
+	return each(rows, fn)
+}
+
+// Claims up to limit events which are due at now until lease, in order.
+func (s *MemoryStore) ClaimEvents(ctx context.Context, now time.Time, lease time.Time, limit int) ([]events.Pending, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var pending []events.Pending
+	for i := range s.outbox {
+		v := &s.outbox[i]
+		if len(pending) == limit {
+			break
+		}
+		if v.dispatched || v.dead || v.availableAt.After(now) {
+			continue
+		}
+
+		var event events.Event
+		if err := json.Unmarshal(v.payload, &event); err != nil {
+			return nil, err
+		}
+		event.ID = v.id
+		pending = append(pending, events.Pending{Event: event, Attempts: v.attempts, Delivered: slices.Clone(v.delivered)})
+		v.availableAt = lease
+	}
+
+	return pending, nil
+}
+
+// Records that the subscriber has handled the event.
+func (s *MemoryStore) MarkDelivered(ctx context.Context, id int64, subscriber string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if v := s.event(id); v != nil && !slices.Contains(v.delivered, subscriber) {
+		v.delivered = append(v.delivered, subscriber)
+	}
+
+	return nil
+}
+
+// Records that every subscriber has handled the event.
+func (s *MemoryStore) MarkDispatched(ctx context.Context, id int64) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if v := s.event(id); v != nil {
+		v.dispatched = true
+	}
+
+	return nil
+}
+
+// Records a failed attempt to dispatch the event, which is due again at retryAt.
+func (s *MemoryStore) RetryEvent(ctx context.Context, id int64, retryAt time.Time, reason string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if len(reason) > MAX_EVENT_ERROR_LENGTH {
+		reason = reason[:MAX_EVENT_ERROR_LENGTH]
+	}
+	if v := s.event(id); v != nil {
+		v.attempts++
+		v.availableAt = retryAt
+		v.lastError = reason
+	}
+
+	return nil
+}
+
+// Records the last failed attempt to dispatch the event, after which it is dead and no longer claimed.
+func (s *MemoryStore) KillEvent(ctx context.Context, id int64, reason string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if len(reason) > MAX_EVENT_ERROR_LENGTH {
+		reason = reason[:MAX_EVENT_ERROR_LENGTH]
+	}
+	if v := s.event(id); v != nil {
+		v.attempts++
+		v.dead = true
+		v.lastError = reason
+	}
+
+	return nil
+}
+
+// Returns the number of dead events.
+func (s *MemoryStore) CountDeadEvents(ctx context.Context) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, v := range s.outbox {
+		if v.dead {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// Registers a webhook receiving the events of the given types, or every event if there are none.
+func (s *MemoryStore) CreateWebhook(ctx context.Context, url string, eventTypes []string, secret string) (webhooks.Webhook, error) {
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	webhook := webhooks.Webhook{URL: url, Events: eventTypes, Secret: secret, CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}
+	if err := s.lock(ctx); err != nil {
+		return webhook, err
+	}
+	defer s.mu.Unlock()
+
+	s.webhookID++
+	webhook.ID = s.webhookID
+	s.webhooks = append(s.webhooks, webhook)
+
+	return webhook, nil
+}
+
+// Returns every registered webhook, in the order they were registered.
+func (s *MemoryStore) Webhooks(ctx context.Context) ([]webhooks.Webhook, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.webhooks), nil
+}
+
+// Returns the webhook with the given ID, or sql.ErrNoRows if there is none.
+func (s *MemoryStore) Webhook(ctx context.Context, id int64) (webhooks.Webhook, error) {
+	if err := s.lock(ctx); err != nil {
+		return webhooks.Webhook{}, err
+	}
+	defer s.mu.Unlock()
+
+	return s.webhook(id)
+}
+
+// Deletes the webhook with the given ID and its deliveries, and returns false if there was none.
+func (s *MemoryStore) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	n := len(s.webhooks)
+	s.webhooks = slices.DeleteFunc(s.webhooks, func(v webhooks.Webhook) bool { return v.ID == id })
+	s.deliveries = slices.DeleteFunc(s.deliveries, func(v webhooks.Delivery) bool { return v.WebhookID == id })
+
+	return len(s.webhooks) < n, nil
+}
+
+// Enqueues a delivery of the event to every webhook accepting it, once per webhook,
+// and returns the number of deliveries enqueued.
+func (s *MemoryStore) EnqueueDeliveries(ctx context.Context, event events.Event, payload []byte) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	n := 0
+	for _, v := range s.webhooks {
+		if len(v.Events) > 0 && !slices.Contains(v.Events, event.Type) {
+			continue
+		}
+		enqueued := slices.ContainsFunc(s.deliveries, func(delivery webhooks.Delivery) bool {
+			return delivery.WebhookID == v.ID && delivery.EventID == event.ID
+		})
+		if enqueued {
+			continue
+		}
+
+		s.deliveryID++
+		s.deliveries = append(s.deliveries, webhooks.Delivery{
+			ID:            s.deliveryID,
+			WebhookID:     v.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        webhooks.STATUS_PENDING,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		n++
+	}
+
+	return n, nil
+}
+
+/*
+Creates a ping delivery of the webhook with the given ID, and returns it ready to be sent by the caller.
+The delivery is claimed until lease. Returns sql.ErrNoRows if there is no such webhook.
+*/
+func (s *MemoryStore) CreatePingDelivery(ctx context.Context, webhookID int64, payload []byte, lease time.Time) (webhooks.Delivery, error) {
+	if err := s.lock(ctx); err != nil {
+		return webhooks.Delivery{}, err
+	}
+	defer s.mu.Unlock()
+
+	webhook, err := s.webhook(webhookID)
+	if err != nil {
+		return webhooks.Delivery{}, err
+	}
+
+	s.deliveryID++
+	delivery := webhooks.Delivery{
+		ID:            s.deliveryID,
+		WebhookID:     webhook.ID,
+		EventType:     webhooks.TYPE_PING,
+		Payload:       payload,
+		Status:        webhooks.STATUS_PENDING,
+		NextAttemptAt: lease,
+		CreatedAt:     time.Now().UTC().Truncate(time.Millisecond),
+	}
+	s.deliveries = append(s.deliveries, delivery)
+
+	delivery.URL = webhook.URL
+	delivery.Secret = webhook.Secret
+
+	return delivery, nil
+}
+
+// Claims up to limit pending deliveries which are due at now until lease, in order.
+func (s *MemoryStore) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Time, limit int) ([]webhooks.Delivery, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var deliveries []webhooks.Delivery
+	for i := range s.deliveries {
+		v := &s.deliveries[i]
+		if len(deliveries) == limit {
+			break
+		}
+		if v.Status != webhooks.STATUS_PENDING || v.NextAttemptAt.After(now) {
+			continue
+		}
+
+		webhook, err := s.webhook(v.WebhookID)
+		if err != nil {
+			continue
+		}
+
+		delivery := *v
+		delivery.URL = webhook.URL
+		delivery.Secret = webhook.Secret
+		delivery.History = nil
+		deliveries = append(deliveries, delivery)
+		v.NextAttemptAt = lease
+	}
+
+	return deliveries, nil
+}
+
+// Records the attempt, and updates the status of its delivery and when it is next attempted.
+func (s *MemoryStore) RecordAttempt(ctx context.Context, attempt webhooks.Attempt, status string, nextAttemptAt time.Time) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	for i := range s.deliveries {
+		if v := &s.deliveries[i]; v.ID == attempt.DeliveryID {
+			attempt.Duration = attempt.Duration.Truncate(time.Millisecond)
+			v.History = append(v.History, attempt)
+			v.Attempts++
+			v.Status = status
+			v.NextAttemptAt = nextAttemptAt
+		}
+	}
+
+	return nil
+}
+
+// Returns at most limit of the latest deliveries of the webhook with their attempts, latest first.
+func (s *MemoryStore) DeliveriesOf(ctx context.Context, webhookID int64, limit int) ([]webhooks.Delivery, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var deliveries []webhooks.Delivery
+	for i := len(s.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if v := s.deliveries[i]; v.WebhookID == webhookID {
+			v.Payload = nil
+			v.History = slices.Clone(v.History)
+			deliveries = append(deliveries, v)
+		}
+	}
+
+	return deliveries, nil
+}
+
+// Locks the store, unless the context is done.
+func (s *MemoryStore) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+
+	return nil
+}
+
+// Unlocks the store, and calls the hooks of the store with the events written since it was locked, if any.
+func (s *MemoryStore) unlock() {
+	written := s.written
+	s.written = nil
+	s.mu.Unlock()
+
+	if len(written) > 0 {
+		for _, v := range s.onEvents {
+			v(written)
+		}
+	}
+}
+
+// Registers the student to the teacher, and returns true if the student was not yet registered.
+func (s *MemoryStore) register(teacher string, student string) bool {
+	teacher, student = s.keep(teacher), s.keep(student)
+	s.teachers[teacher] = true
+	s.students[student] = s.students[student]
+
+	if s.teaches[teacher] == nil {
+		s.teaches[teacher] = make(map[string]bool)
+	}
+	if s.teaches[teacher][student] {
+		return false
+	}
+	s.teaches[teacher][student] = true
+
+	return true
+}
+
+// Returns true if the registration of the student to the teacher, by the keys of their emails, matches the filter.
+func (s *MemoryStore) matches(teacher string, student string, filter ExportFilter) bool {
+	return (filter.Teacher == "" || teacher == emailKey(filter.Teacher)) &&
+		(filter.Class == "" || s.classes[teaching{teacher, student}] == filter.Class) &&
+		(filter.Suspended == nil || s.students[student] == *filter.Suspended)
+}
+
+// Returns true if any registration of the teacher of the filter matches the filter.
+func (s *MemoryStore) anyRegistration(filter ExportFilter) bool {
+	for v := range s.teaches[emailKey(filter.Teacher)] {
+		if s.matches(filter.Teacher, v, filter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the key of the email in the relations of the store, as emails are compared case-insensitively like in MySQL.
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
+
+// Returns the key of the email, and keeps its spelling if it is stored for the first time, as MySQL keeps the first row of a key.
+func (s *MemoryStore) keep(email string) string {
+	key := emailKey(email)
+	if _, ok := s.emails[key]; !ok {
+		s.emails[key] = email
+	}
+
+	return key
+}
+
+// Returns the emails of the keys, as spelled when first stored.
+func (s *MemoryStore) emailsOf(keys []string) []string {
+	result := make([]string, len(keys))
+	for i, v := range keys {
+		result[i] = s.emails[v]
+	}
+
+	return result
+}
+
+// Writes the event to the outbox.
+func (s *MemoryStore) writeEvent(event events.Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	s.outbox = append(s.outbox, memoryEvent{id: int64(len(s.outbox) + 1), payload: payload, availableAt: event.OccurredAt})
+	s.written = append(s.written, event)
+}
+
+// Returns the event of the outbox with the given ID, or nil if there is none.
+func (s *MemoryStore) event(id int64) *memoryEvent {
+	if id < 1 || id > int64(len(s.outbox)) {
+		return nil
+	}
+
+	return &s.outbox[id-1]
+}
+
+// Returns the webhook with the given ID, or sql.ErrNoRows if there is none.
+func (s *MemoryStore) webhook(id int64) (webhooks.Webhook, error) {
+	for _, v := range s.webhooks {
+		if v.ID == id {
+			return v, nil
+		}
+	}
+
+	return webhooks.Webhook{}, sql.ErrNoRows
+}
+
+// Returns the keys of the map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Calls fn with every row, and stops at the first error.
+func each[T any](rows []T, fn func(T) error) error {
+	for _, v := range rows {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,10 +1,20 @@
 package database
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"os"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+
+	"govtech/pkg/config"
 )
 
 // Structure for the configuration paramters used for MySQL DB.
@@ -14,22 +24,191 @@ type MySqlConfig struct {
 	Port     string
 	Host     string
 	Name     string
+
+	// Connection pool limits, zero values are left at the sql.DB defaults.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Timeouts for dialing, reading and writing, zero values for no timeout.
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// One of "false", "true", "skip-verify" or "preferred".
+	// TLSCAFile verifies the server against the given CA certificate instead of the system roots.
+	TLS       string
+	TLSCAFile string
+
+	// Number of attempts to reach the DB on startup, and the delay before the second attempt.
+	// The delay doubles after every attempt up to ConnectMaxBackoff.
+	ConnectAttempts   int
+	ConnectBackoff    time.Duration
+	ConnectMaxBackoff time.Duration
+}
+
+// Returns the configuration used to connect to the DB.
+func NewMySqlConfig(c config.DatabaseConfig) MySqlConfig {
+	return MySqlConfig{
+		User:            c.User,
+		Password:        c.Password,
+		Port:            c.Port,
+		Host:            c.Host,
+		Name:            c.Name,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime.Duration,
+		ConnMaxIdleTime: c.ConnMaxIdleTime.Duration,
+
+		DialTimeout:  c.DialTimeout.Duration,
+		ReadTimeout:  c.ReadTimeout.Duration,
+		WriteTimeout: c.WriteTimeout.Duration,
+		TLS:          c.TLS,
+		TLSCAFile:    c.TLSCAFile,
+
+		ConnectAttempts:   c.ConnectAttempts,
+		ConnectBackoff:    c.ConnectBackoff.Duration,
+		ConnectMaxBackoff: c.ConnectMaxBackoff.Duration,
+	}
+}
+
+// Name of the TLS configuration registered for TLSCAFile.
+const TLS_CONFIG_CUSTOM = "custom"
+
+/*
+Returns the DSN for the configuration.
+With a TLSCAFile, the DSN uses the TLS configuration registered by RegisterTLSConfig, which must be called before connecting.
+*/
+func (config *MySqlConfig) DSN() (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = config.User
+	cfg.Passwd = config.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(config.Host, config.Port)
+	cfg.DBName = config.Name
+	cfg.ParseTime = true
+	cfg.Timeout = config.DialTimeout
+	cfg.ReadTimeout = config.ReadTimeout
+	cfg.WriteTimeout = config.WriteTimeout
+	cfg.TLSConfig = config.TLS
+
+	if config.TLSCAFile != "" {
+		cfg.TLSConfig = TLS_CONFIG_CUSTOM
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+/*
+Registers the TLS configuration verifying the server against TLSCAFile with the MySQL driver, if it is set.
+The registration is global to the driver, so it is made once when connecting rather than when building the DSN.
+*/
+func (config *MySqlConfig) RegisterTLSConfig() error {
+	if config.TLSCAFile == "" {
+		return nil
+	}
+
+	pem, err := os.ReadFile(config.TLSCAFile)
+	if err != nil {
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificates found in %s", config.TLSCAFile)
+	}
+
+	return mysql.RegisterTLSConfig(TLS_CONFIG_CUSTOM, &tls.Config{
+		RootCAs:    roots,
+		ServerName: config.Host,
+		MinVersion: tls.VersionTLS12,
+	})
 }
 
-// Returns an instance of the MySQL DB if connected successfully.
+/*
+Returns an instance of the MySQL DB if connected successfully.
+Retries with exponential backoff while the DB cannot be reached, eg. while it is starting up.
+*/
 func ConnectDB(config *MySqlConfig) *sql.DB {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", config.User, config.Password,
-		config.Host, config.Port, config.Name)
+	if err := config.RegisterTLSConfig(); err != nil {
+		panic(err.Error())
+	}
+
+	dsn, err := config.DSN()
+	if err != nil {
+		panic(err.Error())
+	}
+
 	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+
+	// Verify the connection as sql.Open does not connect to the DB.
+	err = PingWithRetry(context.Background(), db, config)
 
 	if err != nil {
+		db.Close()
 		panic(err.Error())
 	} else {
-		fmt.Println("Successfully connected to database")
+		slog.Info("successfully connected to database", "host", config.Host, "name", config.Name)
 		return db
 	}
 }
 
+// Pings the DB until it succeeds or ConnectAttempts have been made.
+func PingWithRetry(ctx context.Context, db *sql.DB, config *MySqlConfig) error {
+	attempts := max(config.ConnectAttempts, 1)
+	backoff := config.ConnectBackoff
+
+	var err error
+	for i := 1; i <= attempts; i++ {
+		pingCtx, cancel := context.WithTimeout(ctx, max(config.DialTimeout, 5*time.Second))
+		err = db.PingContext(pingCtx)
+		cancel()
+
+		if err == nil || i == attempts {
+			break
+		}
+
+		// Add up to 20% jitter so that replicas do not retry in lockstep.
+		delay := backoff + time.Duration(rand.Int63n(int64(backoff)/5+1))
+		slog.Warn("failed to connect to database, retrying",
+			"attempt", i, "max_attempts", attempts, "retry_in", delay.String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		backoff *= 2
+		if config.ConnectMaxBackoff > 0 && backoff > config.ConnectMaxBackoff {
+			backoff = config.ConnectMaxBackoff
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to connect to database after %d attempt(s): %w", attempts, err)
+	}
+
+	return nil
+}
+
 // Close DB connection.
 func DisconnectDB(db *sql.DB) {
 	db.Close()
@@ -58,20 +237,112 @@ func InitDB(db *sql.DB) {
 	if err != nil {
 		panic(err.Error())
 	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS classes
+					  (teacher VARCHAR(255), student VARCHAR(255),
+					   name VARCHAR(60) NOT NULL,
+					   PRIMARY KEY(teacher, student),
+					   INDEX (name),
+					   FOREIGN KEY (teacher, student) REFERENCES teaches(teacher, student) ON DELETE CASCADE)`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS notifications
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   teacher VARCHAR(255) NOT NULL,
+					   text VARCHAR(255) NOT NULL,
+					   created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3))`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS notification_recipients
+					  (notification_id BIGINT, student VARCHAR(255),
+					   PRIMARY KEY(notification_id, student),
+					   INDEX (student, notification_id),
+					   FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE)`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS outbox
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   type VARCHAR(60) NOT NULL,
+					   payload JSON NOT NULL,
+					   created_at DATETIME(3) NOT NULL,
+					   available_at DATETIME(3) NOT NULL,
+					   attempts INT NOT NULL DEFAULT 0,
+					   last_error TEXT,
+					   dispatched_at DATETIME(3),
+					   dead_at DATETIME(3),
+					   INDEX (dispatched_at, dead_at, available_at))`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS outbox_deliveries
+					  (event_id BIGINT, subscriber VARCHAR(60),
+					   delivered_at DATETIME(3) NOT NULL,
+					   PRIMARY KEY(event_id, subscriber),
+					   FOREIGN KEY (event_id) REFERENCES outbox(id) ON DELETE CASCADE)`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhooks
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   url VARCHAR(2048) NOT NULL,
+					   events JSON NOT NULL,
+					   secret VARCHAR(255) NOT NULL,
+					   created_at DATETIME(3) NOT NULL)`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   webhook_id BIGINT NOT NULL,
+					   event_id BIGINT,
+					   event_type VARCHAR(60) NOT NULL,
+					   payload JSON NOT NULL,
+					   status VARCHAR(20) NOT NULL,
+					   attempts INT NOT NULL DEFAULT 0,
+					   next_attempt_at DATETIME(3) NOT NULL,
+					   created_at DATETIME(3) NOT NULL,
+					   UNIQUE (webhook_id, event_id),
+					   INDEX (status, next_attempt_at),
+					   FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE)`)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhook_attempts
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   delivery_id BIGINT NOT NULL,
+					   attempted_at DATETIME(3) NOT NULL,
+					   response_status INT,
+					   error TEXT,
+					   duration_ms INT NOT NULL,
+					   INDEX (delivery_id, id),
+					   FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE)`)
+	if err != nil {
+		panic(err.Error())
+	}
 }
 
-// Creates relation in test DB.
-func InitTestDB(db *sql.DB) {
+// Creates the relations in the test DB, and returns the error of the first which cannot be created.
+func InitTestDB(db *sql.DB) error {
 	_, err := db.Exec("CREATE TABLE IF NOT EXISTS teachers (email VARCHAR(255) PRIMARY KEY)")
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
 
 	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS students
 					  (email VARCHAR(255) PRIMARY KEY,
 					   suspended TINYINT(1) NOT NULL DEFAULT 0)`)
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
 
 	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS teaches
@@ -80,23 +351,160 @@ func InitTestDB(db *sql.DB) {
 					   FOREIGN KEY (teacher) REFERENCES teachers(emaiL) ON DELETE CASCADE,
 					   FOREIGN KEY (student) REFERENCES students(email) ON DELETE CASCADE)`)
 	if err != nil {
-		panic(err.Error())
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS classes
+					  (teacher VARCHAR(255), student VARCHAR(255),
+					   name VARCHAR(60) NOT NULL,
+					   PRIMARY KEY(teacher, student),
+					   INDEX (name),
+					   FOREIGN KEY (teacher, student) REFERENCES teaches(teacher, student) ON DELETE CASCADE)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS notifications
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   teacher VARCHAR(255) NOT NULL,
+					   text VARCHAR(255) NOT NULL,
+					   created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3))`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS notification_recipients
+					  (notification_id BIGINT, student VARCHAR(255),
+					   PRIMARY KEY(notification_id, student),
+					   INDEX (student, notification_id),
+					   FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS outbox
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   type VARCHAR(60) NOT NULL,
+					   payload JSON NOT NULL,
+					   created_at DATETIME(3) NOT NULL,
+					   available_at DATETIME(3) NOT NULL,
+					   attempts INT NOT NULL DEFAULT 0,
+					   last_error TEXT,
+					   dispatched_at DATETIME(3),
+					   dead_at DATETIME(3),
+					   INDEX (dispatched_at, dead_at, available_at))`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS outbox_deliveries
+					  (event_id BIGINT, subscriber VARCHAR(60),
+					   delivered_at DATETIME(3) NOT NULL,
+					   PRIMARY KEY(event_id, subscriber),
+					   FOREIGN KEY (event_id) REFERENCES outbox(id) ON DELETE CASCADE)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhooks
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   url VARCHAR(2048) NOT NULL,
+					   events JSON NOT NULL,
+					   secret VARCHAR(255) NOT NULL,
+					   created_at DATETIME(3) NOT NULL)`)
+	if err != nil {
+		return err
 	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   webhook_id BIGINT NOT NULL,
+					   event_id BIGINT,
+					   event_type VARCHAR(60) NOT NULL,
+					   payload JSON NOT NULL,
+					   status VARCHAR(20) NOT NULL,
+					   attempts INT NOT NULL DEFAULT 0,
+					   next_attempt_at DATETIME(3) NOT NULL,
+					   created_at DATETIME(3) NOT NULL,
+					   UNIQUE (webhook_id, event_id),
+					   INDEX (status, next_attempt_at),
+					   FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS webhook_attempts
+					  (id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					   delivery_id BIGINT NOT NULL,
+					   attempted_at DATETIME(3) NOT NULL,
+					   response_status INT,
+					   error TEXT,
+					   duration_ms INT NOT NULL,
+					   INDEX (delivery_id, id),
+					   FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE)`)
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func CleanupTestDB(db *sql.DB) {
-	_, err := db.Exec("DROP TABLE teaches")
+// Drops the relations of the test DB, and returns the error of the first which cannot be dropped.
+func CleanupTestDB(db *sql.DB) error {
+	_, err := db.Exec("DROP TABLE webhook_attempts")
 	if err != nil {
-		panic(err.Error())
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE webhook_deliveries")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE webhooks")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE outbox_deliveries")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE outbox")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE notification_recipients")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE notifications")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE classes")
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("DROP TABLE teaches")
+	if err != nil {
+		return err
 	}
 
 	_, err = db.Exec("DROP TABLE teachers")
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
 
 	_, err = db.Exec("DROP TABLE students")
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
+
+	return nil
 }
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"govtech/pkg/events"
+)
+
+// Operations on the outbox, used to label query logs and metrics.
+const OPERATION_CLAIM_EVENTS = "claim_events"
+const OPERATION_DISPATCH_EVENT = "dispatch_event"
+const OPERATION_COUNT_DEAD_EVENTS = "count_dead_events"
+
+// Maximum length of the errors recorded for failed dispatches.
+const MAX_EVENT_ERROR_LENGTH = 1000
+
+/*
+Adds fn to the functions called with the events written to the outbox by each committed transaction,
+eg. to wake the dispatcher up or to invalidate caches.
+Must be called before the store is used.
+*/
+func (s *MySQLStore) OnEventsWritten(fn func([]events.Event)) {
+	s.onEvents = append(s.onEvents, fn)
+}
+
+// Claims up to limit events which are due at now until lease, in order.
+// Claimed events are skipped by other dispatchers until their lease ends.
+func (s *MySQLStore) ClaimEvents(ctx context.Context, now time.Time, lease time.Time, limit int) ([]events.Pending, error) {
+	defer s.recordQuery(ctx, OPERATION_CLAIM_EVENTS, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, payload, attempts
+					   FROM outbox
+					   WHERE dispatched_at IS NULL
+					   AND dead_at IS NULL
+					   AND available_at <= ?
+					   ORDER BY id
+					   LIMIT ?
+					   FOR UPDATE SKIP LOCKED`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []events.Pending
+	for rows.Next() {
+		var v events.Pending
+		var id int64
+		var payload []byte
+		if err := rows.Scan(&id, &payload, &v.Attempts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &v.Event); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		v.Event.ID = id
+		pending = append(pending, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(pending) == 0 {
+		return nil, err
+	}
+
+	ids := make([]any, len(pending))
+	index := make(map[int64]int, len(pending))
+	for i, v := range pending {
+		ids[i] = v.Event.ID
+		index[v.Event.ID] = i
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE outbox
+				  SET available_at = ?
+				  WHERE id IN (`+placeholders(len(ids))+`)`, append([]any{lease}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the subscribers which have already handled the events.
+	rows, err = tx.QueryContext(ctx, `SELECT event_id, subscriber
+					  FROM outbox_deliveries
+					  WHERE event_id IN (`+placeholders(len(ids))+`)`, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var id int64
+		var subscriber string
+		if err := rows.Scan(&id, &subscriber); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		i := index[id]
+		pending[i].Delivered = append(pending[i].Delivered, subscriber)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pending, tx.Commit()
+}
+
+// Records that the subscriber has handled the event.
+func (s *MySQLStore) MarkDelivered(ctx context.Context, id int64, subscriber string) error {
+	defer s.recordQuery(ctx, OPERATION_DISPATCH_EVENT, time.Now())
+
+	_, err := s.db.ExecContext(ctx, `INSERT IGNORE INTO outbox_deliveries
+						 VALUES (?, ?, ?)`, id, subscriber, time.Now().UTC())
+
+	return err
+}
+
+// Records that every subscriber has handled the event.
+func (s *MySQLStore) MarkDispatched(ctx context.Context, id int64) error {
+	defer s.recordQuery(ctx, OPERATION_DISPATCH_EVENT, time.Now())
+
+	_, err := s.db.ExecContext(ctx, `UPDATE outbox
+						 SET dispatched_at = ?
+						 WHERE id = ?`, time.Now().UTC(), id)
+
+	return err
+}
+
+// Records a failed attempt to dispatch the event, which is due again at retryAt.
+func (s *MySQLStore) RetryEvent(ctx context.Context, id int64, retryAt time.Time, reason string) error {
+	defer s.recordQuery(ctx, OPERATION_DISPATCH_EVENT, time.Now())
+
+	if len(reason) > MAX_EVENT_ERROR_LENGTH {
+		reason = reason[:MAX_EVENT_ERROR_LENGTH]
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE outbox
+						 SET attempts = attempts + 1, available_at = ?, last_error = ?
+						 WHERE id = ?`, retryAt, reason, id)
+
+	return err
+}
+
+// Records the last failed attempt to dispatch the event, after which it is dead and no longer claimed.
+func (s *MySQLStore) KillEvent(ctx context.Context, id int64, reason string) error {
+	defer s.recordQuery(ctx, OPERATION_DISPATCH_EVENT, time.Now())
+
+	if len(reason) > MAX_EVENT_ERROR_LENGTH {
+		reason = reason[:MAX_EVENT_ERROR_LENGTH]
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE outbox
+						 SET attempts = attempts + 1, dead_at = ?, last_error = ?
+						 WHERE id = ?`, time.Now().UTC(), reason, id)
+
+	return err
+}
+
+// Returns the number of dead events.
+func (s *MySQLStore) CountDeadEvents(ctx context.Context) (int, error) {
+	defer s.recordQuery(ctx, OPERATION_COUNT_DEAD_EVENTS, time.Now())
+
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)
+					  FROM outbox
+					  WHERE dispatched_at IS NULL
+					  AND dead_at IS NOT NULL`).Scan(&n)
+
+	return n, err
+}
+
+// Writes the event to the outbox in the transaction.
+func writeEvent(ctx context.Context, tx *sql.Tx, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO outbox (type, payload, created_at, available_at)
+				  VALUES (?, ?, ?, ?)`, event.Type, payload, event.OccurredAt, event.OccurredAt)
+
+	return err
+}
+
+// Commits the transaction, and calls the hooks of the store with the events it wrote, if any.
+func (s *MySQLStore) commit(tx *sql.Tx, written ...events.Event) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(written) > 0 {
+		for _, v := range s.onEvents {
+			v(written)
+		}
+	}
+
+	return nil
+}
+
+// Executes the statement in the transaction, and returns true if it changed any row.
+func execAffected(ctx context.Context, tx *sql.Tx, query string, args ...any) (bool, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n > 0, err
+}
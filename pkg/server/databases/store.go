@@ -0,0 +1,679 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"govtech/pkg/events"
+	"govtech/pkg/server/metrics"
+	"govtech/pkg/webhooks"
+)
+
+// Operations performed on the DB, used to label query logs and metrics.
+const OPERATION_REGISTER = "register"
+const OPERATION_DEREGISTER = "deregister"
+const OPERATION_COMMON_STUDENTS = "commonstudents"
+const OPERATION_SUSPEND = "suspend"
+const OPERATION_UNSUSPEND = "unsuspend"
+const OPERATION_RETRIEVE_FOR_NOTIFICATIONS = "retrievefornotifications"
+const OPERATION_TEACHERS = "teachers"
+const OPERATION_STUDENTS = "students"
+const OPERATION_TEACHES = "teaches"
+const OPERATION_SAVE_NOTIFICATION = "save_notification"
+const OPERATION_NOTIFICATION_HISTORY = "notification_history"
+const OPERATION_IMPORT_REGISTRATIONS = "import_registrations"
+
+// Structure for a row of the students relation.
+type Student struct {
+	Email     string
+	Suspended bool
+}
+
+// Structure for the registration of a student to a teacher, in a class of the teacher if the class is given.
+type Registration struct {
+	Teacher string
+	Student string
+	Class   string
+}
+
+// Structure for a notification sent by a teacher.
+// IDs increase in the order notifications are saved.
+type Notification struct {
+	ID        int64
+	Teacher   string
+	Text      string
+	CreatedAt time.Time
+}
+
+/*
+Store performs the queries used by the controllers, the service and the dispatchers.
+Queries are cancelled once the context passed to the store is done.
+*/
+type Store interface {
+	events.Outbox
+	webhooks.Store
+
+	RegisterStudents(ctx context.Context, teacher string, students []string) error
+	RegisterTeachers(ctx context.Context, student string, teachers []string) error
+	ImportRegistrations(ctx context.Context, registrations []Registration, dryRun bool) (int, error)
+	DeregisterStudents(ctx context.Context, teacher string, students []string) ([]string, error)
+	CommonStudents(ctx context.Context, teachers []string) ([]string, error)
+	// Returns true if the suspended state of the student changed.
+	SuspendStudent(ctx context.Context, student string) (bool, error)
+	UnsuspendStudent(ctx context.Context, student string) (bool, error)
+	ActiveStudentsOf(ctx context.Context, teacher string) ([]string, error)
+	IsActiveStudent(ctx context.Context, student string) (bool, error)
+	Teachers(ctx context.Context) ([]string, error)
+	FindTeachers(ctx context.Context, teachers []string) ([]string, error)
+	Students(ctx context.Context) ([]Student, error)
+	FindStudents(ctx context.Context, students []string) ([]Student, error)
+	StudentsOfTeachers(ctx context.Context, teachers []string) (map[string][]string, error)
+	TeachersOfStudents(ctx context.Context, students []string) (map[string][]string, error)
+	SaveNotification(ctx context.Context, teacher string, text string, recipients []string) (Notification, error)
+	NotificationsOf(ctx context.Context, student string, afterID int64, limit int) ([]Notification, error)
+
+	ExportTeachers(ctx context.Context, filter ExportFilter, fn func(string) error) error
+	ExportStudents(ctx context.Context, filter ExportFilter, fn func(Student) error) error
+	ExportTeaches(ctx context.Context, filter ExportFilter, fn func(RosterEntry) error) error
+	ExportNotifications(ctx context.Context, filter ExportFilter, fn func(NotificationRecipient) error) error
+
+	OnEventsWritten(fn func([]events.Event))
+
+	CreateWebhook(ctx context.Context, url string, eventTypes []string, secret string) (webhooks.Webhook, error)
+	Webhooks(ctx context.Context) ([]webhooks.Webhook, error)
+	Webhook(ctx context.Context, id int64) (webhooks.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) (bool, error)
+	CreatePingDelivery(ctx context.Context, webhookID int64, payload []byte, lease time.Time) (webhooks.Delivery, error)
+	DeliveriesOf(ctx context.Context, webhookID int64, limit int) ([]webhooks.Delivery, error)
+
+	// Returns an error if the store cannot be reached.
+	Ping(ctx context.Context) error
+}
+
+// Store of a MySQL DB.
+type MySQLStore struct {
+	db *sql.DB
+	// Called with the events written to the outbox by each committed transaction.
+	onEvents []func([]events.Event)
+}
+
+// Returns a store using the given DB.
+func NewStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+// Returns an error if the DB cannot be reached.
+func (s *MySQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+/*
+Registers a list of students to a teacher.
+Writes the students which were not yet registered to the teacher to the outbox.
+*/
+func (s *MySQLStore) RegisterStudents(ctx context.Context, teacher string, students []string) error {
+	defer s.recordQuery(ctx, OPERATION_REGISTER, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT IGNORE INTO teachers
+						 VALUES (?)`, teacher)
+	if err != nil {
+		return err
+	}
+
+	var registered []string
+	for _, v := range students {
+		_, err := tx.ExecContext(ctx, `INSERT IGNORE INTO students
+							 VALUES (?, 0)`, v)
+		if err != nil {
+			return err
+		}
+
+		inserted, err := execAffected(ctx, tx, `INSERT IGNORE INTO teaches
+							VALUES (?, ?)`, teacher, v)
+		if err != nil {
+			return err
+		}
+		if inserted {
+			registered = append(registered, v)
+		}
+	}
+
+	var written []events.Event
+	if len(registered) > 0 {
+		event := events.New(events.TYPE_STUDENT_REGISTERED, []string{teacher}, registered)
+		if err := writeEvent(ctx, tx, event); err != nil {
+			return err
+		}
+		written = append(written, event)
+	}
+
+	return s.commit(tx, written...)
+}
+
+/*
+Registers a list of teachers to a student.
+Writes the teachers to which the student was not yet registered to the outbox.
+*/
+func (s *MySQLStore) RegisterTeachers(ctx context.Context, student string, teachers []string) error {
+	defer s.recordQuery(ctx, OPERATION_REGISTER, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT IGNORE INTO students
+						 VALUES (?, 0)`, student)
+	if err != nil {
+		return err
+	}
+
+	var registered []string
+	for _, v := range teachers {
+		_, err := tx.ExecContext(ctx, `INSERT IGNORE INTO teachers
+							 VALUES (?)`, v)
+		if err != nil {
+			return err
+		}
+
+		inserted, err := execAffected(ctx, tx, `INSERT IGNORE INTO teaches
+							VALUES (?, ?)`, v, student)
+		if err != nil {
+			return err
+		}
+		if inserted {
+			registered = append(registered, v)
+		}
+	}
+
+	var written []events.Event
+	if len(registered) > 0 {
+		event := events.New(events.TYPE_STUDENT_REGISTERED, registered, []string{student})
+		if err := writeEvent(ctx, tx, event); err != nil {
+			return err
+		}
+		written = append(written, event)
+	}
+
+	return s.commit(tx, written...)
+}
+
+/*
+Applies the registrations in one transaction, and returns the number of students newly registered to teachers.
+The classes of registrations are set, replacing the previous class of the student with the teacher.
+Writes the students newly registered to each teacher to the outbox.
+If dryRun is true, the transaction is rolled back instead, and nothing is written.
+*/
+func (s *MySQLStore) ImportRegistrations(ctx context.Context, registrations []Registration, dryRun bool) (int, error) {
+	defer s.recordQuery(ctx, OPERATION_IMPORT_REGISTRATIONS, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Students newly registered to each teacher, in the order of the teachers.
+	var teachers []string
+	registered := make(map[string][]string)
+	n := 0
+
+	for _, v := range registrations {
+		_, err := tx.ExecContext(ctx, `INSERT IGNORE INTO teachers
+						 VALUES (?)`, v.Teacher)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT IGNORE INTO students
+					 VALUES (?, 0)`, v.Student)
+		if err != nil {
+			return 0, err
+		}
+
+		inserted, err := execAffected(ctx, tx, `INSERT IGNORE INTO teaches
+						VALUES (?, ?)`, v.Teacher, v.Student)
+		if err != nil {
+			return 0, err
+		}
+		if inserted {
+			if _, ok := registered[v.Teacher]; !ok {
+				teachers = append(teachers, v.Teacher)
+			}
+			registered[v.Teacher] = append(registered[v.Teacher], v.Student)
+			n++
+		}
+
+		if v.Class != "" {
+			_, err = tx.ExecContext(ctx, `INSERT INTO classes
+						 VALUES (?, ?, ?)
+						 ON DUPLICATE KEY UPDATE name = VALUES(name)`, v.Teacher, v.Student, v.Class)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if dryRun {
+		return n, nil
+	}
+
+	written := make([]events.Event, 0, len(teachers))
+	for _, v := range teachers {
+		event := events.New(events.TYPE_STUDENT_REGISTERED, []string{v}, registered[v])
+		if err := writeEvent(ctx, tx, event); err != nil {
+			return 0, err
+		}
+		written = append(written, event)
+	}
+
+	return n, s.commit(tx, written...)
+}
+
+/*
+Deregisters a list of students from a teacher.
+Returns the students which were registered to the teacher, in order, and writes them to the outbox.
+*/
+func (s *MySQLStore) DeregisterStudents(ctx context.Context, teacher string, students []string) ([]string, error) {
+	defer s.recordQuery(ctx, OPERATION_DEREGISTER, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	args := append([]any{teacher}, stringArgs(students)...)
+	rows, err := tx.QueryContext(ctx, `SELECT student
+					   FROM teaches
+					   WHERE teacher = ?
+					   AND student IN (`+placeholders(len(students))+`)
+					   ORDER BY student
+					   FOR UPDATE`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		removed = append(removed, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM teaches
+				  WHERE teacher = ?
+				  AND student IN (`+placeholders(len(removed))+`)`,
+		append([]any{teacher}, stringArgs(removed)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	event := events.New(events.TYPE_STUDENT_DEREGISTERED, []string{teacher}, removed)
+	if err := writeEvent(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	return removed, s.commit(tx, event)
+}
+
+// Returns students registered to all of the given teachers.
+func (s *MySQLStore) CommonStudents(ctx context.Context, teachers []string) ([]string, error) {
+	defer s.recordQuery(ctx, OPERATION_COMMON_STUDENTS, time.Now())
+
+	// Build query to get students registered to all teachers in the list.
+	queries := make([]string, len(teachers))
+	args := make([]any, len(teachers))
+	for i, v := range teachers {
+		queries[i] = `SELECT student
+					  FROM teaches
+					  WHERE teacher = ?`
+		args[i] = v
+	}
+
+	return s.queryStrings(ctx, strings.Join(queries, " INTERSECT "), args...)
+}
+
+/*
+Suspends the given student, and returns true unless the student was already suspended or does not exist.
+Writes the suspension to the outbox with the teachers of the student if it changed.
+*/
+func (s *MySQLStore) SuspendStudent(ctx context.Context, student string) (bool, error) {
+	defer s.recordQuery(ctx, OPERATION_SUSPEND, time.Now())
+
+	return s.setSuspended(ctx, student, true, events.TYPE_STUDENT_SUSPENDED)
+}
+
+/*
+Unsuspends the given student, and returns true unless the student was not suspended or does not exist.
+Writes the unsuspension to the outbox with the teachers of the student if it changed.
+*/
+func (s *MySQLStore) UnsuspendStudent(ctx context.Context, student string) (bool, error) {
+	defer s.recordQuery(ctx, OPERATION_UNSUSPEND, time.Now())
+
+	return s.setSuspended(ctx, student, false, events.TYPE_STUDENT_UNSUSPENDED)
+}
+
+/*
+Sets the suspended state of the student, and writes an event of the given type if it changed.
+Returns true if it changed.
+*/
+func (s *MySQLStore) setSuspended(ctx context.Context, student string, suspended bool, eventType string) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	changed, err := execAffected(ctx, tx, `UPDATE students
+						 SET suspended = ?
+						 WHERE email = ?
+						 AND suspended = ?`, suspended, student, !suspended)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT teacher
+					   FROM teaches
+					   WHERE student = ?
+					   ORDER BY teacher`, student)
+	if err != nil {
+		return false, err
+	}
+
+	var teachers []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return false, err
+		}
+		teachers = append(teachers, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	event := events.New(eventType, teachers, []string{student})
+	if err := writeEvent(ctx, tx, event); err != nil {
+		return false, err
+	}
+
+	if err := s.commit(tx, event); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Returns students registered to the teacher who are not suspended.
+func (s *MySQLStore) ActiveStudentsOf(ctx context.Context, teacher string) ([]string, error) {
+	defer s.recordQuery(ctx, OPERATION_RETRIEVE_FOR_NOTIFICATIONS, time.Now())
+
+	return s.queryStrings(ctx, `SELECT student
+						   FROM students
+						   INNER JOIN teaches
+						   ON students.email = teaches.student
+						   WHERE students.suspended = 0
+						   AND teaches.teacher = ?`, teacher)
+}
+
+// Returns true if the student exists and is not suspended.
+func (s *MySQLStore) IsActiveStudent(ctx context.Context, student string) (bool, error) {
+	defer s.recordQuery(ctx, OPERATION_RETRIEVE_FOR_NOTIFICATIONS, time.Now())
+
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*)
+						  FROM students
+						  WHERE suspended = 0
+						  AND email = ?`, student).Scan(&count)
+
+	return count == 1, err
+}
+
+// Returns every teacher, sorted by email.
+func (s *MySQLStore) Teachers(ctx context.Context) ([]string, error) {
+	defer s.recordQuery(ctx, OPERATION_TEACHERS, time.Now())
+
+	return s.queryStrings(ctx, `SELECT email
+						   FROM teachers
+						   ORDER BY email`)
+}
+
+// Returns the given teachers which exist.
+func (s *MySQLStore) FindTeachers(ctx context.Context, teachers []string) ([]string, error) {
+	if len(teachers) == 0 {
+		return nil, nil
+	}
+	defer s.recordQuery(ctx, OPERATION_TEACHERS, time.Now())
+
+	return s.queryStrings(ctx, `SELECT email
+						   FROM teachers
+						   WHERE email IN (`+placeholders(len(teachers))+`)`, stringArgs(teachers)...)
+}
+
+// Returns every student, sorted by email.
+func (s *MySQLStore) Students(ctx context.Context) ([]Student, error) {
+	defer s.recordQuery(ctx, OPERATION_STUDENTS, time.Now())
+
+	return s.queryStudents(ctx, `SELECT email, suspended
+						   FROM students
+						   ORDER BY email`)
+}
+
+// Returns the given students which exist.
+func (s *MySQLStore) FindStudents(ctx context.Context, students []string) ([]Student, error) {
+	if len(students) == 0 {
+		return nil, nil
+	}
+	defer s.recordQuery(ctx, OPERATION_STUDENTS, time.Now())
+
+	return s.queryStudents(ctx, `SELECT email, suspended
+						   FROM students
+						   WHERE email IN (`+placeholders(len(students))+`)`, stringArgs(students)...)
+}
+
+// Returns the students registered to each of the given teachers, sorted by email.
+func (s *MySQLStore) StudentsOfTeachers(ctx context.Context, teachers []string) (map[string][]string, error) {
+	if len(teachers) == 0 {
+		return map[string][]string{}, nil
+	}
+	defer s.recordQuery(ctx, OPERATION_TEACHES, time.Now())
+
+	return s.queryPairs(ctx, `SELECT teacher, student
+						 FROM teaches
+						 WHERE teacher IN (`+placeholders(len(teachers))+`)
+						 ORDER BY student`, stringArgs(teachers)...)
+}
+
+// Returns the teachers registered to each of the given students, sorted by email.
+func (s *MySQLStore) TeachersOfStudents(ctx context.Context, students []string) (map[string][]string, error) {
+	if len(students) == 0 {
+		return map[string][]string{}, nil
+	}
+	defer s.recordQuery(ctx, OPERATION_TEACHES, time.Now())
+
+	return s.queryPairs(ctx, `SELECT student, teacher
+						 FROM teaches
+						 WHERE student IN (`+placeholders(len(students))+`)
+						 ORDER BY teacher`, stringArgs(students)...)
+}
+
+// Saves a notification and its recipients, and returns it with its ID. Writes the notification to the outbox.
+func (s *MySQLStore) SaveNotification(ctx context.Context, teacher string, text string, recipients []string) (Notification, error) {
+	defer s.recordQuery(ctx, OPERATION_SAVE_NOTIFICATION, time.Now())
+
+	notification := Notification{Teacher: teacher, Text: text, CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return notification, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO notifications (teacher, text, created_at)
+						   VALUES (?, ?, ?)`, teacher, text, notification.CreatedAt)
+	if err != nil {
+		return notification, err
+	}
+
+	notification.ID, err = result.LastInsertId()
+	if err != nil {
+		return notification, err
+	}
+
+	for _, v := range recipients {
+		_, err := tx.ExecContext(ctx, `INSERT IGNORE INTO notification_recipients
+							   VALUES (?, ?)`, notification.ID, v)
+		if err != nil {
+			return notification, err
+		}
+	}
+
+	event := events.New(events.TYPE_NOTIFICATION_ISSUED, []string{teacher}, recipients)
+	event.NotificationID = notification.ID
+	event.Notification = text
+	event.OccurredAt = notification.CreatedAt
+	if err := writeEvent(ctx, tx, event); err != nil {
+		return notification, err
+	}
+
+	return notification, s.commit(tx, event)
+}
+
+// Returns at most limit notifications received by the student after the notification with the given ID, in order.
+func (s *MySQLStore) NotificationsOf(ctx context.Context, student string, afterID int64, limit int) ([]Notification, error) {
+	defer s.recordQuery(ctx, OPERATION_NOTIFICATION_HISTORY, time.Now())
+
+	rows, err := s.db.QueryContext(ctx, `SELECT notifications.id, notifications.teacher,
+							 notifications.text, notifications.created_at
+						  FROM notifications
+						  INNER JOIN notification_recipients
+						  ON notifications.id = notification_recipients.notification_id
+						  WHERE notification_recipients.student = ?
+						  AND notifications.id > ?
+						  ORDER BY notifications.id
+						  LIMIT ?`, student, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Notification
+	for rows.Next() {
+		var v Notification
+		if err := rows.Scan(&v.ID, &v.Teacher, &v.Text, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
+
+// Returns the first column of every row of the query.
+func (s *MySQLStore) queryStrings(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
+
+// Returns the students of every row of the query, of the columns email and suspended.
+func (s *MySQLStore) queryStudents(ctx context.Context, query string, args ...any) ([]Student, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []Student
+	for rows.Next() {
+		var v Student
+		if err := rows.Scan(&v.Email, &v.Suspended); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
+
+// Returns the second column of every row of the query, grouped by the first column.
+func (s *MySQLStore) queryPairs(ctx context.Context, query string, args ...any) (map[string][]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		result[key] = append(result[key], value)
+	}
+
+	return result, rows.Err()
+}
+
+// Returns n comma separated placeholders for an IN clause.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// Returns the strings as query arguments.
+func stringArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	return args
+}
+
+// Logs and records the duration of a DB operation started at start.
+func (s *MySQLStore) recordQuery(ctx context.Context, operation string, start time.Time) {
+	duration := time.Since(start)
+
+	metrics.DBQueryDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	slog.InfoContext(ctx, "db query",
+		"operation", operation,
+		"duration_ms", float64(duration.Microseconds())/1000)
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"govtech/pkg/events"
+	"govtech/pkg/webhooks"
+)
+
+// Operations on webhooks, used to label query logs and metrics.
+const OPERATION_WEBHOOKS = "webhooks"
+const OPERATION_ENQUEUE_DELIVERIES = "enqueue_deliveries"
+const OPERATION_CLAIM_DELIVERIES = "claim_deliveries"
+const OPERATION_RECORD_ATTEMPT = "record_attempt"
+const OPERATION_DELIVERIES = "deliveries"
+
+// Registers a webhook receiving the events of the given types, or every event if there are none.
+func (s *MySQLStore) CreateWebhook(ctx context.Context, url string, eventTypes []string, secret string) (webhooks.Webhook, error) {
+	defer s.recordQuery(ctx, OPERATION_WEBHOOKS, time.Now())
+
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	webhook := webhooks.Webhook{URL: url, Events: eventTypes, Secret: secret, CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}
+
+	eventsJSON, err := json.Marshal(eventTypes)
+	if err != nil {
+		return webhook, err
+	}
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO webhooks (url, events, secret, created_at)
+						  VALUES (?, ?, ?, ?)`, url, eventsJSON, secret, webhook.CreatedAt)
+	if err != nil {
+		return webhook, err
+	}
+
+	webhook.ID, err = result.LastInsertId()
+
+	return webhook, err
+}
+
+// Returns every registered webhook, in the order they were registered.
+func (s *MySQLStore) Webhooks(ctx context.Context) ([]webhooks.Webhook, error) {
+	defer s.recordQuery(ctx, OPERATION_WEBHOOKS, time.Now())
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, url, events, secret, created_at
+						  FROM webhooks
+						  ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []webhooks.Webhook
+	for rows.Next() {
+		v, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, rows.Err()
+}
+
+// Returns the webhook with the given ID, or sql.ErrNoRows if there is none.
+func (s *MySQLStore) Webhook(ctx context.Context, id int64) (webhooks.Webhook, error) {
+	defer s.recordQuery(ctx, OPERATION_WEBHOOKS, time.Now())
+
+	row := s.db.QueryRowContext(ctx, `SELECT id, url, events, secret, created_at
+					      FROM webhooks
+					      WHERE id = ?`, id)
+
+	return scanWebhook(row)
+}
+
+// Deletes the webhook with the given ID and its deliveries, and returns false if there was none.
+func (s *MySQLStore) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	defer s.recordQuery(ctx, OPERATION_WEBHOOKS, time.Now())
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks
+						  WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return n > 0, err
+}
+
+// Enqueues a delivery of the event to every webhook accepting it, once per webhook,
+// and returns the number of deliveries enqueued.
+func (s *MySQLStore) EnqueueDeliveries(ctx context.Context, event events.Event, payload []byte) (int, error) {
+	defer s.recordQuery(ctx, OPERATION_ENQUEUE_DELIVERIES, time.Now())
+
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, `INSERT IGNORE INTO webhook_deliveries
+						  (webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+						  SELECT id, ?, ?, ?, ?, ?, ?
+						  FROM webhooks
+						  WHERE JSON_LENGTH(events) = 0
+						  OR JSON_CONTAINS(events, JSON_QUOTE(?))`,
+		event.ID, event.Type, payload, webhooks.STATUS_PENDING, now, now, event.Type)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+
+	return int(n), err
+}
+
+/*
+Creates a ping delivery of the webhook with the given ID, and returns it ready to be sent by the caller.
+The delivery is claimed until lease, after which it is sent by a deliverer if its attempt was not recorded.
+Returns sql.ErrNoRows if there is no such webhook.
+*/
+func (s *MySQLStore) CreatePingDelivery(ctx context.Context, webhookID int64, payload []byte, lease time.Time) (webhooks.Delivery, error) {
+	webhook, err := s.Webhook(ctx, webhookID)
+	if err != nil {
+		return webhooks.Delivery{}, err
+	}
+
+	defer s.recordQuery(ctx, OPERATION_ENQUEUE_DELIVERIES, time.Now())
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	delivery := webhooks.Delivery{
+		WebhookID:     webhook.ID,
+		URL:           webhook.URL,
+		Secret:        webhook.Secret,
+		EventType:     webhooks.TYPE_PING,
+		Payload:       payload,
+		Status:        webhooks.STATUS_PENDING,
+		NextAttemptAt: lease,
+		CreatedAt:     now,
+	}
+
+	result, err := s.db.ExecContext(ctx, `INSERT INTO webhook_deliveries
+						  (webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+						  VALUES (?, NULL, ?, ?, ?, ?, ?)`,
+		webhook.ID, webhooks.TYPE_PING, payload, webhooks.STATUS_PENDING, lease, now)
+	if err != nil {
+		return delivery, err
+	}
+
+	delivery.ID, err = result.LastInsertId()
+
+	return delivery, err
+}
+
+// Claims up to limit pending deliveries which are due at now until lease, in order.
+// Claimed deliveries are skipped by other deliverers until their lease ends.
+func (s *MySQLStore) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Time, limit int) ([]webhooks.Delivery, error) {
+	defer s.recordQuery(ctx, OPERATION_CLAIM_DELIVERIES, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT webhook_deliveries.id, webhook_deliveries.webhook_id,
+						 webhooks.url, webhooks.secret, webhook_deliveries.event_id,
+						 webhook_deliveries.event_type, webhook_deliveries.payload,
+						 webhook_deliveries.status, webhook_deliveries.attempts,
+						 webhook_deliveries.next_attempt_at, webhook_deliveries.created_at
+					   FROM webhook_deliveries
+					   INNER JOIN webhooks
+					   ON webhook_deliveries.webhook_id = webhooks.id
+					   WHERE webhook_deliveries.status = ?
+					   AND webhook_deliveries.next_attempt_at <= ?
+					   ORDER BY webhook_deliveries.id
+					   LIMIT ?
+					   FOR UPDATE OF webhook_deliveries SKIP LOCKED`, webhooks.STATUS_PENDING, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []webhooks.Delivery
+	for rows.Next() {
+		var v webhooks.Delivery
+		var eventID sql.NullInt64
+		err := rows.Scan(&v.ID, &v.WebhookID, &v.URL, &v.Secret, &eventID, &v.EventType, &v.Payload,
+			&v.Status, &v.Attempts, &v.NextAttemptAt, &v.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		v.EventID = eventID.Int64
+		deliveries = append(deliveries, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	ids := make([]any, len(deliveries))
+	for i, v := range deliveries {
+		ids[i] = v.ID
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE webhook_deliveries
+				  SET next_attempt_at = ?
+				  WHERE id IN (`+placeholders(len(ids))+`)`, append([]any{lease}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, tx.Commit()
+}
+
+// Records the attempt, and updates the status of its delivery and when it is next attempted.
+func (s *MySQLStore) RecordAttempt(ctx context.Context, attempt webhooks.Attempt, status string, nextAttemptAt time.Time) error {
+	defer s.recordQuery(ctx, OPERATION_RECORD_ATTEMPT, time.Now())
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var responseStatus, attemptError any
+	if attempt.ResponseStatus != 0 {
+		responseStatus = attempt.ResponseStatus
+	}
+	if attempt.Error != "" {
+		attemptError = attempt.Error
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO webhook_attempts
+				  (delivery_id, attempted_at, response_status, error, duration_ms)
+				  VALUES (?, ?, ?, ?, ?)`,
+		attempt.DeliveryID, attempt.AttemptedAt, responseStatus, attemptError, attempt.Duration.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE webhook_deliveries
+				  SET attempts = attempts + 1, status = ?, next_attempt_at = ?
+				  WHERE id = ?`, status, nextAttemptAt, attempt.DeliveryID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Returns at most limit of the latest deliveries of the webhook with their attempts, latest first.
+func (s *MySQLStore) DeliveriesOf(ctx context.Context, webhookID int64, limit int) ([]webhooks.Delivery, error) {
+	defer s.recordQuery(ctx, OPERATION_DELIVERIES, time.Now())
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, webhook_id, event_id, event_type, status,
+							 attempts, next_attempt_at, created_at
+						  FROM webhook_deliveries
+						  WHERE webhook_id = ?
+						  ORDER BY id DESC
+						  LIMIT ?`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []webhooks.Delivery
+	for rows.Next() {
+		var v webhooks.Delivery
+		var eventID sql.NullInt64
+		err := rows.Scan(&v.ID, &v.WebhookID, &eventID, &v.EventType, &v.Status,
+			&v.Attempts, &v.NextAttemptAt, &v.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		v.EventID = eventID.Int64
+		deliveries = append(deliveries, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	ids := make([]any, len(deliveries))
+	index := make(map[int64]int, len(deliveries))
+	for i, v := range deliveries {
+		ids[i] = v.ID
+		index[v.ID] = i
+	}
+
+	// Add the attempts of the deliveries.
+	rows, err = s.db.QueryContext(ctx, `SELECT delivery_id, attempted_at, response_status, error, duration_ms
+						 FROM webhook_attempts
+						 WHERE delivery_id IN (`+placeholders(len(ids))+`)
+						 ORDER BY id`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v webhooks.Attempt
+		var responseStatus sql.NullInt64
+		var attemptError sql.NullString
+		var durationMs int64
+		if err := rows.Scan(&v.DeliveryID, &v.AttemptedAt, &responseStatus, &attemptError, &durationMs); err != nil {
+			return nil, err
+		}
+		v.ResponseStatus = int(responseStatus.Int64)
+		v.Error = attemptError.String
+		v.Duration = time.Duration(durationMs) * time.Millisecond
+
+		i := index[v.DeliveryID]
+		deliveries[i].History = append(deliveries[i].History, v)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Scanner of a row, either *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Returns the webhook of a row of the columns id, url, events, secret and created_at.
+func scanWebhook(row scanner) (webhooks.Webhook, error) {
+	var v webhooks.Webhook
+	var eventsJSON []byte
+	if err := row.Scan(&v.ID, &v.URL, &eventsJSON, &v.Secret, &v.CreatedAt); err != nil {
+		return v, err
+	}
+
+	err := json.Unmarshal(eventsJSON, &v.Events)
+
+	return v, err
+}
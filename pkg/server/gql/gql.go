@@ -0,0 +1,229 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"govtech/pkg/models/response"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+)
+
+// Structure for a GraphQL request, the body of a POST request to "/graphql".
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// Executes GraphQL requests against the schema within the limits.
+type Handler struct {
+	schema graphql.Schema
+	limits Limits
+}
+
+// Returns a handler of GraphQL requests within the given limits.
+func NewHandler(limits Limits) (*Handler, error) {
+	schema, err := NewSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{schema: schema, limits: limits}, nil
+}
+
+// Returns the schema of the handler.
+func (h *Handler) Schema() *graphql.Schema {
+	return &h.schema
+}
+
+/*
+Parses, validates and executes the request with the given store and service.
+Requests over the depth or complexity limits are rejected before any field is resolved.
+Error messages are in the given language.
+*/
+func (h *Handler) Execute(ctx context.Context, store database.Store, service *services.Service, req *Request, lang string) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	depth, complexity := Measure(&h.schema, doc, req.OperationName)
+	if h.limits.MaxDepth > 0 && depth > h.limits.MaxDepth {
+		return limitResult(lang, messages.CODE_QUERY_TOO_DEEP, messages.MESSAGE_QUERY_TOO_DEEP, depth, h.limits.MaxDepth)
+	}
+	if h.limits.MaxComplexity > 0 && complexity > h.limits.MaxComplexity {
+		return limitResult(lang, messages.CODE_QUERY_TOO_COMPLEX, messages.MESSAGE_QUERY_TOO_COMPLEX, complexity, h.limits.MaxComplexity)
+	}
+
+	ctx = context.WithValue(ctx, requestKey, &requestState{
+		store:   store,
+		service: service,
+		lang:    lang,
+		loaders: newLoaders(store),
+	})
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+}
+
+// Returns the result of a request rejected for exceeding a limit.
+func limitResult(lang string, code string, message string, value int, limit int) *graphql.Result {
+	err := &Error{
+		Message: messages.Translate(lang, message, strconv.Itoa(value), strconv.Itoa(limit)),
+		Code:    code,
+	}
+
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{{
+		Message:    err.Message,
+		Locations:  []location.SourceLocation{},
+		Extensions: err.Extensions(),
+	}}}
+}
+
+type contextKey struct{}
+
+var requestKey = contextKey{}
+
+// Structure for the state of a request, shared by its resolvers.
+type requestState struct {
+	store   database.Store
+	service *services.Service
+	lang    string
+	loaders *loaders
+}
+
+// Loaders of the relationships of a request.
+type loaders struct {
+	// Whether each teacher exists.
+	teachers *Loader[bool]
+	// Each student, or nil if the student does not exist.
+	students *Loader[*database.Student]
+	// Students registered to each teacher.
+	studentsOf *Loader[[]string]
+	// Teachers registered to each student.
+	teachersOf *Loader[[]string]
+}
+
+func newLoaders(store database.Store) *loaders {
+	return &loaders{
+		teachers: NewLoader(func(ctx context.Context, keys []string) (map[string]bool, error) {
+			teachers, err := store.FindTeachers(ctx, keys)
+			if err != nil {
+				return nil, err
+			}
+
+			values := make(map[string]bool, len(teachers))
+			for _, v := range teachers {
+				values[v] = true
+			}
+			return values, nil
+		}),
+		students: NewLoader(func(ctx context.Context, keys []string) (map[string]*database.Student, error) {
+			students, err := store.FindStudents(ctx, keys)
+			if err != nil {
+				return nil, err
+			}
+
+			values := make(map[string]*database.Student, len(students))
+			for i, v := range students {
+				values[v.Email] = &students[i]
+			}
+			return values, nil
+		}),
+		studentsOf: NewLoader(store.StudentsOfTeachers),
+		teachersOf: NewLoader(store.TeachersOfStudents),
+	}
+}
+
+func stateOf(ctx context.Context) *requestState {
+	return ctx.Value(requestKey).(*requestState)
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	return stateOf(ctx).loaders
+}
+
+func languageOf(ctx context.Context) string {
+	if state, ok := ctx.Value(requestKey).(*requestState); ok {
+		return state.lang
+	}
+
+	return messages.LANGUAGE_DEFAULT
+}
+
+// Structure for an error of a GraphQL request, with its code in the extensions of the error.
+type Error struct {
+	Message string
+	Code    string
+	Fields  []response.FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.Code}
+	if len(e.Fields) > 0 {
+		extensions["errors"] = e.Fields
+	}
+
+	return extensions
+}
+
+// Returns the error for a validation problem, with its field errors in the language of the request.
+func validationError(ctx context.Context, problem *response.Problem) error {
+	lang := languageOf(ctx)
+
+	errs := make([]response.FieldError, len(problem.Errors))
+	for i, v := range problem.Errors {
+		v.Detail = messages.FieldErrorMessage(lang, v.Code, v.Param)
+		errs[i] = v
+	}
+
+	return &Error{
+		Message: messages.Translate(lang, problem.DetailCode),
+		Code:    problem.Code,
+		Fields:  errs,
+	}
+}
+
+/*
+Returns the error for an error returned by the store.
+The error is a timeout if the deadline of the request was reached.
+*/
+func databaseError(ctx context.Context, err error) error {
+	lang := languageOf(ctx)
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(ctx, "request deadline exceeded", "error", err)
+		return &Error{Message: messages.Translate(lang, messages.MESSAGE_TIMEOUT), Code: messages.CODE_TIMEOUT}
+	case errors.Is(err, context.Canceled):
+		slog.InfoContext(ctx, "request cancelled by client", "error", err)
+		return &Error{Message: err.Error(), Code: messages.CODE_TIMEOUT}
+	default:
+		slog.ErrorContext(ctx, "database error", "error", err)
+		return &Error{Message: messages.Translate(lang, messages.MESSAGE_DATABASE_ERROR), Code: messages.CODE_DATABASE_ERROR}
+	}
+}
@@ -0,0 +1,152 @@
+package gql
+
+import (
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"govtech/pkg/config"
+)
+
+// Assumed number of objects of a list, by which the complexity of its fields is multiplied.
+const LIST_COMPLEXITY_MULTIPLIER = 10
+
+// Structure for the limits of queries, checked before they are executed.
+// A non-positive limit disables the check.
+type Limits struct {
+	// Maximum nesting of fields, eg. 3 for "{ teacher { students { email } } }".
+	MaxDepth int
+	// Maximum number of fields resolved, counting every field of a list LIST_COMPLEXITY_MULTIPLIER times.
+	MaxComplexity int
+}
+
+// Returns the limits of GraphQL queries.
+func NewLimits(c config.GraphQLConfig) Limits {
+	return Limits{
+		MaxDepth:      c.MaxDepth,
+		MaxComplexity: c.MaxComplexity,
+	}
+}
+
+/*
+Returns the depth and complexity of the operation of the document with the given name,
+or the only operation if the name is empty.
+Introspection fields, eg. "__schema", are not counted.
+*/
+func Measure(schema *graphql.Schema, doc *ast.Document, operationName string) (int, int) {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operation *ast.OperationDefinition
+
+	for _, v := range doc.Definitions {
+		switch definition := v.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+
+	if operation == nil {
+		return 0, 0
+	}
+
+	var root *graphql.Object
+	switch operation.Operation {
+	case ast.OperationTypeMutation:
+		root = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = schema.SubscriptionType()
+	default:
+		root = schema.QueryType()
+	}
+
+	m := measurer{schema: schema, fragments: fragments, visiting: make(map[string]bool)}
+	return m.measure(root, operation.SelectionSet)
+}
+
+type measurer struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	// Fragments being measured, to stop at cycles which are rejected by validation.
+	visiting map[string]bool
+}
+
+// Returns the depth and complexity of the selections of an object of the given type.
+func (m *measurer) measure(parent graphql.Type, selectionSet *ast.SelectionSet) (int, int) {
+	if selectionSet == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, v := range selectionSet.Selections {
+		var d, c int
+
+		switch selection := v.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+
+			fieldType, isList := m.fieldType(parent, selection.Name.Value)
+			d, c = m.measure(fieldType, selection.SelectionSet)
+			if isList {
+				c *= LIST_COMPLEXITY_MULTIPLIER
+			}
+			d, c = d+1, c+1
+		case *ast.InlineFragment:
+			d, c = m.measure(m.typeCondition(parent, selection.TypeCondition), selection.SelectionSet)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || m.visiting[name] {
+				continue
+			}
+
+			m.visiting[name] = true
+			d, c = m.measure(m.typeCondition(parent, fragment.TypeCondition), fragment.SelectionSet)
+			m.visiting[name] = false
+		}
+
+		depth = max(depth, d)
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+// Returns the named type of a field of an object, and true if it is a list.
+func (m *measurer) fieldType(parent graphql.Type, name string) (graphql.Type, bool) {
+	object, ok := parent.(*graphql.Object)
+	if !ok {
+		return nil, false
+	}
+
+	field, ok := object.Fields()[name]
+	if !ok {
+		return nil, false
+	}
+
+	fieldType := field.Type
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	list, isList := fieldType.(*graphql.List)
+	if isList {
+		fieldType = list.OfType
+	}
+
+	named, _ := graphql.GetNamed(fieldType).(graphql.Type)
+	return named, isList
+}
+
+// Returns the type of a fragment, or the type of the parent if it has no type condition.
+func (m *measurer) typeCondition(parent graphql.Type, condition *ast.Named) graphql.Type {
+	if condition == nil {
+		return parent
+	}
+
+	return m.schema.Type(condition.Name.Value)
+}
@@ -0,0 +1,73 @@
+package gql
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Structure for batching lookups by key into one query, to avoid a query per
+object of a list, and caching them for the request.
+Keys which are known to be needed, eg. the keys of a list being resolved, are
+primed and fetched together with the first key which is loaded.
+*/
+type Loader[V any] struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, keys []string) (map[string]V, error)
+	pending []string
+	cache   map[string]V
+}
+
+// Returns a loader fetching the values of keys with fetch.
+// Keys missing from the values returned by fetch are loaded as the zero value.
+func NewLoader[V any](fetch func(ctx context.Context, keys []string) (map[string]V, error)) *Loader[V] {
+	return &Loader[V]{fetch: fetch, cache: make(map[string]V)}
+}
+
+// Adds keys to be fetched together with the next key which is loaded.
+func (l *Loader[V]) Prime(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pending = append(l.pending, keys...)
+}
+
+// Adds the value of a key which is already known, so that it is not fetched.
+func (l *Loader[V]) Set(key string, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache[key] = value
+}
+
+// Returns the value of key, fetching it with every primed key which is not cached.
+func (l *Loader[V]) Load(ctx context.Context, key string) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if v, ok := l.cache[key]; ok {
+		return v, nil
+	}
+
+	keys := []string{key}
+	seen := map[string]bool{key: true}
+	for _, v := range l.pending {
+		if _, ok := l.cache[v]; !ok && !seen[v] {
+			keys = append(keys, v)
+			seen[v] = true
+		}
+	}
+
+	values, err := l.fetch(ctx, keys)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	l.pending = nil
+	for _, v := range keys {
+		l.cache[v] = values[v]
+	}
+
+	return l.cache[key], nil
+}
@@ -0,0 +1,385 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+
+	"govtech/pkg/models/request"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+	"govtech/pkg/utilities/problems"
+)
+
+// Values of the objects of the schema, resolved field by field.
+type teacher struct {
+	email string
+}
+
+type student struct {
+	email string
+}
+
+// The students registered to a teacher.
+type class struct {
+	teacher string
+}
+
+type notification struct {
+	teacher    string
+	text       string
+	recipients []string
+}
+
+// Resolves the objects of the schema with the store and the service of the request.
+type resolver struct{}
+
+// Returns the schema of the GraphQL API.
+func NewSchema() (graphql.Schema, error) {
+	r := &resolver{}
+
+	var teacherType, studentType, classType *graphql.Object
+
+	teacherType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Teacher",
+		Description: "A teacher, identified by email.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"email": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(teacher).email, nil
+					},
+				},
+				"students": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+					Description: "Students registered to the teacher, sorted by email.",
+					Resolve:     r.studentsOfTeacher,
+				},
+				"class": &graphql.Field{
+					Type: graphql.NewNonNull(classType),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return class{teacher: p.Source.(teacher).email}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	studentType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Student",
+		Description: "A student, identified by email.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"email": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(student).email, nil
+					},
+				},
+				"suspended": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.Boolean),
+					Resolve: r.suspended,
+				},
+				"teachers": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(teacherType))),
+					Description: "Teachers the student is registered to, sorted by email.",
+					Resolve:     r.teachersOfStudent,
+				},
+			}
+		}),
+	})
+
+	classType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Class",
+		Description: "The students registered to a teacher.",
+		Fields: graphql.Fields{
+			"teacher": &graphql.Field{
+				Type: graphql.NewNonNull(teacherType),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return teacher{email: p.Source.(class).teacher}, nil
+				},
+			},
+			"students": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+				Description: "Students of the class, sorted by email.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return r.students(p.Context, p.Source.(class).teacher)
+				},
+			},
+			"size": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Number of students of the class.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					students, err := r.students(p.Context, p.Source.(class).teacher)
+					return len(students), err
+				},
+			},
+		},
+	})
+
+	notificationType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Notification",
+		Description: "A notification from a teacher, and the students who can receive it.",
+		Fields: graphql.Fields{
+			"teacher": &graphql.Field{
+				Type: graphql.NewNonNull(teacherType),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return teacher{email: p.Source.(notification).teacher}, nil
+				},
+			},
+			"text": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(notification).text, nil
+				},
+			},
+			"recipients": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+				Description: "Students who can receive the notification, sorted by email.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return studentList(p.Context, p.Source.(notification).recipients), nil
+				},
+			},
+		},
+	})
+
+	emailArgs := graphql.FieldConfigArgument{
+		"email": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	}
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"teacher": &graphql.Field{
+				Type:        teacherType,
+				Description: "The teacher with the email, or null if there is none.",
+				Args:        emailArgs,
+				Resolve:     r.teacher,
+			},
+			"teachers": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(teacherType))),
+				Description: "Every teacher, sorted by email.",
+				Resolve:     r.teachers,
+			},
+			"student": &graphql.Field{
+				Type:        studentType,
+				Description: "The student with the email, or null if there is none.",
+				Args:        emailArgs,
+				Resolve:     r.student,
+			},
+			"students": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+				Description: "Every student, sorted by email.",
+				Resolve:     r.allStudents,
+			},
+			"class": &graphql.Field{
+				Type:        classType,
+				Description: "The class of the teacher with the email, or null if there is none.",
+				Args: graphql.FieldConfigArgument{
+					"teacher": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.class,
+			},
+			"commonStudents": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+				Description: "Students registered to all of the teachers, sorted by email.",
+				Args: graphql.FieldConfigArgument{
+					"teachers": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				},
+				Resolve: r.commonStudents,
+			},
+			"notification": &graphql.Field{
+				Type:        graphql.NewNonNull(notificationType),
+				Description: "A notification from the teacher, and the students who can receive it. Students are mentioned as @<email>.",
+				Args: graphql.FieldConfigArgument{
+					"teacher": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"text":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.notification,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func (r *resolver) teacher(p graphql.ResolveParams) (any, error) {
+	email := p.Args["email"].(string)
+
+	exists, err := loadersOf(p.Context).teachers.Load(p.Context, email)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	return teacher{email: email}, nil
+}
+
+func (r *resolver) teachers(p graphql.ResolveParams) (any, error) {
+	teachers, err := stateOf(p.Context).store.Teachers(p.Context)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+
+	return teacherList(p.Context, teachers), nil
+}
+
+func (r *resolver) student(p graphql.ResolveParams) (any, error) {
+	email := p.Args["email"].(string)
+
+	record, err := loadersOf(p.Context).students.Load(p.Context, email)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+	if record == nil {
+		return nil, nil
+	}
+
+	return student{email: email}, nil
+}
+
+func (r *resolver) allStudents(p graphql.ResolveParams) (any, error) {
+	records, err := stateOf(p.Context).store.Students(p.Context)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+
+	// The suspension of every student is known, so it is not fetched again.
+	loaders := loadersOf(p.Context)
+	emails := make([]string, len(records))
+	for i, v := range records {
+		v := v
+		loaders.students.Set(v.Email, &v)
+		emails[i] = v.Email
+	}
+
+	return studentList(p.Context, emails), nil
+}
+
+func (r *resolver) class(p graphql.ResolveParams) (any, error) {
+	email := p.Args["teacher"].(string)
+
+	exists, err := loadersOf(p.Context).teachers.Load(p.Context, email)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	return class{teacher: email}, nil
+}
+
+func (r *resolver) commonStudents(p graphql.ResolveParams) (any, error) {
+	var teachers []string
+	for _, v := range p.Args["teachers"].([]any) {
+		teachers = append(teachers, v.(string))
+	}
+
+	if len(teachers) == 0 {
+		return nil, validationError(p.Context, problems.Validation(messages.MESSAGE_MISSING_QUERY_PARAMS,
+			problems.Field("teachers", messages.FIELD_CODE_REQUIRED, "")))
+	}
+
+	students, err := stateOf(p.Context).service.CommonStudents(p.Context, teachers)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+
+	return studentList(p.Context, students), nil
+}
+
+func (r *resolver) notification(p graphql.ResolveParams) (any, error) {
+	req := request.ReceieveForNotificationsRequest{
+		Teacher:      p.Args["teacher"].(string),
+		Notification: p.Args["text"].(string),
+	}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, validationError(p.Context, problems.FromBindError(err))
+	}
+
+	if !patterns.ValidatePattern(patterns.REGEX_PATTERN_NOTIFICATION, req.Notification) {
+		return nil, validationError(p.Context, problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+			problems.Field("text", messages.FIELD_CODE_FORMAT, "")))
+	}
+
+	recipients, err := stateOf(p.Context).service.RetrieveForNotifications(p.Context, req.Teacher, req.Notification)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+
+	return notification{teacher: req.Teacher, text: req.Notification, recipients: recipients}, nil
+}
+
+func (r *resolver) studentsOfTeacher(p graphql.ResolveParams) (any, error) {
+	return r.students(p.Context, p.Source.(teacher).email)
+}
+
+// Returns the students registered to the teacher.
+func (r *resolver) students(ctx context.Context, teacher string) ([]student, error) {
+	students, err := loadersOf(ctx).studentsOf.Load(ctx, teacher)
+	if err != nil {
+		return nil, databaseError(ctx, err)
+	}
+
+	return studentList(ctx, students), nil
+}
+
+func (r *resolver) teachersOfStudent(p graphql.ResolveParams) (any, error) {
+	teachers, err := loadersOf(p.Context).teachersOf.Load(p.Context, p.Source.(student).email)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+
+	return teacherList(p.Context, teachers), nil
+}
+
+func (r *resolver) suspended(p graphql.ResolveParams) (any, error) {
+	record, err := loadersOf(p.Context).students.Load(p.Context, p.Source.(student).email)
+	if err != nil {
+		return nil, databaseError(p.Context, err)
+	}
+
+	return record != nil && record.Suspended, nil
+}
+
+/*
+Returns the teachers with the emails, which are known to exist.
+Primes the loaders of their fields, so that the fields of every teacher of
+the list are fetched together.
+*/
+func teacherList(ctx context.Context, emails []string) []teacher {
+	loaders := loadersOf(ctx)
+	loaders.studentsOf.Prime(emails...)
+
+	teachers := make([]teacher, len(emails))
+	for i, v := range emails {
+		loaders.teachers.Set(v, true)
+		teachers[i] = teacher{email: v}
+	}
+
+	return teachers
+}
+
+/*
+Returns the students with the emails.
+Primes the loaders of their fields, so that the fields of every student of
+the list are fetched together.
+*/
+func studentList(ctx context.Context, emails []string) []student {
+	loaders := loadersOf(ctx)
+	loaders.students.Prime(emails...)
+	loaders.teachersOf.Prime(emails...)
+
+	students := make([]student, len(emails))
+	for i, v := range emails {
+		students[i] = student{email: v}
+	}
+
+	return students
+}
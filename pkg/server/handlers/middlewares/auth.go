@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/config"
+	"govtech/pkg/server/auth"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Scheme of the credentials of requests in the Authorization header, eg. "Authorization: Bearer <key>".
+const AUTH_SCHEME_BEARER = "Bearer"
+
+// Query parameter of tokens, for clients which cannot set headers, eg. EventSource in browsers.
+const QUERY_TOKEN = "token"
+
+// Structure for the configuration of the authentication of requests.
+type AuthConfig struct {
+	// Key of administrators required by RequireAdminKey, which refuses every request if it is empty.
+	AdminKey string
+	// Signer of the tokens of teachers and students required by RequireToken, which refuses every request if it is nil.
+	Tokens *auth.Signer
+}
+
+// Returns the configuration of the authentication of requests.
+func NewAuthConfig(c config.AuthConfig) AuthConfig {
+	result := AuthConfig{AdminKey: c.AdminKey}
+	if c.TokenSecret != "" {
+		result.Tokens = auth.NewSigner(c.TokenSecret)
+	}
+
+	return result
+}
+
+// Registers middleware to router, which passes the configuration to the handlers authenticating requests.
+// Must be registered before the endpoints.
+func RegisterAuthMiddleware(router *gin.Engine, config *AuthConfig) {
+	router.Use(func(c *gin.Context) {
+		c.Set("auth", config)
+		c.Next()
+	})
+}
+
+/*
+Aborts requests which do not have the admin key as a bearer token with 401.
+Every request is refused if the router has no admin key or no auth middleware, so that the endpoints are never open by default.
+*/
+func RequireAdminKey(c *gin.Context) {
+	config := authConfig(c)
+	if config == nil || auth.VerifyKey(config.AdminKey, BearerToken(c.Request)) != nil {
+		unauthorized(c, messages.MESSAGE_INVALID_ADMIN_KEY)
+		return
+	}
+
+	c.Next()
+}
+
+/*
+Returns true if the request has a token authenticating the subject, eg. the email of a student, as a
+bearer token or in the "token" query parameter. Otherwise aborts the request with 401 and returns false.
+Every request is refused if the router has no secret of tokens.
+*/
+func RequireToken(c *gin.Context, subject string) bool {
+	token := BearerToken(c.Request)
+	if token == "" {
+		token = c.Query(QUERY_TOKEN)
+	}
+
+	config := authConfig(c)
+	if config == nil || config.Tokens == nil || config.Tokens.Verify(subject, token, time.Now()) != nil {
+		unauthorized(c, messages.MESSAGE_INVALID_TOKEN)
+		return false
+	}
+
+	return true
+}
+
+// Returns the configuration of the auth middleware, or nil if the router has none.
+func authConfig(c *gin.Context) *AuthConfig {
+	value, _ := c.Get("auth")
+	config, _ := value.(*AuthConfig)
+
+	return config
+}
+
+// Returns the bearer token of the Authorization header of the request, or "" if it has none.
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, AUTH_SCHEME_BEARER) {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// Aborts the request with 401, asking for a bearer token.
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", AUTH_SCHEME_BEARER)
+	AbortWithProblem(c, problems.New(http.StatusUnauthorized, messages.CODE_UNAUTHORIZED, message))
+}
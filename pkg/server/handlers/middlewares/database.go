@@ -4,16 +4,27 @@ import (
 	"database/sql"
 
 	"github.com/gin-gonic/gin"
+
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/services"
 )
 
 // Registers middleware to router.
 func RegisterDatabaseMiddleware(router *gin.Engine, db *sql.DB) {
+	RegisterServiceMiddleware(router, services.New(database.NewStore(db)))
+}
+
+// Registers middleware to router, using a service shared with other servers, eg. the gRPC server.
+func RegisterServiceMiddleware(router *gin.Engine, service *services.Service) {
+	store := service.Store()
+
 	router.Use(func(c *gin.Context) {
-		DatabaseMiddleware(c, db)
+		DatabaseMiddleware(c, store, service)
 	})
 }
 
-func DatabaseMiddleware(c *gin.Context, db *sql.DB) {
-	c.Set("db", db)
+func DatabaseMiddleware(c *gin.Context, store database.Store, service *services.Service) {
+	c.Set("store", store)
+	c.Set("service", service)
 	c.Next()
 }
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/config"
+)
+
+// Headers announcing the deprecation of routes, see RFC 9745 and RFC 8594.
+const HEADER_DEPRECATION = "Deprecation"
+const HEADER_SUNSET = "Sunset"
+const HEADER_LINK = "Link"
+
+// Structure for configuration for deprecated routes.
+type DeprecationConfig struct {
+	// Date from which the routes are deprecated.
+	// The routes are announced as deprecated without a date if not set.
+	Deprecation time.Time
+	// Date after which the routes may stop responding. Not announced if not set.
+	Sunset time.Time
+	// Prefix of the routes which replace the deprecated routes, eg. "/api/v2".
+	Successor string
+}
+
+// Returns the configuration of deprecated routes replaced by the routes at successor.
+// The configuration must have been validated.
+func NewDeprecationConfig(c config.APIConfig, successor string) DeprecationConfig {
+	result := DeprecationConfig{Successor: successor}
+	result.Deprecation, _ = config.ParseDate(c.V1Deprecation)
+	result.Sunset, _ = config.ParseDate(c.V1Sunset)
+
+	return result
+}
+
+// Registers middleware to a group of deprecated routes.
+func RegisterDeprecationMiddleware(group *gin.RouterGroup, config *DeprecationConfig) {
+	prefix := group.BasePath()
+
+	group.Use(func(c *gin.Context) {
+		DeprecationMiddleware(c, config, prefix)
+	})
+}
+
+/*
+Adds the Deprecation and Sunset headers to the response, and a link to the
+successor of the route if there is one.
+The prefix is the prefix of the deprecated routes, replaced by the prefix of the successor.
+*/
+func DeprecationMiddleware(c *gin.Context, config *DeprecationConfig, prefix string) {
+	if config.Deprecation.IsZero() {
+		c.Header(HEADER_DEPRECATION, "true")
+	} else {
+		c.Header(HEADER_DEPRECATION, fmt.Sprintf("@%d", config.Deprecation.Unix()))
+	}
+
+	if !config.Sunset.IsZero() {
+		c.Header(HEADER_SUNSET, config.Sunset.UTC().Format(http.TimeFormat))
+	}
+
+	if config.Successor != "" {
+		successor := config.Successor + strings.TrimPrefix(c.FullPath(), prefix)
+		c.Header(HEADER_LINK, fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+	}
+
+	c.Next()
+}
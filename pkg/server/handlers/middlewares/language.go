@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/utilities/messages"
+)
+
+// Headers used to negotiate the language of messages.
+const HEADER_ACCEPT_LANGUAGE = "Accept-Language"
+const HEADER_CONTENT_LANGUAGE = "Content-Language"
+
+// Registers middleware to router.
+func RegisterLanguageMiddleware(router *gin.Engine) {
+	router.Use(LanguageMiddleware)
+}
+
+/*
+Negotiates the language of messages from the Accept-Language header of the request,
+and adds it to the context and the Content-Language header of the response.
+*/
+func LanguageMiddleware(c *gin.Context) {
+	lang := NegotiateLanguage(c.GetHeader(HEADER_ACCEPT_LANGUAGE))
+
+	c.Set("language", lang)
+	c.Header(HEADER_CONTENT_LANGUAGE, lang)
+	c.Writer.Header().Add("Vary", HEADER_ACCEPT_LANGUAGE)
+
+	c.Next()
+}
+
+/*
+Returns the supported language with the highest quality in an Accept-Language
+header, eg. "zh-CN,zh;q=0.9,en;q=0.8", or the default language if none is accepted.
+Regional variants match their primary language, eg. "ms-MY" matches "ms".
+*/
+func NegotiateLanguage(header string) string {
+	best := messages.LANGUAGE_DEFAULT
+	bestQuality := 0.0
+
+	for _, v := range strings.Split(header, ",") {
+		tag, quality, ok := parseLanguageRange(v)
+		if !ok || quality <= bestQuality {
+			continue
+		}
+
+		if tag == "*" {
+			best, bestQuality = messages.LANGUAGE_DEFAULT, quality
+		} else if messages.IsSupported(tag) {
+			best, bestQuality = tag, quality
+		}
+	}
+
+	return best
+}
+
+// Returns the primary language and quality of a language range, eg. "en-GB;q=0.8".
+func parseLanguageRange(s string) (string, float64, bool) {
+	tag, params, _ := strings.Cut(strings.TrimSpace(s), ";")
+	if tag == "" {
+		return "", 0, false
+	}
+
+	quality := 1.0
+	if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+		v, err := strconv.ParseFloat(q, 64)
+		if err != nil || v < 0 || v > 1 {
+			return "", 0, false
+		}
+		quality = v
+	}
+
+	primary, _, _ := strings.Cut(tag, "-")
+	primary, _, _ = strings.Cut(primary, "_")
+
+	return strings.ToLower(primary), quality, true
+}
@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/models/response"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Registers middlewares to router.
+func RegisterLoggerMiddleware(router *gin.Engine) {
+	router.Use(LoggerMiddleware)
+	router.Use(gin.CustomRecoveryWithWriter(nil, RecoveryHandler))
+}
+
+// Logs a line for every request once it has been handled.
+func LoggerMiddleware(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	} else if status >= http.StatusBadRequest {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("route", c.FullPath()),
+		slog.Int("status", status),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.String("client_ip", c.ClientIP()),
+		slog.Int("bytes", c.Writer.Size()),
+	}
+	if problem, ok := c.Get(KEY_PROBLEM); ok {
+		attrs = append(attrs, slog.String("problem", problem.(*response.Problem).Code))
+	}
+	if len(c.Errors) > 0 {
+		attrs = append(attrs, slog.String("errors", c.Errors.String()))
+	}
+
+	slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+}
+
+// Logs the recovered panic and returns an internal server error.
+func RecoveryHandler(c *gin.Context, err any) {
+	// Handlers abort the connection with http.ErrAbortHandler, eg. exports which fail midway,
+	// so that the client does not take the response for a complete one.
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+
+	slog.ErrorContext(c.Request.Context(), "panic while handling request",
+		"error", err, "stack", string(debug.Stack()))
+	// The problem middleware runs inside the recovery, so the problem is written here.
+	writeProblem(c, problems.New(http.StatusInternalServerError, messages.CODE_INTERNAL_ERROR, messages.MESSAGE_INTERNAL_ERROR))
+	c.Abort()
+}
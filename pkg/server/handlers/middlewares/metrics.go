@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/server/metrics"
+)
+
+// Registers middleware to router.
+func RegisterMetricsMiddleware(router *gin.Engine) {
+	router.Use(MetricsMiddleware)
+}
+
+// Records the count and latency of every request by route.
+func MetricsMiddleware(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	// Group requests to unknown routes together.
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	status := strconv.Itoa(c.Writer.Status())
+	metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+}
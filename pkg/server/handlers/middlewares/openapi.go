@@ -0,0 +1,296 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/config"
+	"govtech/pkg/models/response"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+	"govtech/pkg/utilities/problems"
+)
+
+// Structure for configuration for validation against the OpenAPI document.
+type OpenAPIConfig struct {
+	// Validates responses as well, eg. in tests, to catch drift from the document.
+	ValidateResponses bool
+	// Called with every response which does not match the document.
+	// Logs the error if not set.
+	OnResponseError func(c *gin.Context, err error)
+}
+
+// Returns the configuration of the OpenAPI validation middleware.
+func NewOpenAPIConfig(c config.FeaturesConfig) OpenAPIConfig {
+	return OpenAPIConfig{ValidateResponses: c.ResponseValidation}
+}
+
+func init() {
+	// Validate the "email" format the same way as the "email" tag of request bodies.
+	openapi3.DefineStringFormatCallback(messages.FIELD_CODE_EMAIL, func(value string) error {
+		if !patterns.IsEmail(value) {
+			return errors.New("not an email")
+		}
+		return nil
+	})
+
+	// Validate JSON Lines, eg. of exports, as strings.
+	openapi3filter.RegisterBodyDecoder(MIME_NDJSON, openapi3filter.FileBodyDecoder)
+}
+
+// Registers middleware to router.
+func RegisterOpenAPIMiddleware(router *gin.Engine, doc *openapi3.T, config *OpenAPIConfig) error {
+	middleware, err := OpenAPIMiddleware(doc, config)
+	if err != nil {
+		return err
+	}
+
+	router.Use(middleware)
+	return nil
+}
+
+/*
+Returns middleware which validates query parameters and JSON bodies of requests
+against the OpenAPI document before they reach the controllers.
+Requests to routes which are not in the document are passed on as is.
+*/
+func OpenAPIMiddleware(doc *openapi3.T, config *OpenAPIConfig) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	onResponseError := config.OnResponseError
+	if onResponseError == nil {
+		onResponseError = func(c *gin.Context, err error) {
+			slog.ErrorContext(c.Request.Context(), "response does not match openapi document", "error", err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		// Bodies without a content type are parsed as JSON by the controllers.
+		if c.Request.ContentLength != 0 && c.GetHeader("Content-Type") == "" {
+			c.Request.Header.Set("Content-Type", "application/json")
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				ExcludeRequestBody: IsStreamingUpload(c.Request) || isUnsupportedBody(c.Request, route),
+			},
+		}
+
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			AbortWithProblem(c, requestProblem(err, route))
+			return
+		}
+
+		// Streaming responses are not recorded, as they last until the client disconnects.
+		if !config.ValidateResponses || IsStreamingRoute(c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		writer := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		// The problem of the request is written once the handlers return, so it is written here to be validated.
+		WriteAbortedProblem(c)
+
+		output := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Body:                   io.NopCloser(&writer.body),
+			Options:                &openapi3filter.Options{MultiError: true, IncludeResponseStatus: true},
+		}
+		if err := openapi3filter.ValidateResponse(c.Request.Context(), output); err != nil {
+			onResponseError(c, err)
+		}
+	}, nil
+}
+
+/*
+Returns true if the body of the request is of a content type which the operation does not accept,
+and the operation documents 415, so that the controller responds with it instead of 400.
+*/
+func isUnsupportedBody(r *http.Request, route *routers.Route) bool {
+	body := route.Operation.RequestBody
+	if body == nil || body.Value == nil || route.Operation.Responses.Get(http.StatusUnsupportedMediaType) == nil {
+		return false
+	}
+
+	return body.Value.Content.Get(r.Header.Get("Content-Type")) == nil
+}
+
+// Response writer which keeps a copy of the response body.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+/*
+Returns the problem for an error returned by validating a request.
+Well-typed bodies which fail validation are reported with 422 if the operation
+documents it, and everything else with 400, the same as the controllers.
+*/
+func requestProblem(err error, route *routers.Route) *response.Problem {
+	status := http.StatusBadRequest
+	errs := []response.FieldError{}
+
+	for _, v := range unwrapErrors(err) {
+		var requestError *openapi3filter.RequestError
+		if !errors.As(v, &requestError) {
+			continue
+		}
+
+		if requestError.Parameter != nil {
+			errs = append(errs, fieldErrors(requestError.Parameter.Name, requestError.Err)...)
+			continue
+		}
+
+		var schemaError *openapi3.SchemaError
+		if !errors.As(requestError.Err, &schemaError) {
+			// The body is missing, of the wrong content type or not valid JSON.
+			return problems.New(http.StatusBadRequest, messages.CODE_BAD_REQUEST, messages.MESSAGE_INVALID_JSON)
+		}
+
+		bodyErrs := fieldErrors("", requestError.Err)
+		if route.Operation.Responses.Get(http.StatusUnprocessableEntity) != nil && !hasTypeError(bodyErrs) {
+			status = http.StatusUnprocessableEntity
+		}
+		errs = append(errs, bodyErrs...)
+	}
+
+	if len(errs) == 0 {
+		return problems.New(http.StatusBadRequest, messages.CODE_BAD_REQUEST, messages.MESSAGE_BAD_REQUEST)
+	}
+
+	problem := problems.Validation(messages.MESSAGE_VALIDATION_FAILED, errs...)
+	problem.Status = status
+
+	return problem
+}
+
+// Returns the field errors of a parameter or the body, named by their path from the given name.
+func fieldErrors(name string, err error) []response.FieldError {
+	if errors.Is(err, openapi3filter.ErrInvalidRequired) || errors.Is(err, openapi3filter.ErrInvalidEmptyValue) {
+		return []response.FieldError{problems.Field(name, messages.FIELD_CODE_REQUIRED, "")}
+	}
+
+	errs := []response.FieldError{}
+	for _, v := range unwrapErrors(err) {
+		var schemaError *openapi3.SchemaError
+		if !errors.As(v, &schemaError) {
+			errs = append(errs, problems.Field(name, messages.FIELD_CODE_FORMAT, ""))
+			continue
+		}
+
+		code, param := schemaErrorCode(schemaError)
+		errs = append(errs, problems.Field(fieldName(name, schemaError.JSONPointer()), code, param))
+	}
+
+	return errs
+}
+
+// Returns true if a field is of the wrong type.
+func hasTypeError(errs []response.FieldError) bool {
+	for _, v := range errs {
+		if v.Code == messages.FIELD_CODE_TYPE {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the field code and validation parameter of a schema error.
+func schemaErrorCode(err *openapi3.SchemaError) (string, string) {
+	schema := err.Schema
+
+	switch err.SchemaField {
+	case "required", "minItems":
+		return messages.FIELD_CODE_REQUIRED, ""
+	case "format":
+		if schema.Format == messages.FIELD_CODE_EMAIL {
+			return messages.FIELD_CODE_EMAIL, ""
+		}
+		return messages.FIELD_CODE_FORMAT, ""
+	case "pattern":
+		return messages.FIELD_CODE_FORMAT, ""
+	case "type":
+		return messages.FIELD_CODE_TYPE, schema.Type
+	case "maxLength":
+		if schema.MaxLength != nil {
+			return "max", strconv.FormatUint(*schema.MaxLength, 10)
+		}
+	case "maxItems":
+		if schema.MaxItems != nil {
+			return messages.FIELD_CODE_MAX_ITEMS, strconv.FormatUint(*schema.MaxItems, 10)
+		}
+	}
+
+	return err.SchemaField, ""
+}
+
+// Returns the name of a field from its JSON pointer, eg. "students[1]" for "/students/1".
+func fieldName(name string, pointer []string) string {
+	var builder strings.Builder
+	builder.WriteString(name)
+
+	for _, v := range pointer {
+		if _, err := strconv.Atoi(v); err == nil {
+			builder.WriteString("[" + v + "]")
+		} else if builder.Len() > 0 {
+			builder.WriteString("." + v)
+		} else {
+			builder.WriteString(v)
+		}
+	}
+
+	return builder.String()
+}
+
+// Returns the errors of a multi error, or the error itself.
+func unwrapErrors(err error) []error {
+	if multiError, ok := err.(openapi3.MultiError); ok {
+		errs := []error{}
+		for _, v := range multiError {
+			errs = append(errs, unwrapErrors(v)...)
+		}
+		return errs
+	}
+
+	return []error{err}
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/models/response"
+	"govtech/pkg/utilities/problems"
+)
+
+// Key of the problem of the request in the gin.Context.
+const KEY_PROBLEM = "problem"
+
+// Registers middleware to router.
+// It must be registered after the middlewares which read the response status, eg. logging and metrics.
+func RegisterProblemMiddleware(router *gin.Engine) {
+	router.Use(ProblemMiddleware)
+}
+
+// Writes the problem of the request, if any, once it has been handled.
+func ProblemMiddleware(c *gin.Context) {
+	c.Next()
+	WriteAbortedProblem(c)
+}
+
+/*
+Writes the problem the request was aborted with, unless a response has already been written.
+Middlewares which read the response after the handlers, eg. response validation, call it first.
+*/
+func WriteAbortedProblem(c *gin.Context) {
+	value, ok := c.Get(KEY_PROBLEM)
+	if !ok || c.Writer.Written() {
+		return
+	}
+
+	writeProblem(c, value.(*response.Problem))
+}
+
+// Sets the problem as the response of the request, and stops the remaining handlers.
+func AbortWithProblem(c *gin.Context, problem *response.Problem) {
+	c.Set(KEY_PROBLEM, problem)
+	c.Abort()
+}
+
+// Writes the problem as the response in the language negotiated for the request.
+func writeProblem(c *gin.Context, problem *response.Problem) {
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = c.GetString("request_id")
+	if lang := c.GetString("language"); lang != "" {
+		problems.Translate(problem, lang)
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	c.Data(problem.Status, problems.MIME_PROBLEM_JSON, body)
+}
@@ -0,0 +1,276 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/config"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Values for RateLimitConfig.KeyBy denoting what identifies a client.
+const RATE_LIMIT_KEY_IP = config.RATE_LIMIT_KEY_IP
+const RATE_LIMIT_KEY_API_KEY = config.RATE_LIMIT_KEY_API_KEY
+const RATE_LIMIT_KEY_TEACHER = config.RATE_LIMIT_KEY_TEACHER
+
+// Maximum size of a JSON body read to identify the teacher of a request, in bytes.
+const RATE_LIMIT_MAX_BODY = 1 << 20
+
+// Header used by integrations to identify themselves.
+const HEADER_API_KEY = "X-API-Key"
+
+// Structure for a token bucket limit.
+// Rate is the number of tokens added per second and Burst is the bucket size.
+// A non-positive Rate disables limiting.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Structure for configuration of the rate limiting middleware.
+type RateLimitConfig struct {
+	KeyBy   string
+	Default RateLimit
+	// Limits keyed by route path, eg. "/api/retrievefornotifications".
+	// Unversioned paths apply to every version, eg. "/api/v2/retrievefornotifications".
+	Routes map[string]RateLimit
+}
+
+// Returns the configuration of the rate limiting middleware.
+// The configuration must have been validated.
+func NewRateLimitConfig(c config.RateLimitConfig) RateLimitConfig {
+	result := RateLimitConfig{KeyBy: c.KeyBy, Routes: make(map[string]RateLimit)}
+
+	if c.Default != "" {
+		limit, _ := config.ParseRateLimit(c.Default)
+		result.Default = RateLimit(limit)
+	}
+
+	routes, _ := config.ParseRateLimitRoutes(c.Routes)
+	for route, limit := range routes {
+		result.Routes[route] = RateLimit(limit)
+	}
+
+	return result
+}
+
+// Structure for the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Storage for token buckets, so that limits can be shared across instances
+// by providing an alternative implementation.
+type RateLimitStore interface {
+	Take(key string, limit RateLimit, now time.Time) RateLimitResult
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// Time at which the bucket would have refilled completely.
+	full time.Time
+}
+
+// In-process RateLimitStore.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// Returns a new in-process rate limit store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+// Takes a token from the bucket for key, refilling it based on the time elapsed.
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) RateLimitResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+
+	// Refill bucket for the time elapsed since the last request.
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	result := RateLimitResult{Limit: limit.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((burst - b.tokens) / limit.Rate)
+	b.full = now.Add(result.Reset)
+
+	return result
+}
+
+/*
+Removes buckets which would have refilled completely, at most once a minute.
+Buckets are kept until then, as a client whose bucket is removed earlier would regain a full burst.
+*/
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for k, v := range s.buckets {
+		if !now.Before(v.full) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Registers middleware to router.
+func RegisterRateLimitMiddleware(router *gin.Engine, config *RateLimitConfig, store RateLimitStore) {
+	router.Use(func(c *gin.Context) {
+		RateLimitMiddleware(c, config, store)
+	})
+}
+
+// Returns the limit of the route, or the default limit if the route has none.
+func (config *RateLimitConfig) RouteLimit(route string) RateLimit {
+	if limit, ok := routeSetting(config.Routes, route); ok {
+		return limit
+	}
+
+	return config.Default
+}
+
+// Returns true if the limit is disabled.
+func (limit RateLimit) Disabled() bool {
+	return limit.Rate <= 0 || limit.Burst <= 0
+}
+
+/*
+Returns the key of the bucket of a client for the route.
+Versions of a route share the bucket of a client, so that they share its limit.
+*/
+func RateLimitBucket(route string, client string) string {
+	return unversionedRoute(route) + "|" + client
+}
+
+func RateLimitMiddleware(c *gin.Context, config *RateLimitConfig, store RateLimitStore) {
+	route := c.FullPath()
+	limit := config.RouteLimit(route)
+
+	// Skip limiting if disabled for the route or the route does not exist.
+	if limit.Disabled() || route == "" {
+		c.Next()
+		return
+	}
+
+	client := RateLimitClient(config.KeyBy, c.ClientIP(), c.GetHeader(HEADER_API_KEY), func() string {
+		return requestTeacher(c)
+	})
+	result := store.Take(RateLimitBucket(route, client), limit, time.Now())
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		AbortWithProblem(c, problems.New(http.StatusTooManyRequests, messages.CODE_TOO_MANY_REQUESTS, messages.MESSAGE_TOO_MANY_REQUESTS))
+		return
+	}
+
+	c.Next()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+/*
+Returns the key identifying a client by keyBy, given its IP, API key and teacher.
+Falls back to the client IP if the requested identifier is not present.
+The teacher is only read if the client is identified by it, eg. as it may be read from the body.
+*/
+func RateLimitClient(keyBy string, ip string, apiKey string, teacher func() string) string {
+	switch keyBy {
+	case RATE_LIMIT_KEY_API_KEY:
+		if apiKey != "" {
+			return "api_key:" + apiKey
+		}
+	case RATE_LIMIT_KEY_TEACHER:
+		if teacher := teacher(); teacher != "" {
+			return "teacher:" + teacher
+		}
+	}
+
+	return "ip:" + ip
+}
+
+// Returns the teachers as a single identifier, the same in any order or case.
+func RateLimitTeachers(teachers []string) string {
+	sorted := make([]string, len(teachers))
+	for i, v := range teachers {
+		sorted[i] = strings.ToLower(v)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// Returns the teacher of the request from the query string or the JSON body.
+func requestTeacher(c *gin.Context) string {
+	if teachers := c.QueryArray("teacher"); len(teachers) > 0 {
+		return RateLimitTeachers(teachers)
+	}
+
+	if c.Request.Body == nil || c.ContentType() != gin.MIMEJSON {
+		return ""
+	}
+
+	// Read the body and restore it for the handlers.
+	// Bodies above the limit are not read further, and the client is identified by its IP.
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, RATE_LIMIT_MAX_BODY))
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Teacher string `json:"teacher"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	return strings.ToLower(payload.Teacher)
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/utilities/logging"
+)
+
+// Header used to propagate the request ID.
+const HEADER_REQUEST_ID = "X-Request-ID"
+
+// Maximum length of a request ID propagated from the client.
+const MAX_REQUEST_ID_LENGTH = 128
+
+// Registers middleware to router.
+func RegisterRequestIDMiddleware(router *gin.Engine) {
+	router.Use(RequestIDMiddleware)
+}
+
+/*
+Propagates the X-Request-ID header of the request, or generates one if it is
+missing or invalid, and adds it to the request context and the response.
+*/
+func RequestIDMiddleware(c *gin.Context) {
+	requestID := c.GetHeader(HEADER_REQUEST_ID)
+	if !ValidRequestID(requestID) {
+		requestID = NewRequestID()
+	}
+
+	c.Set("request_id", requestID)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+	c.Header(HEADER_REQUEST_ID, requestID)
+
+	c.Next()
+}
+
+// Returns a random 128-bit request ID in hex.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err.Error())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Returns true if the request ID is safe to write to logs and headers.
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > MAX_REQUEST_ID_LENGTH {
+		return false
+	}
+
+	for _, v := range requestID {
+		isAlphanumeric := (v >= 'a' && v <= 'z') || (v >= 'A' && v <= 'Z') || (v >= '0' && v <= '9')
+		if !isAlphanumeric && v != '-' && v != '_' && v != '.' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"regexp"
+)
+
+// Version segment of versioned routes, eg. "/api/v1/".
+var versionPattern = regexp.MustCompile(`^/api/v[0-9]+/`)
+
+/*
+Returns the route without its API version, eg. "/api/register" for "/api/v1/register",
+so that settings of a route apply to every version of it.
+*/
+func unversionedRoute(route string) string {
+	return versionPattern.ReplaceAllString(route, "/api/")
+}
+
+// Returns the setting of the route, falling back to the setting of its unversioned route.
+func routeSetting[T any](routes map[string]T, route string) (T, bool) {
+	if v, ok := routes[route]; ok {
+		return v, true
+	}
+
+	v, ok := routes[unversionedRoute(route)]
+	return v, ok
+}
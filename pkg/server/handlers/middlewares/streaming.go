@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Content type of server-sent events.
+const MIME_EVENT_STREAM = "text/event-stream"
+
+// Content type of CSV uploads.
+const MIME_CSV = "text/csv"
+
+// Content type of JSON Lines.
+const MIME_NDJSON = "application/x-ndjson"
+
+// Time after the deadline of a transfer during which its response can still be written, eg. the problem of the deadline.
+const TRANSFER_WRITE_GRACE = 5 * time.Second
+
+// Structure for a set of routes by their full path, eg. "/api/v2/students/:email/notifications/stream".
+type routeSet struct {
+	mu    sync.RWMutex
+	paths map[string]bool
+}
+
+// Routes registered with RegisterStreamingRoute and RegisterTransferRoute.
+var streamingRoutes = &routeSet{paths: make(map[string]bool)}
+var transferRoutes = &routeSet{paths: make(map[string]bool)}
+
+// Adds the route at the path relative to the router.
+func (s *routeSet) add(r gin.IRouter, relativePath string) {
+	route := relativePath
+	if group, ok := r.(interface{ BasePath() string }); ok {
+		route = path.Join(group.BasePath(), relativePath)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paths[route] = true
+}
+
+func (s *routeSet) has(route string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.paths[route]
+}
+
+// Key of the streamControl of the server in the request context.
+type streamControlKey struct{}
+
+// Structure for what the server provides to streaming and transfer routes.
+type streamControl struct {
+	response *http.ResponseController
+	// Done once the server shuts down, as shutdown waits for in-flight requests to complete.
+	shutdown context.Context
+}
+
+/*
+Registers a GET route whose response is streamed until the client disconnects, ie. server-sent
+events or a WebSocket. Streaming routes are not bound by request deadlines or write timeouts,
+and their responses are not validated.
+The request context is cancelled once the server shuts down, so that streams end.
+*/
+func RegisterStreamingRoute(r gin.IRouter, relativePath string, handler gin.HandlerFunc) {
+	streamingRoutes.add(r, relativePath)
+
+	r.GET(relativePath, func(c *gin.Context) {
+		control, ok := c.Request.Context().Value(streamControlKey{}).(*streamControl)
+		if !ok {
+			handler(c)
+			return
+		}
+
+		if err := control.response.SetWriteDeadline(time.Time{}); err != nil {
+			slog.WarnContext(c.Request.Context(), "failed to clear write deadline of stream", "error", err)
+		}
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		stop := context.AfterFunc(control.shutdown, cancel)
+		defer stop()
+
+		c.Request = c.Request.WithContext(ctx)
+		handler(c)
+	})
+}
+
+// Returns true if the route, ie. the full path of a request, was registered with RegisterStreamingRoute.
+func IsStreamingRoute(route string) bool {
+	return streamingRoutes.has(route)
+}
+
+/*
+Registers a route which transfers a body of any size, eg. an import or an export, which takes longer
+than the read and write timeouts of the server. Transfer routes have the deadline of transfers unless
+their route has its own, and the server reads their request and writes their response until it.
+*/
+func RegisterTransferRoute(r gin.IRouter, method string, relativePath string, handler gin.HandlerFunc) {
+	transferRoutes.add(r, relativePath)
+
+	r.Handle(method, relativePath, func(c *gin.Context) {
+		control, ok := c.Request.Context().Value(streamControlKey{}).(*streamControl)
+		if !ok {
+			handler(c)
+			return
+		}
+
+		// Requests without a deadline are read and written until the client disconnects.
+		var read, write time.Time
+		if deadline, ok := c.Request.Context().Deadline(); ok {
+			read = deadline
+			write = deadline.Add(TRANSFER_WRITE_GRACE)
+		}
+
+		if err := control.response.SetReadDeadline(read); err != nil {
+			slog.WarnContext(c.Request.Context(), "failed to set read deadline of transfer", "error", err)
+		}
+		if err := control.response.SetWriteDeadline(write); err != nil {
+			slog.WarnContext(c.Request.Context(), "failed to set write deadline of transfer", "error", err)
+		}
+
+		handler(c)
+	})
+}
+
+// Returns true if the route, ie. the full path of a request, was registered with RegisterTransferRoute.
+func IsTransferRoute(route string) bool {
+	return transferRoutes.has(route)
+}
+
+/*
+Returns the request with what streaming and transfer routes need from the server in its context,
+ie. the response controller of the writer, as the router wraps the writer, and the context done
+once the server shuts down.
+*/
+func WithStreamControl(r *http.Request, w http.ResponseWriter, shutdown context.Context) *http.Request {
+	control := &streamControl{response: http.NewResponseController(w), shutdown: shutdown}
+	return r.WithContext(context.WithValue(r.Context(), streamControlKey{}, control))
+}
+
+/*
+Returns true if the request uploads a body which is parsed as it is read, ie. CSV.
+Streamed bodies are not read before the controller, eg. to validate them, so that
+uploads of any size are parsed without buffering them.
+*/
+func IsStreamingUpload(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), MIME_CSV)
+}
@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"govtech/pkg/config"
+)
+
+// Structure for configuration of the deadline of requests.
+// A non-positive duration disables the deadline.
+type TimeoutConfig struct {
+	Default time.Duration
+	// Deadlines keyed by route path, eg. "/api/retrievefornotifications".
+	// Unversioned paths apply to every version, eg. "/api/v2/retrievefornotifications".
+	Routes map[string]time.Duration
+	// Deadline of the routes registered with RegisterTransferRoute, eg. imports and exports.
+	Transfer time.Duration
+}
+
+// Returns the configuration of the deadline of requests.
+// The configuration must have been validated.
+func NewTimeoutConfig(c config.RouterConfig) TimeoutConfig {
+	result := TimeoutConfig{Default: c.RequestTimeout.Duration, Transfer: c.TransferTimeout.Duration}
+	result.Routes, _ = config.ParseRouteTimeouts(c.RequestTimeoutRoutes)
+
+	return result
+}
+
+/*
+Returns the deadline of the route, or the deadline of transfers if the route is a transfer route,
+or the default deadline otherwise.
+*/
+func (config *TimeoutConfig) RouteTimeout(route string) time.Duration {
+	if timeout, ok := routeSetting(config.Routes, route); ok {
+		return timeout
+	}
+
+	if IsTransferRoute(route) {
+		return config.Transfer
+	}
+
+	return config.Default
+}
+
+// Registers middleware to router.
+func RegisterTimeoutMiddleware(router *gin.Engine, config *TimeoutConfig) {
+	router.Use(func(c *gin.Context) {
+		TimeoutMiddleware(c, config)
+	})
+}
+
+/*
+Sets the deadline of the request context for the route.
+DB calls made with the request context are cancelled once the deadline is reached
+or the client disconnects.
+Streaming routes have no deadline.
+*/
+func TimeoutMiddleware(c *gin.Context, config *TimeoutConfig) {
+	timeout := config.RouteTimeout(c.FullPath())
+
+	if timeout <= 0 || IsStreamingRoute(c.FullPath()) {
+		c.Next()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
@@ -1,42 +1,199 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"govtech/pkg/config"
 	"govtech/pkg/controllers"
 	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
 )
 
 // Structure for configuration for the router.
 type RouterConfig struct {
-	Port string
-	Host string
+	Port              string
+	Host              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// Maximum duration to wait for in-flight requests on shutdown.
+	ShutdownTimeout time.Duration
+	// Serves HTTPS if both are set.
+	TLSCertFile string
+	TLSKeyFile  string
 }
 
-// Returns an instance of the router.
+// Returns the configuration used to run the router.
+func NewRouterConfig(c config.RouterConfig) RouterConfig {
+	return RouterConfig{
+		Port:              c.Port,
+		Host:              c.Host,
+		ReadTimeout:       c.ReadTimeout.Duration,
+		ReadHeaderTimeout: c.ReadHeaderTimeout.Duration,
+		WriteTimeout:      c.WriteTimeout.Duration,
+		IdleTimeout:       c.IdleTimeout.Duration,
+		ShutdownTimeout:   c.ShutdownTimeout.Duration,
+		TLSCertFile:       c.TLSCertFile,
+		TLSKeyFile:        c.TLSKeyFile,
+	}
+}
+
+// Returns an instance of the router with request IDs, languages, logging, metrics, recovery and problems.
 func InitRouter() *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	middlewares.RegisterRequestIDMiddleware(r)
+	middlewares.RegisterLanguageMiddleware(r)
+	middlewares.RegisterLoggerMiddleware(r)
+	middlewares.RegisterMetricsMiddleware(r)
+	middlewares.RegisterProblemMiddleware(r)
 	return r
 }
 
-// Runs the router as the assigned port and host.
-func RunRouter(router *gin.Engine, config *RouterConfig) {
-	connectionString := fmt.Sprintf("%s:%s", config.Host, config.Port)
-	router.Run(connectionString)
+/*
+Returns the server of the router at the assigned port and host, with the timeouts of the configuration.
+Streams are ended once the server shuts down, as shutdown waits for in-flight requests to complete.
+*/
+func NewServer(router *gin.Engine, config *RouterConfig) *http.Server {
+	shutdown, cancel := context.WithCancel(context.Background())
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%s", config.Host, config.Port),
+		Handler:           streamingHandler(router, shutdown),
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+	server.RegisterOnShutdown(cancel)
+
+	return server
 }
 
-// Register endpoints to the router.
-func RegisterEndpoints(router *gin.Engine, db *sql.DB) {
-	endpointRegistrations := []func(*gin.Engine){
+/*
+Runs the router as the assigned port and host until SIGINT or SIGTERM is received,
+then stops accepting connections and waits for in-flight requests to complete.
+*/
+func RunRouter(router *gin.Engine, config *RouterConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := NewServer(router, config)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+			slog.Info("server listening", "address", server.Addr, "tls", true)
+			serverErr <- server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+		} else {
+			slog.Info("server listening", "address", server.Addr, "tls", false)
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server", "timeout", config.ShutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+/*
+Returns a handler which passes the response controller of requests and the shutdown of the server
+to the router, so that streaming routes are not cut off by the write timeout and end on shutdown.
+*/
+func streamingHandler(router *gin.Engine, shutdown context.Context) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, middlewares.WithStreamControl(r, w, shutdown))
+	})
+}
+
+// Prefixes of the versions of the API.
+// The unversioned prefix is an alias of v1, the version served before versioning.
+const API_PREFIX = "/api"
+const API_V1_PREFIX = "/api/v1"
+const API_V2_PREFIX = "/api/v2"
+
+/*
+Register endpoints to the router.
+The v1 endpoints are served at "/api/v1" and "/api" and announced as deprecated
+with the given configuration. The endpoints added at "/api" after v1, eg. "/api/webhooks",
+are served there without deprecation and at "/api/v2". Endpoints added since v2 are served
+at "/api/v2" only.
+*/
+func RegisterEndpoints(router *gin.Engine, db *sql.DB, deprecation *middlewares.DeprecationConfig) {
+	v1Registrations := []func(gin.IRouter){
 		controllers.RegisterCommonStudentsEndpoint,
 		controllers.RegisterRegisterEndpoint,
 		controllers.RegisterRetrieveForNotificationEndpoint,
 		controllers.RegisterSuspendEndpoint,
 	}
 
+	unversionedRegistrations := []func(gin.IRouter){
+		controllers.RegisterNotificationStreamEndpoint,
+		controllers.RegisterWebhookEndpoints,
+		controllers.RegisterImportEndpoint,
+		controllers.RegisterExportEndpoints,
+	}
+
+	v2Registrations := append([]func(gin.IRouter){
+		controllers.RegisterCommonStudentsEndpoint,
+		controllers.RegisterRegisterEndpoint,
+		controllers.RegisterRetrieveForNotificationEndpointV2,
+		controllers.RegisterSuspendEndpoint,
+		controllers.RegisterDeregisterEndpoint,
+	}, unversionedRegistrations...)
+
+	endpointRegistrations := []func(*gin.Engine){
+		controllers.RegisterHealthEndpoints,
+		controllers.RegisterDocsEndpoints,
+	}
+
+	for _, prefix := range []string{API_PREFIX, API_V1_PREFIX} {
+		v1 := router.Group(prefix)
+		middlewares.RegisterDeprecationMiddleware(v1, deprecation)
+
+		for _, v := range v1Registrations {
+			v(v1)
+		}
+	}
+
+	// A group of its own, so that the endpoints are not announced as deprecated.
+	unversioned := router.Group(API_PREFIX)
+	for _, v := range unversionedRegistrations {
+		v(unversioned)
+	}
+
+	v2 := router.Group(API_V2_PREFIX)
+	for _, v := range v2Registrations {
+		v(v2)
+	}
+
 	for _, v := range endpointRegistrations {
 		v(router)
 	}
@@ -54,3 +211,11 @@ func RegisterMiddlewares(router *gin.Engine, db *sql.DB) {
 		v(router, db)
 	}
 }
+
+/*
+Register middlewares to the router, using a service shared with other servers, eg. the gRPC server,
+or a service of another store, eg. a memory store in tests.
+*/
+func RegisterServiceMiddlewares(router *gin.Engine, service *services.Service) {
+	middlewares.RegisterServiceMiddleware(router, service)
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry of all collectors exposed on "/metrics".
+var Registry = prometheus.NewRegistry()
+
+// HTTP metrics, labelled by route rather than path to bound cardinality.
+var HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_requests_total",
+	Help: "Number of HTTP requests handled.",
+}, []string{"method", "route", "status"})
+
+var HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "http_request_duration_seconds",
+	Help:    "Latency of HTTP requests.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route"})
+
+// DB metrics, labelled by the operation performed by the store.
+var DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Latency of DB operations.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation"})
+
+// Domain metrics.
+var NotificationsResolved = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "notifications_resolved_total",
+	Help: "Number of notifications whose recipients were resolved.",
+})
+
+var NotificationRecipients = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "notification_recipients_total",
+	Help: "Number of recipients resolved across all notifications.",
+})
+
+var StudentsSuspended = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "students_suspended_total",
+	Help: "Number of student suspensions.",
+})
+
+var Registrations = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "registrations_total",
+	Help: "Number of student and teacher pairs registered.",
+})
+
+var Deregistrations = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "deregistrations_total",
+	Help: "Number of student and teacher pairs deregistered.",
+})
+
+// Cache metrics, labelled by the cache.
+var CacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_lookups_total",
+	Help: "Number of lookups of cached values, by cache and result.",
+}, []string{"cache", "result"})
+
+var CacheInvalidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_invalidations_total",
+	Help: "Number of cached values removed by invalidations, by cache.",
+}, []string{"cache"})
+
+// Event metrics.
+var EventDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "event_deliveries_total",
+	Help: "Number of deliveries of domain events to subscribers, by subscriber and result.",
+}, []string{"subscriber", "result"})
+
+// Webhook metrics.
+var WebhookAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_attempts_total",
+	Help: "Number of attempts to deliver events to registered webhooks, by result.",
+}, []string{"result"})
+
+// WebSocket metrics.
+var WebSocketConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "websocket_connections",
+	Help: "Number of open WebSocket connections.",
+})
+
+var WebSocketSlowClients = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "websocket_slow_clients_total",
+	Help: "Number of WebSocket connections closed for falling behind their events.",
+})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		DBQueryDuration,
+		NotificationsResolved,
+		NotificationRecipients,
+		StudentsSuspended,
+		Registrations,
+		Deregistrations,
+		CacheLookups,
+		CacheInvalidations,
+		EventDeliveries,
+		WebhookAttempts,
+		WebSocketConnections,
+		WebSocketSlowClients,
+	)
+}
+
+// Registers a collector for the connection pool stats of the DB.
+func RegisterDBStatsCollector(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Maximum duration of the count of dead events for a scrape.
+const DEAD_EVENTS_TIMEOUT = 5 * time.Second
+
+var deadEventsDesc = prometheus.NewDesc("events_dead",
+	"Number of domain events which are dead after failing every attempt, and are no longer dispatched.", nil, nil)
+
+// Collector of the number of dead events, counted on every scrape as the outbox is shared by every server.
+type deadEventsCollector struct {
+	count func(ctx context.Context) (int, error)
+}
+
+// Returns a collector of the number of dead events, counted with count.
+func NewDeadEventsCollector(count func(ctx context.Context) (int, error)) prometheus.Collector {
+	return &deadEventsCollector{count: count}
+}
+
+func (c *deadEventsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- deadEventsDesc
+}
+
+// Collects the number of dead events, or nothing if it cannot be counted so that other metrics are still scraped.
+func (c *deadEventsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEAD_EVENTS_TIMEOUT)
+	defer cancel()
+
+	n, err := c.count(ctx)
+	if err != nil {
+		slog.Warn("failed to count dead events", "error", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(deadEventsDesc, prometheus.GaugeValue, float64(n))
+}
+
+// Registers a collector for the number of dead events, counted with count.
+func RegisterDeadEventsCollector(count func(ctx context.Context) (int, error)) {
+	Registry.MustRegister(NewDeadEventsCollector(count))
+}
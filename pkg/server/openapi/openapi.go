@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+/*
+OpenAPI 3 document of the API, without the unversioned aliases of the v1 routes
+and of the v2 routes marked with EXTENSION_UNVERSIONED.
+The document is maintained by hand, keep it in sync with the request models in
+pkg/models/request and the endpoints registered by the controllers.
+*/
+//go:embed openapi.json
+var Spec []byte
+
+// Swagger UI page which loads the OpenAPI document from "/openapi.json".
+//
+//go:embed swagger.html
+var SwaggerUI []byte
+
+// Static files of Swagger UI, eg. "swagger-ui-bundle.js".
+var SwaggerUIAssets fs.FS = swaggerFiles.FS
+
+// Prefixes of the routes of v1, and of the unversioned routes which are aliases of them.
+const V1_PREFIX = "/api/v1/"
+const ALIAS_PREFIX = "/api/"
+
+// Prefix of the routes of v2, which have an unversioned alias if their path item has EXTENSION_UNVERSIONED.
+const V2_PREFIX = "/api/v2/"
+const EXTENSION_UNVERSIONED = "x-unversioned"
+
+/*
+Returns the OpenAPI document, after checking that it is valid.
+The unversioned aliases of the v1 routes and of the marked v2 routes are added to the document.
+*/
+func Load() (*openapi3.T, error) {
+	data, err := withAliases(Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Returns the OpenAPI document as JSON, including the unversioned aliases.
+func JSON() ([]byte, error) {
+	doc, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(doc)
+}
+
+/*
+Returns the document with a copy of every v1 path at its unversioned alias, eg.
+"/api/register" for "/api/v1/register", and of every v2 path marked with EXTENSION_UNVERSIONED,
+eg. "/api/webhooks" for "/api/v2/webhooks".
+Operation IDs of the copies are suffixed with "Alias" to keep them unique.
+*/
+func withAliases(spec []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	aliases := map[string]any{}
+
+	for path, item := range paths {
+		prefix := V1_PREFIX
+		if unversioned, _ := item.(map[string]any)[EXTENSION_UNVERSIONED].(bool); unversioned {
+			prefix = V2_PREFIX
+		}
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		// Copy the path item, as the operations are modified.
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var alias map[string]any
+		if err := json.Unmarshal(data, &alias); err != nil {
+			return nil, err
+		}
+
+		for _, v := range alias {
+			if operation, ok := v.(map[string]any); ok {
+				operation["operationId"] = fmt.Sprint(operation["operationId"], "Alias")
+			}
+		}
+		aliases[ALIAS_PREFIX+strings.TrimPrefix(path, prefix)] = alias
+	}
+
+	for path, item := range aliases {
+		paths[path] = item
+	}
+
+	return json.Marshal(doc)
+}
@@ -0,0 +1,101 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+/*
+Structure for in-process publishing of events to the subscribers of a topic.
+Publishing never blocks: a subscriber whose buffer is full is too slow to keep up,
+and its subscription is closed so that it can resume from persisted history.
+*/
+type Hub[T any] struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription[T]]struct{}
+	buffer      int
+}
+
+// Structure for the subscription of a subscriber to a topic.
+type Subscription[T any] struct {
+	hub    *Hub[T]
+	topic  string
+	events chan T
+	once   sync.Once
+}
+
+// Returns a hub buffering up to buffer events for each subscriber.
+func New[T any](buffer int) *Hub[T] {
+	return &Hub[T]{subscribers: make(map[string]map[*Subscription[T]]struct{}), buffer: buffer}
+}
+
+// Subscribes to the events published to topic from now on.
+// The subscription must be closed once it is no longer used.
+func (h *Hub[T]) Subscribe(topic string) *Subscription[T] {
+	s := &Subscription[T]{hub: h, topic: topic, events: make(chan T, h.buffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[*Subscription[T]]struct{})
+	}
+	h.subscribers[topic][s] = struct{}{}
+
+	return s
+}
+
+/*
+Publishes event to the subscribers of topic.
+Closes the subscriptions of subscribers which have not received their buffered events.
+*/
+func (h *Hub[T]) Publish(topic string, event T) {
+	var slow []*Subscription[T]
+
+	h.mu.RLock()
+	for s := range h.subscribers[topic] {
+		select {
+		case s.events <- event:
+		default:
+			slow = append(slow, s)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, v := range slow {
+		v.Close()
+	}
+}
+
+// Returns the number of subscribers of topic.
+func (h *Hub[T]) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscribers[topic])
+}
+
+// Returns the channel of events of the subscription, which is closed with the subscription.
+func (s *Subscription[T]) Events() <-chan T {
+	return s.events
+}
+
+// Returns the topic of the subscription.
+func (s *Subscription[T]) Topic() string {
+	return s.topic
+}
+
+// Unsubscribes from the topic. Safe to call more than once.
+func (s *Subscription[T]) Close() {
+	s.once.Do(func() {
+		h := s.hub
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[s.topic], s)
+		if len(h.subscribers[s.topic]) == 0 {
+			delete(h.subscribers, s.topic)
+		}
+		close(s.events)
+	})
+}
@@ -0,0 +1,4 @@
+package rpc
+
+// Generates the code of the TeacherService from "proto/teacher/v1/teacher.proto".
+//go:generate protoc -I ../../../proto --go_out=pb --go_opt=module=govtech/pkg/server/rpc/pb --go-grpc_out=pb --go-grpc_opt=module=govtech/pkg/server/rpc/pb teacher/v1/teacher.proto
@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/rpc/pb"
+	"govtech/pkg/utilities/logging"
+	"govtech/pkg/utilities/messages"
+)
+
+// Metadata key used to propagate the request ID, the same as the HTTP header.
+const METADATA_REQUEST_ID = "x-request-id"
+
+// Metadata key used by integrations to identify themselves, the same as the HTTP header.
+const METADATA_API_KEY = "x-api-key"
+
+/*
+Propagates the request ID in the metadata of the call, or generates one if it is
+missing or invalid, and adds it to the call context and the response headers.
+*/
+func RequestIDInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var requestID string
+	if values := metadata.ValueFromIncomingContext(ctx, METADATA_REQUEST_ID); len(values) > 0 {
+		requestID = values[0]
+	}
+	if !middlewares.ValidRequestID(requestID) {
+		requestID = middlewares.NewRequestID()
+	}
+
+	ctx = logging.WithRequestID(ctx, requestID)
+	grpc.SetHeader(ctx, metadata.Pairs(METADATA_REQUEST_ID, requestID))
+
+	return handler(ctx, req)
+}
+
+// Logs a line for every call once it has been handled.
+func LoggerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	slog.LogAttrs(ctx, level, "grpc call",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	)
+
+	return resp, err
+}
+
+// Logs the recovered panic and returns an internal error.
+func RecoveryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "panic while handling call",
+				"error", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, messages.Translate(messages.LANGUAGE_DEFAULT, messages.MESSAGE_INTERNAL_ERROR))
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// Routes of the HTTP API equivalent to the methods, whose rate limits and deadlines apply to the methods.
+var methodRoutes = map[string]string{
+	pb.TeacherService_Register_FullMethodName:                 "/api/v2/register",
+	pb.TeacherService_CommonStudents_FullMethodName:           "/api/v2/commonstudents",
+	pb.TeacherService_RetrieveForNotifications_FullMethodName: "/api/v2/retrievefornotifications",
+	pb.TeacherService_Suspend_FullMethodName:                  "/api/v2/suspend",
+}
+
+/*
+Returns an interceptor limiting the rate of calls with the limits of the equivalent routes.
+Calls share the buckets of the clients with the HTTP API if the store is shared with it.
+Calls over the limit return ResourceExhausted, with the number of seconds to wait in the
+retry-after header.
+*/
+func RateLimitInterceptor(config *middlewares.RateLimitConfig, store middlewares.RateLimitStore) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		route, ok := methodRoutes[info.FullMethod]
+		limit := config.RouteLimit(route)
+
+		// Skip limiting if disabled for the route or the method has no route.
+		if !ok || limit.Disabled() {
+			return handler(ctx, req)
+		}
+
+		var ip, apiKey string
+		if p, ok := peer.FromContext(ctx); ok {
+			ip = p.Addr.String()
+			if host, _, err := net.SplitHostPort(ip); err == nil {
+				ip = host
+			}
+		}
+		if values := metadata.ValueFromIncomingContext(ctx, METADATA_API_KEY); len(values) > 0 {
+			apiKey = values[0]
+		}
+
+		client := middlewares.RateLimitClient(config.KeyBy, ip, apiKey, func() string {
+			return callTeacher(req)
+		})
+		result := store.Take(middlewares.RateLimitBucket(route, client), limit, time.Now())
+
+		header := metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(result.Limit),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", strconv.Itoa(ceilSeconds(result.Reset)),
+		)
+		if !result.Allowed {
+			header.Set("retry-after", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			grpc.SetHeader(ctx, header)
+			return nil, status.Error(codes.ResourceExhausted, messages.Translate(messages.LANGUAGE_DEFAULT, messages.MESSAGE_TOO_MANY_REQUESTS))
+		}
+		grpc.SetHeader(ctx, header)
+
+		return handler(ctx, req)
+	}
+}
+
+/*
+Returns an interceptor setting the deadline of calls to the deadline of the equivalent routes.
+Deadlines set by clients are kept if they are earlier.
+*/
+func TimeoutInterceptor(config *middlewares.TimeoutConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		route, ok := methodRoutes[info.FullMethod]
+		timeout := config.Default
+		if ok {
+			timeout = config.RouteTimeout(route)
+		}
+
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return handler(ctx, req)
+	}
+}
+
+// Returns the teacher of the call, the same as the teacher of the equivalent HTTP request.
+func callTeacher(req any) string {
+	switch in := req.(type) {
+	case *pb.RegisterRequest:
+		return strings.ToLower(in.GetStudentsOfTeacher().GetTeacher())
+	case *pb.CommonStudentsRequest:
+		if len(in.GetTeachers()) > 0 {
+			return middlewares.RateLimitTeachers(in.GetTeachers())
+		}
+	case *pb.RetrieveForNotificationsRequest:
+		return strings.ToLower(in.GetTeacher())
+	}
+
+	return ""
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
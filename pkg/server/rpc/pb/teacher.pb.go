@@ -0,0 +1,806 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v28.3.0
+// source: teacher/v1/teacher.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Exactly one of the two shapes of registration.
+	//
+	// Types that are assignable to Registration:
+	//	*RegisterRequest_StudentsOfTeacher
+	//	*RegisterRequest_TeachersOfStudent
+	Registration isRegisterRequest_Registration `protobuf_oneof:"registration"`
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *RegisterRequest) GetRegistration() isRegisterRequest_Registration {
+	if m != nil {
+		return m.Registration
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetStudentsOfTeacher() *StudentsOfTeacher {
+	if x, ok := x.GetRegistration().(*RegisterRequest_StudentsOfTeacher); ok {
+		return x.StudentsOfTeacher
+	}
+	return nil
+}
+
+func (x *RegisterRequest) GetTeachersOfStudent() *TeachersOfStudent {
+	if x, ok := x.GetRegistration().(*RegisterRequest_TeachersOfStudent); ok {
+		return x.TeachersOfStudent
+	}
+	return nil
+}
+
+type isRegisterRequest_Registration interface {
+	isRegisterRequest_Registration()
+}
+
+type RegisterRequest_StudentsOfTeacher struct {
+	StudentsOfTeacher *StudentsOfTeacher `protobuf:"bytes,1,opt,name=students_of_teacher,json=studentsOfTeacher,proto3,oneof"`
+}
+
+type RegisterRequest_TeachersOfStudent struct {
+	TeachersOfStudent *TeachersOfStudent `protobuf:"bytes,2,opt,name=teachers_of_student,json=teachersOfStudent,proto3,oneof"`
+}
+
+func (*RegisterRequest_StudentsOfTeacher) isRegisterRequest_Registration() {}
+
+func (*RegisterRequest_TeachersOfStudent) isRegisterRequest_Registration() {}
+
+// A list of at most 50 students registered to a teacher.
+type StudentsOfTeacher struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Teacher  string   `protobuf:"bytes,1,opt,name=teacher,proto3" json:"teacher,omitempty"`
+	Students []string `protobuf:"bytes,2,rep,name=students,proto3" json:"students,omitempty"`
+}
+
+func (x *StudentsOfTeacher) Reset() {
+	*x = StudentsOfTeacher{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StudentsOfTeacher) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StudentsOfTeacher) ProtoMessage() {}
+
+func (x *StudentsOfTeacher) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StudentsOfTeacher.ProtoReflect.Descriptor instead.
+func (*StudentsOfTeacher) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StudentsOfTeacher) GetTeacher() string {
+	if x != nil {
+		return x.Teacher
+	}
+	return ""
+}
+
+func (x *StudentsOfTeacher) GetStudents() []string {
+	if x != nil {
+		return x.Students
+	}
+	return nil
+}
+
+// A list of at most 50 teachers registered to a student.
+type TeachersOfStudent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Student  string   `protobuf:"bytes,1,opt,name=student,proto3" json:"student,omitempty"`
+	Teachers []string `protobuf:"bytes,2,rep,name=teachers,proto3" json:"teachers,omitempty"`
+}
+
+func (x *TeachersOfStudent) Reset() {
+	*x = TeachersOfStudent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TeachersOfStudent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeachersOfStudent) ProtoMessage() {}
+
+func (x *TeachersOfStudent) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeachersOfStudent.ProtoReflect.Descriptor instead.
+func (*TeachersOfStudent) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TeachersOfStudent) GetStudent() string {
+	if x != nil {
+		return x.Student
+	}
+	return ""
+}
+
+func (x *TeachersOfStudent) GetTeachers() []string {
+	if x != nil {
+		return x.Teachers
+	}
+	return nil
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{3}
+}
+
+type CommonStudentsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Teachers []string `protobuf:"bytes,1,rep,name=teachers,proto3" json:"teachers,omitempty"`
+}
+
+func (x *CommonStudentsRequest) Reset() {
+	*x = CommonStudentsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommonStudentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommonStudentsRequest) ProtoMessage() {}
+
+func (x *CommonStudentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommonStudentsRequest.ProtoReflect.Descriptor instead.
+func (*CommonStudentsRequest) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CommonStudentsRequest) GetTeachers() []string {
+	if x != nil {
+		return x.Teachers
+	}
+	return nil
+}
+
+type CommonStudentsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Students []string `protobuf:"bytes,1,rep,name=students,proto3" json:"students,omitempty"`
+}
+
+func (x *CommonStudentsResponse) Reset() {
+	*x = CommonStudentsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommonStudentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommonStudentsResponse) ProtoMessage() {}
+
+func (x *CommonStudentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommonStudentsResponse.ProtoReflect.Descriptor instead.
+func (*CommonStudentsResponse) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CommonStudentsResponse) GetStudents() []string {
+	if x != nil {
+		return x.Students
+	}
+	return nil
+}
+
+type RetrieveForNotificationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Teacher string `protobuf:"bytes,1,opt,name=teacher,proto3" json:"teacher,omitempty"`
+	// Text of the notification. Students are mentioned as @<email>.
+	Notification string `protobuf:"bytes,2,opt,name=notification,proto3" json:"notification,omitempty"`
+}
+
+func (x *RetrieveForNotificationsRequest) Reset() {
+	*x = RetrieveForNotificationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetrieveForNotificationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveForNotificationsRequest) ProtoMessage() {}
+
+func (x *RetrieveForNotificationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveForNotificationsRequest.ProtoReflect.Descriptor instead.
+func (*RetrieveForNotificationsRequest) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RetrieveForNotificationsRequest) GetTeacher() string {
+	if x != nil {
+		return x.Teacher
+	}
+	return ""
+}
+
+func (x *RetrieveForNotificationsRequest) GetNotification() string {
+	if x != nil {
+		return x.Notification
+	}
+	return ""
+}
+
+type RetrieveForNotificationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Recipients []string `protobuf:"bytes,1,rep,name=recipients,proto3" json:"recipients,omitempty"`
+}
+
+func (x *RetrieveForNotificationsResponse) Reset() {
+	*x = RetrieveForNotificationsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetrieveForNotificationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveForNotificationsResponse) ProtoMessage() {}
+
+func (x *RetrieveForNotificationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveForNotificationsResponse.ProtoReflect.Descriptor instead.
+func (*RetrieveForNotificationsResponse) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RetrieveForNotificationsResponse) GetRecipients() []string {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+type SuspendRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Student string `protobuf:"bytes,1,opt,name=student,proto3" json:"student,omitempty"`
+}
+
+func (x *SuspendRequest) Reset() {
+	*x = SuspendRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SuspendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendRequest) ProtoMessage() {}
+
+func (x *SuspendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendRequest.ProtoReflect.Descriptor instead.
+func (*SuspendRequest) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SuspendRequest) GetStudent() string {
+	if x != nil {
+		return x.Student
+	}
+	return ""
+}
+
+type SuspendResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SuspendResponse) Reset() {
+	*x = SuspendResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_teacher_v1_teacher_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SuspendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendResponse) ProtoMessage() {}
+
+func (x *SuspendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_teacher_v1_teacher_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendResponse.ProtoReflect.Descriptor instead.
+func (*SuspendResponse) Descriptor() ([]byte, []int) {
+	return file_teacher_v1_teacher_proto_rawDescGZIP(), []int{9}
+}
+
+var File_teacher_v1_teacher_proto protoreflect.FileDescriptor
+
+var file_teacher_v1_teacher_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x65, 0x61,
+	0x63, 0x68, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x74, 0x65, 0x61, 0x63,
+	0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0xc3, 0x01, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x4f, 0x0a, 0x13, 0x73, 0x74,
+	0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x5f, 0x6f, 0x66, 0x5f, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x4f, 0x66, 0x54,
+	0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x48, 0x00, 0x52, 0x11, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e,
+	0x74, 0x73, 0x4f, 0x66, 0x54, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x12, 0x4f, 0x0a, 0x13, 0x74,
+	0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x73, 0x5f, 0x6f, 0x66, 0x5f, 0x73, 0x74, 0x75, 0x64, 0x65,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x73, 0x4f, 0x66,
+	0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x11, 0x74, 0x65, 0x61, 0x63, 0x68,
+	0x65, 0x72, 0x73, 0x4f, 0x66, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x42, 0x0e, 0x0a, 0x0c,
+	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x49, 0x0a, 0x11,
+	0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x4f, 0x66, 0x54, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x72, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x73,
+	0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x73,
+	0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x49, 0x0a, 0x11, 0x54, 0x65, 0x61, 0x63, 0x68,
+	0x65, 0x72, 0x73, 0x4f, 0x66, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73,
+	0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x72, 0x73, 0x22, 0x12, 0x0a, 0x10, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x33, 0x0a, 0x15, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e,
+	0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1a, 0x0a, 0x08, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x73, 0x22, 0x34, 0x0a, 0x16, 0x43,
+	0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74,
+	0x73, 0x22, 0x5f, 0x0a, 0x1f, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72,
+	0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x12, 0x22,
+	0x0a, 0x0c, 0x6e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0x42, 0x0a, 0x20, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f,
+	0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x69,
+	0x70, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x2a, 0x0a, 0x0e, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e,
+	0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x74, 0x75, 0x64,
+	0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x74, 0x75, 0x64, 0x65,
+	0x6e, 0x74, 0x22, 0x11, 0x0a, 0x0f, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xeb, 0x02, 0x0a, 0x0e, 0x54, 0x65, 0x61, 0x63, 0x68, 0x65,
+	0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x45, 0x0a, 0x08, 0x52, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x12, 0x1b, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1c, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x57, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x21, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x75, 0x0a, 0x18, 0x52, 0x65, 0x74, 0x72,
+	0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2b, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x2c, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x42, 0x0a, 0x07, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x12, 0x1a, 0x2e, 0x74, 0x65, 0x61,
+	0x63, 0x68, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x42, 0x1e, 0x5a, 0x1c, 0x67, 0x6f, 0x76, 0x74, 0x65, 0x63, 0x68, 0x2f, 0x70,
+	0x6b, 0x67, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x72, 0x70, 0x63, 0x2f, 0x70, 0x62,
+	0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_teacher_v1_teacher_proto_rawDescOnce sync.Once
+	file_teacher_v1_teacher_proto_rawDescData = file_teacher_v1_teacher_proto_rawDesc
+)
+
+func file_teacher_v1_teacher_proto_rawDescGZIP() []byte {
+	file_teacher_v1_teacher_proto_rawDescOnce.Do(func() {
+		file_teacher_v1_teacher_proto_rawDescData = protoimpl.X.CompressGZIP(file_teacher_v1_teacher_proto_rawDescData)
+	})
+	return file_teacher_v1_teacher_proto_rawDescData
+}
+
+var file_teacher_v1_teacher_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_teacher_v1_teacher_proto_goTypes = []any{
+	(*RegisterRequest)(nil),                  // 0: teacher.v1.RegisterRequest
+	(*StudentsOfTeacher)(nil),                // 1: teacher.v1.StudentsOfTeacher
+	(*TeachersOfStudent)(nil),                // 2: teacher.v1.TeachersOfStudent
+	(*RegisterResponse)(nil),                 // 3: teacher.v1.RegisterResponse
+	(*CommonStudentsRequest)(nil),            // 4: teacher.v1.CommonStudentsRequest
+	(*CommonStudentsResponse)(nil),           // 5: teacher.v1.CommonStudentsResponse
+	(*RetrieveForNotificationsRequest)(nil),  // 6: teacher.v1.RetrieveForNotificationsRequest
+	(*RetrieveForNotificationsResponse)(nil), // 7: teacher.v1.RetrieveForNotificationsResponse
+	(*SuspendRequest)(nil),                   // 8: teacher.v1.SuspendRequest
+	(*SuspendResponse)(nil),                  // 9: teacher.v1.SuspendResponse
+}
+var file_teacher_v1_teacher_proto_depIdxs = []int32{
+	1, // 0: teacher.v1.RegisterRequest.students_of_teacher:type_name -> teacher.v1.StudentsOfTeacher
+	2, // 1: teacher.v1.RegisterRequest.teachers_of_student:type_name -> teacher.v1.TeachersOfStudent
+	0, // 2: teacher.v1.TeacherService.Register:input_type -> teacher.v1.RegisterRequest
+	4, // 3: teacher.v1.TeacherService.CommonStudents:input_type -> teacher.v1.CommonStudentsRequest
+	6, // 4: teacher.v1.TeacherService.RetrieveForNotifications:input_type -> teacher.v1.RetrieveForNotificationsRequest
+	8, // 5: teacher.v1.TeacherService.Suspend:input_type -> teacher.v1.SuspendRequest
+	3, // 6: teacher.v1.TeacherService.Register:output_type -> teacher.v1.RegisterResponse
+	5, // 7: teacher.v1.TeacherService.CommonStudents:output_type -> teacher.v1.CommonStudentsResponse
+	7, // 8: teacher.v1.TeacherService.RetrieveForNotifications:output_type -> teacher.v1.RetrieveForNotificationsResponse
+	9, // 9: teacher.v1.TeacherService.Suspend:output_type -> teacher.v1.SuspendResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_teacher_v1_teacher_proto_init() }
+func file_teacher_v1_teacher_proto_init() {
+	if File_teacher_v1_teacher_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_teacher_v1_teacher_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*StudentsOfTeacher); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*TeachersOfStudent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*CommonStudentsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*CommonStudentsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*RetrieveForNotificationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*RetrieveForNotificationsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*SuspendRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_teacher_v1_teacher_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*SuspendResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_teacher_v1_teacher_proto_msgTypes[0].OneofWrappers = []any{
+		(*RegisterRequest_StudentsOfTeacher)(nil),
+		(*RegisterRequest_TeachersOfStudent)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_teacher_v1_teacher_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_teacher_v1_teacher_proto_goTypes,
+		DependencyIndexes: file_teacher_v1_teacher_proto_depIdxs,
+		MessageInfos:      file_teacher_v1_teacher_proto_msgTypes,
+	}.Build()
+	File_teacher_v1_teacher_proto = out.File
+	file_teacher_v1_teacher_proto_rawDesc = nil
+	file_teacher_v1_teacher_proto_goTypes = nil
+	file_teacher_v1_teacher_proto_depIdxs = nil
+}
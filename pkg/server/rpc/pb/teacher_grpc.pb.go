@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v28.3.0
+// source: teacher/v1/teacher.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TeacherService_Register_FullMethodName                 = "/teacher.v1.TeacherService/Register"
+	TeacherService_CommonStudents_FullMethodName           = "/teacher.v1.TeacherService/CommonStudents"
+	TeacherService_RetrieveForNotifications_FullMethodName = "/teacher.v1.TeacherService/RetrieveForNotifications"
+	TeacherService_Suspend_FullMethodName                  = "/teacher.v1.TeacherService/Suspend"
+)
+
+// TeacherServiceClient is the client API for TeacherService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Administrative functions of teachers for their students.
+// The same operations as the HTTP API, sharing its validation rules.
+type TeacherServiceClient interface {
+	// Registers a list of students to a teacher, or a list of teachers to a student.
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	// Returns the students registered to all of the given teachers, sorted by email.
+	CommonStudents(ctx context.Context, in *CommonStudentsRequest, opts ...grpc.CallOption) (*CommonStudentsResponse, error)
+	// Returns the students who can receive a notification from a teacher, sorted by email.
+	RetrieveForNotifications(ctx context.Context, in *RetrieveForNotificationsRequest, opts ...grpc.CallOption) (*RetrieveForNotificationsResponse, error)
+	// Suspends a student.
+	Suspend(ctx context.Context, in *SuspendRequest, opts ...grpc.CallOption) (*SuspendResponse, error)
+}
+
+type teacherServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTeacherServiceClient(cc grpc.ClientConnInterface) TeacherServiceClient {
+	return &teacherServiceClient{cc}
+}
+
+func (c *teacherServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, TeacherService_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *teacherServiceClient) CommonStudents(ctx context.Context, in *CommonStudentsRequest, opts ...grpc.CallOption) (*CommonStudentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommonStudentsResponse)
+	err := c.cc.Invoke(ctx, TeacherService_CommonStudents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *teacherServiceClient) RetrieveForNotifications(ctx context.Context, in *RetrieveForNotificationsRequest, opts ...grpc.CallOption) (*RetrieveForNotificationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RetrieveForNotificationsResponse)
+	err := c.cc.Invoke(ctx, TeacherService_RetrieveForNotifications_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *teacherServiceClient) Suspend(ctx context.Context, in *SuspendRequest, opts ...grpc.CallOption) (*SuspendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuspendResponse)
+	err := c.cc.Invoke(ctx, TeacherService_Suspend_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TeacherServiceServer is the server API for TeacherService service.
+// All implementations must embed UnimplementedTeacherServiceServer
+// for forward compatibility.
+//
+// Administrative functions of teachers for their students.
+// The same operations as the HTTP API, sharing its validation rules.
+type TeacherServiceServer interface {
+	// Registers a list of students to a teacher, or a list of teachers to a student.
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	// Returns the students registered to all of the given teachers, sorted by email.
+	CommonStudents(context.Context, *CommonStudentsRequest) (*CommonStudentsResponse, error)
+	// Returns the students who can receive a notification from a teacher, sorted by email.
+	RetrieveForNotifications(context.Context, *RetrieveForNotificationsRequest) (*RetrieveForNotificationsResponse, error)
+	// Suspends a student.
+	Suspend(context.Context, *SuspendRequest) (*SuspendResponse, error)
+	mustEmbedUnimplementedTeacherServiceServer()
+}
+
+// UnimplementedTeacherServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTeacherServiceServer struct{}
+
+func (UnimplementedTeacherServiceServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedTeacherServiceServer) CommonStudents(context.Context, *CommonStudentsRequest) (*CommonStudentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommonStudents not implemented")
+}
+func (UnimplementedTeacherServiceServer) RetrieveForNotifications(context.Context, *RetrieveForNotificationsRequest) (*RetrieveForNotificationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetrieveForNotifications not implemented")
+}
+func (UnimplementedTeacherServiceServer) Suspend(context.Context, *SuspendRequest) (*SuspendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Suspend not implemented")
+}
+func (UnimplementedTeacherServiceServer) mustEmbedUnimplementedTeacherServiceServer() {}
+func (UnimplementedTeacherServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeTeacherServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TeacherServiceServer will
+// result in compilation errors.
+type UnsafeTeacherServiceServer interface {
+	mustEmbedUnimplementedTeacherServiceServer()
+}
+
+func RegisterTeacherServiceServer(s grpc.ServiceRegistrar, srv TeacherServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTeacherServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TeacherService_ServiceDesc, srv)
+}
+
+func _TeacherService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TeacherServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TeacherService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TeacherServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TeacherService_CommonStudents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommonStudentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TeacherServiceServer).CommonStudents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TeacherService_CommonStudents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TeacherServiceServer).CommonStudents(ctx, req.(*CommonStudentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TeacherService_RetrieveForNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveForNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TeacherServiceServer).RetrieveForNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TeacherService_RetrieveForNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TeacherServiceServer).RetrieveForNotifications(ctx, req.(*RetrieveForNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TeacherService_Suspend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TeacherServiceServer).Suspend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TeacherService_Suspend_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TeacherServiceServer).Suspend(ctx, req.(*SuspendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TeacherService_ServiceDesc is the grpc.ServiceDesc for TeacherService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TeacherService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "teacher.v1.TeacherService",
+	HandlerType: (*TeacherServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _TeacherService_Register_Handler,
+		},
+		{
+			MethodName: "CommonStudents",
+			Handler:    _TeacherService_CommonStudents_Handler,
+		},
+		{
+			MethodName: "RetrieveForNotifications",
+			Handler:    _TeacherService_RetrieveForNotifications_Handler,
+		},
+		{
+			MethodName: "Suspend",
+			Handler:    _TeacherService_Suspend_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "teacher/v1/teacher.proto",
+}
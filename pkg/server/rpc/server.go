@@ -0,0 +1,208 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"govtech/pkg/config"
+	"govtech/pkg/models/request"
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/rpc/pb"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/patterns"
+	"govtech/pkg/utilities/problems"
+)
+
+// Structure for configuration for the gRPC server.
+type RPCConfig struct {
+	Port string
+	Host string
+}
+
+// Returns the configuration used to run the gRPC server.
+func NewRPCConfig(c config.GRPCConfig) RPCConfig {
+	return RPCConfig{
+		Port: c.Port,
+		Host: c.Host,
+	}
+}
+
+/*
+Structure for the gRPC TeacherService.
+Requests are validated with the same rules as the HTTP API, and handled by the
+service shared with it.
+*/
+type Server struct {
+	pb.UnimplementedTeacherServiceServer
+	service *services.Service
+}
+
+// Returns the TeacherService using the given service.
+func NewServer(service *services.Service) *Server {
+	return &Server{service: service}
+}
+
+// Returns a gRPC server with request IDs, logging and recovery, serving the TeacherService.
+func NewGRPCServer(service *services.Service, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(RequestIDInterceptor, LoggerInterceptor, RecoveryInterceptor)}, opts...)
+
+	server := grpc.NewServer(opts...)
+	pb.RegisterTeacherServiceServer(server, NewServer(service))
+
+	return server
+}
+
+/*
+Runs the gRPC server at the assigned port and host until ctx is done,
+then stops accepting connections and waits for in-flight calls to complete.
+*/
+func Run(ctx context.Context, server *grpc.Server, config *RPCConfig) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(config.Host, config.Port))
+	if err != nil {
+		return err
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("grpc server listening", "address", listener.Addr().String())
+		serverErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down grpc server")
+	server.GracefulStop()
+
+	return <-serverErr
+}
+
+// Registers a list of students to a teacher, or a list of teachers to a student.
+func (s *Server) Register(ctx context.Context, in *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	var req request.RegisterRequest
+
+	switch registration := in.Registration.(type) {
+	case *pb.RegisterRequest_StudentsOfTeacher:
+		req.Teacher = registration.StudentsOfTeacher.GetTeacher()
+		req.Students = registration.StudentsOfTeacher.GetStudents()
+	case *pb.RegisterRequest_TeachersOfStudent:
+		req.Student = registration.TeachersOfStudent.GetStudent()
+		req.Teachers = registration.TeachersOfStudent.GetTeachers()
+	}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, validationError(problems.FromBindError(err))
+	}
+
+	var err error
+	if req.IsTeacherShape() {
+		err = s.service.RegisterStudents(ctx, req.Teacher, req.Students)
+	} else {
+		err = s.service.RegisterTeachers(ctx, req.Student, req.Teachers)
+	}
+	if err != nil {
+		return nil, databaseError(ctx, err)
+	}
+
+	return &pb.RegisterResponse{}, nil
+}
+
+// Returns the students registered to all of the given teachers.
+func (s *Server) CommonStudents(ctx context.Context, in *pb.CommonStudentsRequest) (*pb.CommonStudentsResponse, error) {
+	if len(in.GetTeachers()) == 0 {
+		return nil, validationError(problems.Validation(messages.MESSAGE_MISSING_QUERY_PARAMS,
+			problems.Field("teachers", messages.FIELD_CODE_REQUIRED, "")))
+	}
+
+	students, err := s.service.CommonStudents(ctx, in.GetTeachers())
+	if err != nil {
+		return nil, databaseError(ctx, err)
+	}
+
+	return &pb.CommonStudentsResponse{Students: students}, nil
+}
+
+// Returns the students who can receive a notification from a teacher.
+func (s *Server) RetrieveForNotifications(ctx context.Context, in *pb.RetrieveForNotificationsRequest) (*pb.RetrieveForNotificationsResponse, error) {
+	req := request.ReceieveForNotificationsRequest{Teacher: in.GetTeacher(), Notification: in.GetNotification()}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, validationError(problems.FromBindError(err))
+	}
+
+	if !patterns.ValidatePattern(patterns.REGEX_PATTERN_NOTIFICATION, req.Notification) {
+		return nil, validationError(problems.Validation(messages.MESSAGE_INVALID_PARAMS,
+			problems.Field("notification", messages.FIELD_CODE_FORMAT, "")))
+	}
+
+	recipients, err := s.service.RetrieveForNotifications(ctx, req.Teacher, req.Notification)
+	if err != nil {
+		return nil, databaseError(ctx, err)
+	}
+
+	return &pb.RetrieveForNotificationsResponse{Recipients: recipients}, nil
+}
+
+// Suspends a student.
+func (s *Server) Suspend(ctx context.Context, in *pb.SuspendRequest) (*pb.SuspendResponse, error) {
+	req := request.SuspendRequest{Student: in.GetStudent()}
+
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, validationError(problems.FromBindError(err))
+	}
+
+	if err := s.service.Suspend(ctx, req.Student); err != nil {
+		return nil, databaseError(ctx, err)
+	}
+
+	return &pb.SuspendResponse{}, nil
+}
+
+/*
+Returns the InvalidArgument status for a validation problem,
+with a field violation for every field error of the problem.
+*/
+func validationError(problem *response.Problem) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(problem.Errors))
+	for _, v := range problem.Errors {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Detail})
+	}
+
+	st := status.New(codes.InvalidArgument, problem.Detail)
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+/*
+Returns the status for an error returned by the store.
+Returns DeadlineExceeded if the deadline of the call was reached, Canceled if the
+client has cancelled the call, and Internal otherwise.
+*/
+func databaseError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(ctx, "call deadline exceeded", "error", err)
+		return status.Error(codes.DeadlineExceeded, messages.Translate(messages.LANGUAGE_DEFAULT, messages.MESSAGE_TIMEOUT))
+	case errors.Is(err, context.Canceled):
+		slog.InfoContext(ctx, "call cancelled by client", "error", err)
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		slog.ErrorContext(ctx, "database error", "error", err)
+		return status.Error(codes.Internal, messages.Translate(messages.LANGUAGE_DEFAULT, messages.MESSAGE_DATABASE_ERROR))
+	}
+}
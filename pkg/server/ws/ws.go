@@ -0,0 +1,292 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+
+	"govtech/pkg/config"
+	"govtech/pkg/events"
+	"govtech/pkg/models/request"
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/auth"
+	"govtech/pkg/server/metrics"
+	"govtech/pkg/server/pubsub"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Maximum number of teachers a connection can subscribe to.
+const MAX_SUBSCRIPTIONS = 50
+
+// Maximum size in bytes of the messages sent by clients.
+const MAX_MESSAGE_SIZE = 4096
+
+// Reason of the close message sent to clients which fall behind their events.
+const CLOSE_REASON_SLOW = "client too slow"
+
+// Reason of the close message sent to clients when the server shuts down.
+const CLOSE_REASON_SHUTDOWN = "server shutting down"
+
+// Structure for the configuration of the teacher events WebSocket.
+type Config struct {
+	// Secret used to verify the tokens of teachers.
+	Secret string
+	// Interval between pings, clients which do not answer within twice the interval are disconnected.
+	PingInterval time.Duration
+	// Maximum duration to write a message before the client is disconnected.
+	WriteTimeout time.Duration
+	// Number of messages buffered for each client before it is disconnected as too slow.
+	Buffer int
+}
+
+// Returns the configuration of the teacher events WebSocket, verifying tokens with the secret of auth.
+func NewConfig(c config.WebSocketConfig, auth config.AuthConfig) Config {
+	return Config{
+		Secret:       auth.TokenSecret,
+		PingInterval: c.PingInterval.Duration,
+		WriteTimeout: c.WriteTimeout.Duration,
+		Buffer:       c.Buffer,
+	}
+}
+
+// Source of the events published to teachers, eg. the service.
+type Source interface {
+	SubscribeTeacherEvents(teacher string) *pubsub.Subscription[events.Event]
+}
+
+// Structure for a WebSocket connection and its subscriptions.
+type connection struct {
+	conn   *websocket.Conn
+	source Source
+	signer *auth.Signer
+	config *Config
+	lang   string
+
+	// Messages waiting to be written by the writer.
+	send chan response.TeacherMessage
+	// Closed once the connection is closing, with the close code and reason.
+	done        chan struct{}
+	once        sync.Once
+	closeCode   int
+	closeReason string
+
+	// Subscriptions by teacher, only used by the reader.
+	subscriptions map[string]*subscription
+}
+
+// Structure for the subscription of a connection to a teacher.
+type subscription struct {
+	events *pubsub.Subscription[events.Event]
+	// Closed when the client unsubscribes, to tell it apart from the subscription being dropped.
+	stopped chan struct{}
+}
+
+/*
+Serves the teacher events of a WebSocket connection until it is closed, or ctx is done.
+Messages are written in the language lang.
+Clients which do not answer pings, or fall behind their events, are disconnected.
+Once ctx is done, eg. as the server shuts down, clients are disconnected with a going away close message,
+as the server does not wait for hijacked connections.
+*/
+func Serve(ctx context.Context, conn *websocket.Conn, source Source, lang string, config *Config) {
+	c := &connection{
+		conn:          conn,
+		source:        source,
+		signer:        auth.NewSigner(config.Secret),
+		config:        config,
+		lang:          lang,
+		send:          make(chan response.TeacherMessage, config.Buffer),
+		done:          make(chan struct{}),
+		subscriptions: make(map[string]*subscription),
+	}
+
+	metrics.WebSocketConnections.Inc()
+	defer metrics.WebSocketConnections.Dec()
+
+	stop := context.AfterFunc(ctx, func() {
+		c.close(websocket.CloseGoingAway, CLOSE_REASON_SHUTDOWN)
+	})
+	defer stop()
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		c.write()
+	}()
+
+	c.read()
+	c.close(websocket.CloseNormalClosure, "")
+	<-written
+
+	for _, v := range c.subscriptions {
+		close(v.stopped)
+		v.events.Close()
+	}
+}
+
+// Handles the messages of the client until the connection fails or is closed.
+func (c *connection) read() {
+	c.conn.SetReadLimit(MAX_MESSAGE_SIZE)
+	c.conn.SetReadDeadline(time.Now().Add(2 * c.config.PingInterval))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(2 * c.config.PingInterval))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.Debug("websocket connection closed unexpectedly", "error", err)
+			}
+			return
+		}
+
+		var message request.SubscriptionMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			c.fail("", problems.FromBindError(err))
+			continue
+		}
+
+		if err := binding.Validator.ValidateStruct(&message); err != nil {
+			c.fail(message.Teacher, problems.FromBindError(err))
+			continue
+		}
+
+		switch message.Type {
+		case request.MESSAGE_SUBSCRIBE:
+			c.subscribe(message.Teacher, message.Token)
+		case request.MESSAGE_UNSUBSCRIBE:
+			c.unsubscribe(message.Teacher)
+		}
+	}
+}
+
+// Subscribes to the events of the teacher if the token authenticates the client as the teacher.
+func (c *connection) subscribe(teacher string, token string) {
+	if err := c.signer.Verify(teacher, token, time.Now()); err != nil {
+		c.fail(teacher, problems.New(http.StatusUnauthorized, messages.CODE_UNAUTHORIZED, messages.MESSAGE_INVALID_TOKEN))
+		return
+	}
+
+	if _, ok := c.subscriptions[teacher]; ok {
+		c.enqueue(response.TeacherMessage{Type: response.MESSAGE_SUBSCRIBED, Teacher: teacher})
+		return
+	}
+
+	if len(c.subscriptions) >= MAX_SUBSCRIPTIONS {
+		c.fail(teacher, problems.New(http.StatusTooManyRequests, messages.CODE_TOO_MANY_SUBSCRIPTIONS, messages.MESSAGE_TOO_MANY_SUBSCRIPTIONS))
+		return
+	}
+
+	s := &subscription{events: c.source.SubscribeTeacherEvents(teacher), stopped: make(chan struct{})}
+	c.subscriptions[teacher] = s
+
+	// Confirm the subscription before forwarding its events.
+	c.enqueue(response.TeacherMessage{Type: response.MESSAGE_SUBSCRIBED, Teacher: teacher})
+	go c.forward(teacher, s)
+}
+
+// Unsubscribes from the events of the teacher.
+func (c *connection) unsubscribe(teacher string) {
+	if s, ok := c.subscriptions[teacher]; ok {
+		close(s.stopped)
+		s.events.Close()
+		delete(c.subscriptions, teacher)
+	}
+
+	c.enqueue(response.TeacherMessage{Type: response.MESSAGE_UNSUBSCRIBED, Teacher: teacher})
+}
+
+/*
+Forwards the events of the subscription to the client.
+The subscription is dropped by the publisher if the client falls behind,
+in which case the client is disconnected and resubscribes once it has caught up.
+*/
+func (c *connection) forward(teacher string, s *subscription) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-s.stopped:
+			return
+		case event, ok := <-s.events.Events():
+			if !ok {
+				select {
+				case <-s.stopped:
+				default:
+					metrics.WebSocketSlowClients.Inc()
+					c.close(websocket.CloseTryAgainLater, CLOSE_REASON_SLOW)
+				}
+				return
+			}
+
+			occurredAt := event.OccurredAt
+			c.enqueue(response.TeacherMessage{
+				Type:       event.Type,
+				Teacher:    teacher,
+				Students:   event.Students,
+				OccurredAt: &occurredAt,
+			})
+		}
+	}
+}
+
+// Sends the problem with a message of the client.
+func (c *connection) fail(teacher string, problem *response.Problem) {
+	problems.Translate(problem, c.lang)
+	c.enqueue(response.TeacherMessage{Type: response.MESSAGE_ERROR, Teacher: teacher, Problem: problem})
+}
+
+// Queues the message to be written, or disconnects the client if its buffer is full.
+func (c *connection) enqueue(message response.TeacherMessage) {
+	select {
+	case c.send <- message:
+	case <-c.done:
+	default:
+		metrics.WebSocketSlowClients.Inc()
+		c.close(websocket.CloseTryAgainLater, CLOSE_REASON_SLOW)
+	}
+}
+
+// Writes the queued messages and pings until the connection is closing, then closes it.
+func (c *connection) write() {
+	ping := time.NewTicker(c.config.PingInterval)
+	defer ping.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case message := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+			if err := c.conn.WriteJSON(message); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.config.WriteTimeout)); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeReason), time.Now().Add(c.config.WriteTimeout))
+			return
+		}
+	}
+}
+
+// Closes the connection with the code and reason. Only the first call has an effect.
+func (c *connection) close(code int, reason string) {
+	c.once.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
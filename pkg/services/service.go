@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"govtech/pkg/events"
+	"govtech/pkg/server/cache"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/metrics"
+	"govtech/pkg/server/pubsub"
+	"govtech/pkg/utilities/patterns"
+	"govtech/pkg/utilities/set"
+)
+
+/*
+Structure for the operations of the API, shared by every version of the endpoints.
+Requests must have been validated by the controllers.
+*/
+type Service struct {
+	store database.Store
+	// Notifications published to the students who receive them, by email.
+	notifications *pubsub.Hub[database.Notification]
+	// Changes to the students of teachers published to the teachers, by email.
+	teacherEvents *pubsub.Hub[events.Event]
+	caches        Caches
+}
+
+/*
+Structure for the caches of the lookups of the service, which are not cached if nil.
+Cached students are tagged with the teachers they are registered to.
+*/
+type Caches struct {
+	// Active students of a teacher, by teacher.
+	Rosters cache.Cache[[]string]
+	// Students common to teachers, by teachers.
+	CommonStudents cache.Cache[[]string]
+}
+
+// Names of the caches of the service, used to label metrics.
+const CACHE_ROSTERS = "rosters"
+const CACHE_COMMON_STUDENTS = "common_students"
+
+// Number of notifications buffered for each subscriber of the notifications of a student.
+const NOTIFICATION_BUFFER = 64
+
+// Number of events buffered for each subscriber of the events of a teacher.
+const TEACHER_EVENT_BUFFER = 64
+
+// Name of the subscriber publishing the events of the outbox to teachers.
+const SUBSCRIBER_TEACHER_EVENTS = "teacher_events"
+
+// Name of the subscriber invalidating the caches with the events of the outbox.
+const SUBSCRIBER_CACHES = "caches"
+
+// Number of notifications read at once from the history of a student.
+const NOTIFICATION_HISTORY_PAGE = 100
+
+// Returns the service using the given store.
+func New(store database.Store) *Service {
+	return &Service{
+		store:         store,
+		notifications: pubsub.New[database.Notification](NOTIFICATION_BUFFER),
+		teacherEvents: pubsub.New[events.Event](TEACHER_EVENT_BUFFER),
+	}
+}
+
+// Returns caches in memory of the lookups of the service.
+func NewCaches(config cache.Config) Caches {
+	return Caches{
+		Rosters:        cache.NewLRU[[]string](CACHE_ROSTERS, config),
+		CommonStudents: cache.NewLRU[[]string](CACHE_COMMON_STUDENTS, config),
+	}
+}
+
+/*
+Caches the lookups of the service, invalidating the students of teachers once the events of changes to
+them are written by the store.
+Changes written by other processes, eg. other servers or the admin CLI, are invalidated by InvalidateCaches
+once dispatched from the outbox, or missed until the TTL of the caches ends. Until then, other servers may
+return stale students, eg. notify a student who was suspended.
+Must be called before the service is used.
+*/
+func (s *Service) UseCaches(caches Caches) {
+	s.caches = caches
+	s.store.OnEventsWritten(s.invalidateCaches)
+}
+
+// Returns the store of the service.
+func (s *Service) Store() database.Store {
+	return s.store
+}
+
+// Invalidates the cached students of the teachers of the changes to students.
+func (s *Service) invalidateCaches(written []events.Event) {
+	var teachers []string
+	for _, v := range written {
+		if v.IsStudentChange() {
+			teachers = append(teachers, v.Teachers...)
+		}
+	}
+	if len(teachers) == 0 {
+		return
+	}
+
+	for _, v := range []cache.Cache[[]string]{s.caches.Rosters, s.caches.CommonStudents} {
+		if v != nil {
+			v.Invalidate(teachers...)
+		}
+	}
+}
+
+/*
+Invalidates the cached students of the teachers of a change to students.
+Subscribed to the dispatcher of the outbox as SUBSCRIBER_CACHES, which sees the changes written by every process.
+*/
+func (s *Service) InvalidateCaches(ctx context.Context, event events.Event) error {
+	s.invalidateCaches([]events.Event{event})
+	return nil
+}
+
+// Registers a list of students to a teacher.
+func (s *Service) RegisterStudents(ctx context.Context, teacher string, students []string) error {
+	if err := s.store.RegisterStudents(ctx, teacher, students); err != nil {
+		return err
+	}
+	metrics.Registrations.Add(float64(len(students)))
+
+	return nil
+}
+
+// Registers a list of teachers to a student.
+func (s *Service) RegisterTeachers(ctx context.Context, student string, teachers []string) error {
+	if err := s.store.RegisterTeachers(ctx, student, teachers); err != nil {
+		return err
+	}
+	metrics.Registrations.Add(float64(len(teachers)))
+
+	return nil
+}
+
+/*
+Applies a chunk of registrations in one transaction, and returns the number of students newly registered.
+Nothing is written if dryRun is true.
+*/
+func (s *Service) ImportRegistrations(ctx context.Context, registrations []database.Registration, dryRun bool) (int, error) {
+	n, err := s.store.ImportRegistrations(ctx, registrations, dryRun)
+	if err != nil {
+		return 0, err
+	}
+	if !dryRun {
+		metrics.Registrations.Add(float64(n))
+	}
+
+	return n, nil
+}
+
+/*
+Deregisters a list of students from a teacher.
+Returns the students which were registered to the teacher, sorted by email.
+*/
+func (s *Service) DeregisterStudents(ctx context.Context, teacher string, students []string) ([]string, error) {
+	removed, err := s.store.DeregisterStudents(ctx, teacher, students)
+	if err != nil {
+		return nil, err
+	}
+	metrics.Deregistrations.Add(float64(len(removed)))
+
+	return removed, nil
+}
+
+// Returns the students registered to all of the teachers, sorted by email.
+func (s *Service) CommonStudents(ctx context.Context, teachers []string) ([]string, error) {
+	load := func(ctx context.Context) ([]string, error) {
+		students, err := s.store.CommonStudents(ctx, teachers)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(students)
+
+		return students, nil
+	}
+
+	if s.caches.CommonStudents == nil {
+		return load(ctx)
+	}
+
+	// The same teachers in any order have the same students.
+	tags := slices.Clone(teachers)
+	sort.Strings(tags)
+	tags = slices.Compact(tags)
+
+	students, err := s.caches.CommonStudents.Load(ctx, strings.Join(tags, "\n"), tags, load)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached students are shared, so callers get their own copy.
+	return slices.Clone(students), nil
+}
+
+// Returns the students registered to the teacher who are not suspended.
+func (s *Service) activeStudentsOf(ctx context.Context, teacher string) ([]string, error) {
+	if s.caches.Rosters == nil {
+		return s.store.ActiveStudentsOf(ctx, teacher)
+	}
+
+	students, err := s.caches.Rosters.Load(ctx, teacher, []string{teacher}, func(ctx context.Context) ([]string, error) {
+		return s.store.ActiveStudentsOf(ctx, teacher)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached students are shared, so callers get their own copy.
+	return slices.Clone(students), nil
+}
+
+// Suspends a student. Students already suspended are not counted again.
+func (s *Service) Suspend(ctx context.Context, student string) error {
+	changed, err := s.store.SuspendStudent(ctx, student)
+	if err != nil {
+		return err
+	}
+	if changed {
+		metrics.StudentsSuspended.Inc()
+	}
+
+	return nil
+}
+
+// Unsuspends a student.
+func (s *Service) Unsuspend(ctx context.Context, student string) error {
+	_, err := s.store.UnsuspendStudent(ctx, student)
+	return err
+}
+
+/*
+Returns the students who can receive a notification from a teacher, sorted by email.
+A student can receive a notification if he is not suspended and is registered to the teacher
+or is mentioned in the notification.
+The notification is saved to the history of its recipients and published to their subscribers.
+*/
+func (s *Service) RetrieveForNotifications(ctx context.Context, teacher string, notification string) ([]string, error) {
+	recipients := set.New[string]()
+
+	// Get all students registered under the teacher who are not suspended.
+	students, err := s.activeStudentsOf(ctx, teacher)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range students {
+		recipients.Add(v)
+	}
+
+	// Get all tagged students in the notification who are not
+	// suspended and are in the database.
+	regex := regexp.MustCompile(patterns.REGEX_PATTERN_EMAIL)
+	taggedStudents := regex.FindAllString(notification, -1)
+	for _, v := range taggedStudents {
+		active, err := s.store.IsActiveStudent(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add student to set if it exists.
+		if active {
+			recipients.Add(v)
+		}
+	}
+	array := recipients.ToArray()
+	sort.Strings(array)
+
+	if len(array) > 0 {
+		saved, err := s.store.SaveNotification(ctx, teacher, notification, array)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range array {
+			s.notifications.Publish(v, saved)
+		}
+	}
+
+	metrics.NotificationsResolved.Inc()
+	metrics.NotificationRecipients.Add(float64(len(array)))
+
+	return array, nil
+}
+
+/*
+Subscribes to the notifications received by the student from now on.
+The subscription is closed if the subscriber falls behind, and must be closed once it is no longer used.
+*/
+func (s *Service) SubscribeNotifications(student string) *pubsub.Subscription[database.Notification] {
+	return s.notifications.Subscribe(student)
+}
+
+// Calls fn with every notification received by the student after the notification with the given ID, in order.
+func (s *Service) NotificationsSince(ctx context.Context, student string, afterID int64, fn func(database.Notification) error) error {
+	for {
+		notifications, err := s.store.NotificationsOf(ctx, student, afterID, NOTIFICATION_HISTORY_PAGE)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range notifications {
+			if err := fn(v); err != nil {
+				return err
+			}
+			afterID = v.ID
+		}
+
+		if len(notifications) < NOTIFICATION_HISTORY_PAGE {
+			return nil
+		}
+	}
+}
+
+/*
+Subscribes to the changes to the students of the teacher from now on.
+The subscription is closed if the subscriber falls behind, and must be closed once it is no longer used.
+*/
+func (s *Service) SubscribeTeacherEvents(teacher string) *pubsub.Subscription[events.Event] {
+	return s.teacherEvents.Subscribe(teacher)
+}
+
+/*
+Publishes changes to students to the subscribers of each of their teachers.
+Subscribed to the dispatcher of the outbox as SUBSCRIBER_TEACHER_EVENTS, so that only committed changes are published.
+*/
+func (s *Service) PublishTeacherEvent(ctx context.Context, event events.Event) error {
+	if !event.IsStudentChange() {
+		return nil
+	}
+
+	for _, v := range event.Teachers {
+		s.teacherEvents.Publish(v, event)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package messages
+
+import (
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ms"
+	"github.com/go-playground/locales/ta"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Supported languages of messages.
+const LANGUAGE_EN = "en"
+const LANGUAGE_ZH = "zh"
+const LANGUAGE_MS = "ms"
+const LANGUAGE_TA = "ta"
+
+// Language used if the client does not accept any supported language.
+const LANGUAGE_DEFAULT = LANGUAGE_EN
+
+// Supported languages, in order of preference if the client accepts several equally.
+var LANGUAGES = []string{LANGUAGE_EN, LANGUAGE_ZH, LANGUAGE_MS, LANGUAGE_TA}
+
+/*
+Catalogs of messages keyed by message code, one per language.
+Parameters of a message are given as "{0}", "{1}", ...
+Every catalog must have the same message codes as the English catalog.
+*/
+var catalogs = map[string]map[string]string{
+	LANGUAGE_EN: catalogEN,
+	LANGUAGE_ZH: catalogZH,
+	LANGUAGE_MS: catalogMS,
+	LANGUAGE_TA: catalogTA,
+}
+
+var translator = newTranslator()
+
+// Returns the universal translator with the catalog of every language.
+func newTranslator() *ut.UniversalTranslator {
+	uni := ut.New(en.New(), en.New(), zh.New(), ms.New(), ta.New())
+
+	for lang, catalog := range catalogs {
+		trans, _ := uni.GetTranslator(lang)
+		for code, text := range catalog {
+			if err := trans.Add(code, text, false); err != nil {
+				panic(err.Error())
+			}
+		}
+	}
+
+	return uni
+}
+
+// Returns the translator of the language, or of the default language if it is not supported.
+func Translator(lang string) ut.Translator {
+	trans, _ := translator.GetTranslator(lang)
+
+	return trans
+}
+
+// Returns true if the message code is in the catalogs.
+func HasMessage(code string) bool {
+	_, ok := catalogEN[code]
+
+	return ok
+}
+
+// Returns true if the language has a catalog.
+func IsSupported(lang string) bool {
+	_, ok := catalogs[lang]
+
+	return ok
+}
+
+/*
+Returns the message of the code in the language with the given parameters.
+Falls back to the default language, and returns the code itself if it is not a
+message code, eg. if a detail was given as text.
+*/
+func Translate(lang string, code string, params ...string) string {
+	if text, err := Translator(lang).T(code, params...); err == nil {
+		return text
+	}
+	if text, err := Translator(LANGUAGE_DEFAULT).T(code, params...); err == nil {
+		return text
+	}
+
+	return code
+}
+
+// Returns the message codes missing from the catalog of the language.
+func MissingMessages(lang string) []string {
+	missing := []string{}
+	for code := range catalogEN {
+		if _, ok := catalogs[lang][code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,45 @@
+package messages
+
+// English messages.
+var catalogEN = map[string]string{
+	TITLE_PREFIX + CODE_BAD_REQUEST:            "Bad request",
+	TITLE_PREFIX + CODE_VALIDATION_FAILED:      "Validation failed",
+	TITLE_PREFIX + CODE_DATABASE_ERROR:         "Database error",
+	TITLE_PREFIX + CODE_INTERNAL_ERROR:         "Internal server error",
+	TITLE_PREFIX + CODE_NOT_READY:              "Service not ready",
+	TITLE_PREFIX + CODE_TIMEOUT:                "Request timed out",
+	TITLE_PREFIX + CODE_TOO_MANY_REQUESTS:      "Too many requests",
+	TITLE_PREFIX + CODE_UNAUTHORIZED:           "Unauthorized",
+	TITLE_PREFIX + CODE_TOO_MANY_SUBSCRIPTIONS: "Too many subscriptions",
+	TITLE_PREFIX + CODE_NOT_FOUND:              "Not found",
+
+	MESSAGE_BAD_REQUEST:            "The server could not understand the request due to invalid syntax or missing parameters.",
+	MESSAGE_INVALID_JSON:           "The request body is not valid JSON.",
+	MESSAGE_VALIDATION_FAILED:      "One or more fields are missing or invalid.",
+	MESSAGE_MISSING_QUERY_PARAMS:   "One or more required query parameters are missing or invalid.",
+	MESSAGE_INVALID_PARAMS:         "One or more fields are of the wrong type or format.",
+	MESSAGE_DATABASE_ERROR:         "Failed to query database record. Contact the administrator for more information.",
+	MESSAGE_INTERNAL_ERROR:         "An unexpected error occurred. Contact the administrator for more information.",
+	MESSAGE_NOT_READY:              "The server is unable to reach the database. Retry later.",
+	MESSAGE_TIMEOUT:                "The request took too long to complete. Retry later.",
+	MESSAGE_TOO_MANY_REQUESTS:      "Too many requests. Retry after the duration in the Retry-After header.",
+	MESSAGE_QUERY_TOO_DEEP:         "The query is nested {0} levels deep, more than the maximum of {1}.",
+	MESSAGE_QUERY_TOO_COMPLEX:      "The query has a complexity of {0}, more than the maximum of {1}.",
+	MESSAGE_INVALID_TOKEN:          "The token is missing, invalid or expired.",
+	MESSAGE_INVALID_ADMIN_KEY:      "The admin key is missing or invalid.",
+	MESSAGE_TOO_MANY_SUBSCRIPTIONS: "The connection is subscribed to the maximum number of teachers. Unsubscribe from a teacher first.",
+	MESSAGE_WEBSOCKET_REQUIRED:     "This endpoint only accepts WebSocket connections.",
+	MESSAGE_WEBHOOK_NOT_FOUND:      "There is no webhook with this ID.",
+	MESSAGE_CSV_REQUIRED:           "The request body must be CSV with the text/csv content type.",
+	MESSAGE_UNREADABLE_BODY:        "The request body could not be read completely.",
+
+	FIELD_PREFIX + FIELD_CODE_REQUIRED:  "This field is required.",
+	FIELD_PREFIX + FIELD_CODE_EMAIL:     "Must be a valid email address.",
+	FIELD_PREFIX + FIELD_CODE_FORMAT:    "Is of the wrong format.",
+	FIELD_PREFIX + FIELD_CODE_TYPE:      "Must be of type {0}.",
+	FIELD_PREFIX + FIELD_CODE_EXCLUSIVE: "Cannot be given together with {0}.",
+	FIELD_PREFIX + FIELD_CODE_MAX_ITEMS: "Must contain at most {0} items.",
+	FIELD_PREFIX + "max":                "Must be at most {0} characters long.",
+	FIELD_PREFIX + "min":                "Must be at least {0} characters long.",
+	FIELD_INVALID:                       "Failed validation {0}.",
+}
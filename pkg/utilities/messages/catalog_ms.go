@@ -0,0 +1,45 @@
+package messages
+
+// Malay messages.
+var catalogMS = map[string]string{
+	TITLE_PREFIX + CODE_BAD_REQUEST:            "Permintaan tidak sah",
+	TITLE_PREFIX + CODE_VALIDATION_FAILED:      "Pengesahan gagal",
+	TITLE_PREFIX + CODE_DATABASE_ERROR:         "Ralat pangkalan data",
+	TITLE_PREFIX + CODE_INTERNAL_ERROR:         "Ralat pelayan dalaman",
+	TITLE_PREFIX + CODE_NOT_READY:              "Perkhidmatan belum sedia",
+	TITLE_PREFIX + CODE_TIMEOUT:                "Permintaan tamat masa",
+	TITLE_PREFIX + CODE_TOO_MANY_REQUESTS:      "Terlalu banyak permintaan",
+	TITLE_PREFIX + CODE_UNAUTHORIZED:           "Tidak dibenarkan",
+	TITLE_PREFIX + CODE_TOO_MANY_SUBSCRIPTIONS: "Terlalu banyak langganan",
+	TITLE_PREFIX + CODE_NOT_FOUND:              "Tidak dijumpai",
+
+	MESSAGE_BAD_REQUEST:            "Pelayan tidak dapat memahami permintaan kerana sintaks tidak sah atau parameter tiada.",
+	MESSAGE_INVALID_JSON:           "Badan permintaan bukan JSON yang sah.",
+	MESSAGE_VALIDATION_FAILED:      "Satu atau lebih medan tiada atau tidak sah.",
+	MESSAGE_MISSING_QUERY_PARAMS:   "Satu atau lebih parameter pertanyaan yang diperlukan tiada atau tidak sah.",
+	MESSAGE_INVALID_PARAMS:         "Satu atau lebih medan mempunyai jenis atau format yang salah.",
+	MESSAGE_DATABASE_ERROR:         "Gagal membuat pertanyaan rekod pangkalan data. Hubungi pentadbir untuk maklumat lanjut.",
+	MESSAGE_INTERNAL_ERROR:         "Ralat yang tidak dijangka telah berlaku. Hubungi pentadbir untuk maklumat lanjut.",
+	MESSAGE_NOT_READY:              "Pelayan tidak dapat menghubungi pangkalan data. Cuba lagi kemudian.",
+	MESSAGE_TIMEOUT:                "Permintaan mengambil masa terlalu lama untuk selesai. Cuba lagi kemudian.",
+	MESSAGE_TOO_MANY_REQUESTS:      "Terlalu banyak permintaan. Cuba lagi selepas tempoh dalam pengepala Retry-After.",
+	MESSAGE_QUERY_TOO_DEEP:         "Pertanyaan bersarang sedalam {0} tahap, melebihi maksimum {1}.",
+	MESSAGE_QUERY_TOO_COMPLEX:      "Pertanyaan mempunyai kerumitan {0}, melebihi maksimum {1}.",
+	MESSAGE_INVALID_TOKEN:          "Token tiada, tidak sah atau telah tamat tempoh.",
+	MESSAGE_INVALID_ADMIN_KEY:      "Kunci pentadbir tiada atau tidak sah.",
+	MESSAGE_TOO_MANY_SUBSCRIPTIONS: "Sambungan telah melanggan bilangan maksimum guru. Nyahlanggan seorang guru terlebih dahulu.",
+	MESSAGE_WEBSOCKET_REQUIRED:     "Titik akhir ini hanya menerima sambungan WebSocket.",
+	MESSAGE_WEBHOOK_NOT_FOUND:      "Tiada webhook dengan ID ini.",
+	MESSAGE_CSV_REQUIRED:           "Badan permintaan mesti CSV dengan jenis kandungan text/csv.",
+	MESSAGE_UNREADABLE_BODY:        "Badan permintaan tidak dapat dibaca sepenuhnya.",
+
+	FIELD_PREFIX + FIELD_CODE_REQUIRED:  "Medan ini diperlukan.",
+	FIELD_PREFIX + FIELD_CODE_EMAIL:     "Mestilah alamat e-mel yang sah.",
+	FIELD_PREFIX + FIELD_CODE_FORMAT:    "Formatnya salah.",
+	FIELD_PREFIX + FIELD_CODE_TYPE:      "Mestilah jenis {0}.",
+	FIELD_PREFIX + FIELD_CODE_EXCLUSIVE: "Tidak boleh diberikan bersama {0}.",
+	FIELD_PREFIX + FIELD_CODE_MAX_ITEMS: "Mesti mengandungi paling banyak {0} item.",
+	FIELD_PREFIX + "max":                "Mesti tidak melebihi {0} aksara.",
+	FIELD_PREFIX + "min":                "Mesti sekurang-kurangnya {0} aksara.",
+	FIELD_INVALID:                       "Gagal pengesahan {0}.",
+}
@@ -0,0 +1,45 @@
+package messages
+
+// Tamil messages.
+var catalogTA = map[string]string{
+	TITLE_PREFIX + CODE_BAD_REQUEST:            "தவறான கோரிக்கை",
+	TITLE_PREFIX + CODE_VALIDATION_FAILED:      "சரிபார்ப்பு தோல்வியடைந்தது",
+	TITLE_PREFIX + CODE_DATABASE_ERROR:         "தரவுத்தளப் பிழை",
+	TITLE_PREFIX + CODE_INTERNAL_ERROR:         "உள் சேவையகப் பிழை",
+	TITLE_PREFIX + CODE_NOT_READY:              "சேவை தயாராக இல்லை",
+	TITLE_PREFIX + CODE_TIMEOUT:                "கோரிக்கை நேரம் முடிந்தது",
+	TITLE_PREFIX + CODE_TOO_MANY_REQUESTS:      "அதிகமான கோரிக்கைகள்",
+	TITLE_PREFIX + CODE_UNAUTHORIZED:           "அங்கீகரிக்கப்படவில்லை",
+	TITLE_PREFIX + CODE_TOO_MANY_SUBSCRIPTIONS: "அதிகமான சந்தாக்கள்",
+	TITLE_PREFIX + CODE_NOT_FOUND:              "கிடைக்கவில்லை",
+
+	MESSAGE_BAD_REQUEST:            "தவறான தொடரியல் அல்லது விடுபட்ட அளவுருக்கள் காரணமாக சேவையகத்தால் கோரிக்கையைப் புரிந்துகொள்ள முடியவில்லை.",
+	MESSAGE_INVALID_JSON:           "கோரிக்கையின் உள்ளடக்கம் சரியான JSON அல்ல.",
+	MESSAGE_VALIDATION_FAILED:      "ஒன்று அல்லது அதற்கு மேற்பட்ட புலங்கள் விடுபட்டுள்ளன அல்லது தவறானவை.",
+	MESSAGE_MISSING_QUERY_PARAMS:   "தேவையான ஒன்று அல்லது அதற்கு மேற்பட்ட வினவல் அளவுருக்கள் விடுபட்டுள்ளன அல்லது தவறானவை.",
+	MESSAGE_INVALID_PARAMS:         "ஒன்று அல்லது அதற்கு மேற்பட்ட புலங்களின் வகை அல்லது வடிவம் தவறானது.",
+	MESSAGE_DATABASE_ERROR:         "தரவுத்தளப் பதிவைப் பெற முடியவில்லை. மேலும் தகவலுக்கு நிர்வாகியைத் தொடர்பு கொள்ளவும்.",
+	MESSAGE_INTERNAL_ERROR:         "எதிர்பாராத பிழை ஏற்பட்டது. மேலும் தகவலுக்கு நிர்வாகியைத் தொடர்பு கொள்ளவும்.",
+	MESSAGE_NOT_READY:              "சேவையகத்தால் தரவுத்தளத்தை அணுக முடியவில்லை. பின்னர் மீண்டும் முயற்சிக்கவும்.",
+	MESSAGE_TIMEOUT:                "கோரிக்கையை முடிக்க அதிக நேரம் ஆனது. பின்னர் மீண்டும் முயற்சிக்கவும்.",
+	MESSAGE_TOO_MANY_REQUESTS:      "அதிகமான கோரிக்கைகள். Retry-After தலைப்பில் உள்ள நேரத்திற்குப் பிறகு மீண்டும் முயற்சிக்கவும்.",
+	MESSAGE_QUERY_TOO_DEEP:         "வினவல் {0} நிலைகள் ஆழமாக உள்ளது, இது அதிகபட்சமான {1} ஐ விட அதிகம்.",
+	MESSAGE_QUERY_TOO_COMPLEX:      "வினவலின் சிக்கலானது {0}, இது அதிகபட்சமான {1} ஐ விட அதிகம்.",
+	MESSAGE_INVALID_TOKEN:          "டோக்கன் இல்லை, தவறானது அல்லது காலாவதியானது.",
+	MESSAGE_INVALID_ADMIN_KEY:      "நிர்வாகி விசை இல்லை அல்லது தவறானது.",
+	MESSAGE_TOO_MANY_SUBSCRIPTIONS: "இணைப்பு அதிகபட்ச எண்ணிக்கையிலான ஆசிரியர்களுக்குச் சந்தா செய்துள்ளது. முதலில் ஒரு ஆசிரியரின் சந்தாவை நீக்கவும்.",
+	MESSAGE_WEBSOCKET_REQUIRED:     "இந்த முனையம் WebSocket இணைப்புகளை மட்டுமே ஏற்கிறது.",
+	MESSAGE_WEBHOOK_NOT_FOUND:      "இந்த ID உடைய webhook எதுவும் இல்லை.",
+	MESSAGE_CSV_REQUIRED:           "கோரிக்கை உள்ளடக்கம் text/csv உள்ளடக்க வகையுடன் CSV ஆக இருக்க வேண்டும்.",
+	MESSAGE_UNREADABLE_BODY:        "கோரிக்கை உள்ளடக்கத்தை முழுமையாகப் படிக்க முடியவில்லை.",
+
+	FIELD_PREFIX + FIELD_CODE_REQUIRED:  "இந்தப் புலம் தேவை.",
+	FIELD_PREFIX + FIELD_CODE_EMAIL:     "சரியான மின்னஞ்சல் முகவரியாக இருக்க வேண்டும்.",
+	FIELD_PREFIX + FIELD_CODE_FORMAT:    "வடிவம் தவறானது.",
+	FIELD_PREFIX + FIELD_CODE_TYPE:      "{0} வகையாக இருக்க வேண்டும்.",
+	FIELD_PREFIX + FIELD_CODE_EXCLUSIVE: "{0} உடன் சேர்த்து வழங்க முடியாது.",
+	FIELD_PREFIX + FIELD_CODE_MAX_ITEMS: "அதிகபட்சம் {0} உருப்படிகளைக் கொண்டிருக்க வேண்டும்.",
+	FIELD_PREFIX + "max":                "அதிகபட்சம் {0} எழுத்துகள் நீளமாக இருக்க வேண்டும்.",
+	FIELD_PREFIX + "min":                "குறைந்தபட்சம் {0} எழுத்துகள் நீளமாக இருக்க வேண்டும்.",
+	FIELD_INVALID:                       "சரிபார்ப்பு {0} தோல்வியடைந்தது.",
+}
@@ -0,0 +1,45 @@
+package messages
+
+// Simplified Chinese messages.
+var catalogZH = map[string]string{
+	TITLE_PREFIX + CODE_BAD_REQUEST:            "请求无效",
+	TITLE_PREFIX + CODE_VALIDATION_FAILED:      "验证失败",
+	TITLE_PREFIX + CODE_DATABASE_ERROR:         "数据库错误",
+	TITLE_PREFIX + CODE_INTERNAL_ERROR:         "服务器内部错误",
+	TITLE_PREFIX + CODE_NOT_READY:              "服务尚未就绪",
+	TITLE_PREFIX + CODE_TIMEOUT:                "请求超时",
+	TITLE_PREFIX + CODE_TOO_MANY_REQUESTS:      "请求过多",
+	TITLE_PREFIX + CODE_UNAUTHORIZED:           "未授权",
+	TITLE_PREFIX + CODE_TOO_MANY_SUBSCRIPTIONS: "订阅过多",
+	TITLE_PREFIX + CODE_NOT_FOUND:              "未找到",
+
+	MESSAGE_BAD_REQUEST:            "由于语法无效或缺少参数，服务器无法理解该请求。",
+	MESSAGE_INVALID_JSON:           "请求正文不是有效的 JSON。",
+	MESSAGE_VALIDATION_FAILED:      "一个或多个字段缺失或无效。",
+	MESSAGE_MISSING_QUERY_PARAMS:   "一个或多个必需的查询参数缺失或无效。",
+	MESSAGE_INVALID_PARAMS:         "一个或多个字段的类型或格式错误。",
+	MESSAGE_DATABASE_ERROR:         "查询数据库记录失败。请联系管理员了解更多信息。",
+	MESSAGE_INTERNAL_ERROR:         "发生意外错误。请联系管理员了解更多信息。",
+	MESSAGE_NOT_READY:              "服务器无法连接数据库。请稍后重试。",
+	MESSAGE_TIMEOUT:                "请求处理时间过长。请稍后重试。",
+	MESSAGE_TOO_MANY_REQUESTS:      "请求过多。请在 Retry-After 标头指定的时间后重试。",
+	MESSAGE_QUERY_TOO_DEEP:         "查询嵌套了 {0} 层，超过了最大值 {1}。",
+	MESSAGE_QUERY_TOO_COMPLEX:      "查询的复杂度为 {0}，超过了最大值 {1}。",
+	MESSAGE_INVALID_TOKEN:          "令牌缺失、无效或已过期。",
+	MESSAGE_INVALID_ADMIN_KEY:      "管理员密钥缺失或无效。",
+	MESSAGE_TOO_MANY_SUBSCRIPTIONS: "该连接订阅的教师数量已达上限。请先取消订阅一位教师。",
+	MESSAGE_WEBSOCKET_REQUIRED:     "此端点仅接受 WebSocket 连接。",
+	MESSAGE_WEBHOOK_NOT_FOUND:      "不存在此 ID 的 webhook。",
+	MESSAGE_CSV_REQUIRED:           "请求正文必须是内容类型为 text/csv 的 CSV。",
+	MESSAGE_UNREADABLE_BODY:        "无法完整读取请求正文。",
+
+	FIELD_PREFIX + FIELD_CODE_REQUIRED:  "此字段为必填项。",
+	FIELD_PREFIX + FIELD_CODE_EMAIL:     "必须是有效的电子邮件地址。",
+	FIELD_PREFIX + FIELD_CODE_FORMAT:    "格式错误。",
+	FIELD_PREFIX + FIELD_CODE_TYPE:      "类型必须为 {0}。",
+	FIELD_PREFIX + FIELD_CODE_EXCLUSIVE: "不能与 {0} 同时提供。",
+	FIELD_PREFIX + FIELD_CODE_MAX_ITEMS: "最多只能包含 {0} 项。",
+	FIELD_PREFIX + "max":                "长度不能超过 {0} 个字符。",
+	FIELD_PREFIX + "min":                "长度不能少于 {0} 个字符。",
+	FIELD_INVALID:                       "未通过验证 {0}。",
+}
@@ -1,90 +1,74 @@
 package messages
 
-import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-)
-
-const MESSAGE_BAD_REQUEST = "The server could not understand the request due to invalid syntax or missing parameters."
-const MESSAGE_DATABASE_ERROR = "Failed to query database record. Contact the administrator for more information."
-const MESSAGE_MISSING_PARAMS = "One or more required query parameter(s) is missing or invalid"
-
-// Returns string of missing required query parameters.
-func MissingQueryParamsMessage(parameters []string) string {
-	output := "One or more required query parameter(s) is missing or invalid: "
-
-	for i, v := range parameters {
-		if i == len(parameters)-1 {
-			output = output + v
-		} else {
-			output = output + v + ", "
-		}
-	}
-
-	return output
-}
-
-// Returns string of invalid required query parameters.
-func InvalidParamsMessage(fields []string) string {
-	output := "One or more field(s) is of the wrong type or format: "
-
-	for i, v := range fields {
-		if i == len(fields)-1 {
-			output = output + v
-		} else {
-			output = output + v + ", "
-		}
+// Stable machine-readable codes of error responses.
+const CODE_BAD_REQUEST = "bad_request"
+const CODE_VALIDATION_FAILED = "validation_failed"
+const CODE_DATABASE_ERROR = "database_error"
+const CODE_INTERNAL_ERROR = "internal_error"
+const CODE_NOT_READY = "not_ready"
+const CODE_TIMEOUT = "timeout"
+const CODE_TOO_MANY_REQUESTS = "too_many_requests"
+const CODE_QUERY_TOO_DEEP = "query_too_deep"
+const CODE_QUERY_TOO_COMPLEX = "query_too_complex"
+const CODE_UNAUTHORIZED = "unauthorized"
+const CODE_TOO_MANY_SUBSCRIPTIONS = "too_many_subscriptions"
+const CODE_NOT_FOUND = "not_found"
+
+// Stable machine-readable codes of field errors.
+// Codes of validation tags, eg. "required", "email" or "max", are used as is.
+const FIELD_CODE_REQUIRED = "required"
+const FIELD_CODE_EMAIL = "email"
+const FIELD_CODE_FORMAT = "format"
+const FIELD_CODE_TYPE = "type"
+const FIELD_CODE_EXCLUSIVE = "exclusive"
+const FIELD_CODE_MAX_ITEMS = "max_items"
+
+// Message codes of the details of error responses.
+// The messages of each language are in the catalogs.
+const MESSAGE_BAD_REQUEST = "message.bad_request"
+const MESSAGE_INVALID_JSON = "message.invalid_json"
+const MESSAGE_VALIDATION_FAILED = "message.validation_failed"
+const MESSAGE_MISSING_QUERY_PARAMS = "message.missing_query_params"
+const MESSAGE_INVALID_PARAMS = "message.invalid_params"
+const MESSAGE_DATABASE_ERROR = "message.database_error"
+const MESSAGE_INTERNAL_ERROR = "message.internal_error"
+const MESSAGE_NOT_READY = "message.not_ready"
+const MESSAGE_TIMEOUT = "message.timeout"
+const MESSAGE_TOO_MANY_REQUESTS = "message.too_many_requests"
+const MESSAGE_QUERY_TOO_DEEP = "message.query_too_deep"
+const MESSAGE_QUERY_TOO_COMPLEX = "message.query_too_complex"
+const MESSAGE_INVALID_TOKEN = "message.invalid_token"
+const MESSAGE_INVALID_ADMIN_KEY = "message.invalid_admin_key"
+const MESSAGE_TOO_MANY_SUBSCRIPTIONS = "message.too_many_subscriptions"
+const MESSAGE_WEBSOCKET_REQUIRED = "message.websocket_required"
+const MESSAGE_WEBHOOK_NOT_FOUND = "message.webhook_not_found"
+const MESSAGE_CSV_REQUIRED = "message.csv_required"
+const MESSAGE_UNREADABLE_BODY = "message.unreadable_body"
+
+// Prefixes of the message codes of titles and field errors, followed by the code.
+const TITLE_PREFIX = "title."
+const FIELD_PREFIX = "field."
+
+// Message code of field errors without a message of their own.
+const FIELD_INVALID = FIELD_PREFIX + "invalid"
+
+// Returns the title of the error code in the language.
+func Title(lang string, code string) string {
+	if !HasMessage(TITLE_PREFIX + code) {
+		code = CODE_BAD_REQUEST
 	}
 
-	return output
+	return Translate(lang, TITLE_PREFIX+code)
 }
 
-// Returns map of fields and "required" string.
-func MissingParamsMessage(verr validator.ValidationErrors) map[string]string {
-	errs := make(map[string]string)
-
-	for _, v := range verr {
-		err := v.ActualTag()
-		if v.Param() != "" {
-			err = fmt.Sprintf("%s=%s", err, v.Param())
-		}
-		errs[strings.ToLower(v.Field())] = err
+// Returns the detail of a field error with the given code and validation parameter in the language.
+func FieldErrorMessage(lang string, code string, param string) string {
+	if HasMessage(FIELD_PREFIX + code) {
+		return Translate(lang, FIELD_PREFIX+code, param)
 	}
 
-	return errs
-}
-
-// Returns map of field and its required data type.
-func TypeErrorParamsMessage(jsErr json.UnmarshalTypeError) map[string]string {
-	errs := make(map[string]string)
-	errs[jsErr.Field] = jsErr.Type.String()
-
-	return errs
-}
-
-/*
-Returns map of MissingParamsMessage or TypeErrorParamsMessage.
-If both error are not present, then return a generic error message.
-*/
-func GetErrorMessage(err error) (gin.H, string) {
-	var validationError validator.ValidationErrors
-	var unmarshalTypeError *json.UnmarshalTypeError
-
-	if errors.As(err, &validationError) {
-		return gin.H{MESSAGE_MISSING_PARAMS: MissingParamsMessage(validationError)}, ""
-	} else if errors.As(err, &unmarshalTypeError) {
-		return gin.H{MESSAGE_MISSING_PARAMS: TypeErrorParamsMessage(*unmarshalTypeError)}, ""
-	} else {
-		return nil, "One or more required fields are missing or invalid."
+	if param != "" {
+		return Translate(lang, FIELD_INVALID, code+"="+param)
 	}
-}
-
-// Return string of missing required pairs of fields.
-func MissingValidPairMessage(field1 string, field2 string) string {
-	return "Both fields " + field1 + " and " + field2 + " must be present and valid"
+	return Translate(lang, FIELD_INVALID, code)
 }
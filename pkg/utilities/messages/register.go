@@ -1,5 +0,0 @@
-package messages
-
-// Error messages for the "/api/register" endpoint.
-const INVALID_TEACHER_EMAIL_FORMAT = "One or more provided teacher email is of the wrong format"
-const INVALID_STUDENT_EMAIL_FORMAT = "One or more provided student email is of the wrong format"
@@ -8,6 +8,14 @@ import (
 const REGEX_PATTERN_NOTIFICATION = `^([a-zA-Z0-9_.,!?-]+\s?)*(@[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}\s*)*$`
 const REGEX_PATTERN_EMAIL = `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`
 
+// Whole emails, compiled once as every interface validates emails with it.
+var emailRegex = regexp.MustCompile("^" + REGEX_PATTERN_EMAIL + "$")
+
+// Returns true if the string is an email following REGEX_PATTERN_EMAIL.
+func IsEmail(str string) bool {
+	return emailRegex.MatchString(str)
+}
+
 // Validates a given string based on the given regexp pattern.
 func ValidatePattern(pattern string, str string) bool {
 	regex := regexp.MustCompile(pattern)
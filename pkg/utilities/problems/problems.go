@@ -0,0 +1,93 @@
+package problems
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"govtech/pkg/models/response"
+	"govtech/pkg/utilities/messages"
+)
+
+// Content type of error responses.
+const MIME_PROBLEM_JSON = "application/problem+json"
+
+// Prefix of the type URI of problems, followed by the problem code.
+const TYPE_PREFIX = "/problems/"
+
+/*
+Returns a problem with the given status, code and detail.
+The detail is a message code, which is translated into the language of the
+client when the problem is written, or a text which is written as is.
+*/
+func New(status int, code string, detail string) *response.Problem {
+	return &response.Problem{
+		Type:       TYPE_PREFIX + code,
+		Title:      messages.Title(messages.LANGUAGE_DEFAULT, code),
+		Status:     status,
+		Detail:     messages.Translate(messages.LANGUAGE_DEFAULT, detail),
+		Code:       code,
+		DetailCode: detail,
+	}
+}
+
+// Returns a validation problem for the given field errors.
+func Validation(detail string, errs ...response.FieldError) *response.Problem {
+	problem := New(http.StatusBadRequest, messages.CODE_VALIDATION_FAILED, detail)
+	problem.Errors = errs
+
+	return problem
+}
+
+// Returns a field error with the given code, and the detail for the code.
+func Field(field string, code string, param string) response.FieldError {
+	return response.FieldError{
+		Field:  field,
+		Code:   code,
+		Detail: messages.FieldErrorMessage(messages.LANGUAGE_DEFAULT, code, param),
+		Param:  param,
+	}
+}
+
+// Translates the title and details of the problem into the language.
+func Translate(problem *response.Problem, lang string) {
+	problem.Title = messages.Title(lang, problem.Code)
+	if problem.DetailCode != "" {
+		problem.Detail = messages.Translate(lang, problem.DetailCode)
+	}
+
+	for i, v := range problem.Errors {
+		problem.Errors[i].Detail = messages.FieldErrorMessage(lang, v.Code, v.Param)
+	}
+}
+
+/*
+Returns the problem for an error returned by binding the request body.
+Validation and type errors are reported per field.
+*/
+func FromBindError(err error) *response.Problem {
+	var validationErrors validator.ValidationErrors
+	var unmarshalTypeError *json.UnmarshalTypeError
+
+	if errors.As(err, &validationErrors) {
+		errs := make([]response.FieldError, 0, len(validationErrors))
+		for _, v := range validationErrors {
+			code := v.ActualTag()
+			// The "max" tag of a list limits its length instead of the length of a string.
+			if code == "max" && v.Kind() == reflect.Slice {
+				code = messages.FIELD_CODE_MAX_ITEMS
+			}
+			errs = append(errs, Field(strings.ToLower(v.Field()), code, v.Param()))
+		}
+		return Validation(messages.MESSAGE_VALIDATION_FAILED, errs...)
+	} else if errors.As(err, &unmarshalTypeError) {
+		return Validation(messages.MESSAGE_VALIDATION_FAILED,
+			Field(unmarshalTypeError.Field, messages.FIELD_CODE_TYPE, unmarshalTypeError.Type.String()))
+	}
+
+	return New(http.StatusBadRequest, messages.CODE_BAD_REQUEST, messages.MESSAGE_INVALID_JSON)
+}
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// Error of connections to addresses which webhooks may not receive deliveries at.
+var ErrForbiddenAddress = errors.New("address of webhook is not allowed")
+
+/*
+Returns a client for deliveries to webhooks, whose requests end after the timeout.
+Unless allowPrivate is set, the client refuses to connect to addresses which are not public, eg. loopback,
+link-local or private addresses, so that webhooks cannot reach the internal network of the server. The address is
+checked once resolved, as the host of a URL can resolve to any address.
+*/
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = checkAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Requests are not sent through proxies, which would be the address checked instead of the webhook.
+	transport.Proxy = nil
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Returns ErrForbiddenAddress if the address of the connection is not public.
+func checkAddress(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !IsPublicAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+
+	return nil
+}
+
+// IPv4 ranges which are not public, besides those reported by the methods of netip.Addr.
+var nonPublicPrefixes = []netip.Prefix{
+	// "This network", which some systems route to the host itself.
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// Shared address space of carrier-grade NAT.
+	netip.MustParsePrefix("100.64.0.0/10"),
+	// Benchmarking networks.
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+/*
+Returns true unless the IP is unspecified, loopback, link-local, private, multicast or in a non-public range,
+eg. 127.0.0.1, 169.254.169.254, 10.0.0.1, 224.0.0.1 or 100.64.0.1.
+*/
+func IsPublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	for _, v := range nonPublicPrefixes {
+		if v.Contains(ip) {
+			return false
+		}
+	}
+
+	return !ip.IsUnspecified() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsMulticast() && !ip.IsPrivate()
+}
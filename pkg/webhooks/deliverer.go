@@ -0,0 +1,235 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"govtech/pkg/config"
+	"govtech/pkg/events"
+	"govtech/pkg/server/metrics"
+)
+
+// Name of the subscriber of the dispatcher enqueuing the deliveries of events to webhooks.
+const SUBSCRIBER = "webhooks"
+
+// Maximum duration of a ping, and duration of its claim after which it is retried as a delivery
+// if its attempt could not be recorded.
+const PING_TIMEOUT = 10 * time.Second
+const PING_LEASE = time.Minute
+
+// Store of webhooks and their deliveries, eg. the store.
+type Store interface {
+	// Enqueues a delivery of the event to every webhook accepting it, once per webhook,
+	// and returns the number of deliveries enqueued.
+	EnqueueDeliveries(ctx context.Context, event events.Event, payload []byte) (int, error)
+	// Claims up to limit pending deliveries which are due, in order, so that other deliverers skip them until lease.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Time, limit int) ([]Delivery, error)
+	// Records the attempt, and updates the status of its delivery and when it is next attempted.
+	RecordAttempt(ctx context.Context, attempt Attempt, status string, nextAttemptAt time.Time) error
+}
+
+// Structure for the configuration of the deliverer.
+type Config struct {
+	// Interval between polls of pending deliveries, deliveries enqueued by this server are sent without waiting.
+	PollInterval time.Duration
+	// Maximum number of deliveries claimed at once.
+	BatchSize int
+	// Maximum number of webhooks delivered to at once, so that slow webhooks do not hold up the others.
+	Concurrency int
+	// Duration of a claim, after which deliveries are sent again if their attempt could not be recorded.
+	Lease time.Duration
+	// Delay before the first retry of a delivery, doubled on every attempt up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Number of attempts after which a delivery fails.
+	MaxAttempts int
+}
+
+// Returns the configuration of the deliveries to registered webhooks.
+func NewConfig(c config.WebhooksConfig) Config {
+	return Config{
+		PollInterval:    c.PollInterval.Duration,
+		BatchSize:       c.BatchSize,
+		Concurrency:     c.Concurrency,
+		Lease:           c.Lease.Duration,
+		RetryBackoff:    c.RetryBackoff.Duration,
+		MaxRetryBackoff: c.MaxRetryBackoff.Duration,
+		MaxAttempts:     c.MaxAttempts,
+	}
+}
+
+/*
+Structure for the delivery of events to the registered webhooks.
+Deliveries are retried with backoff until the webhook responds with a 2xx status
+or the maximum number of attempts is reached, and every attempt is recorded.
+*/
+type Deliverer struct {
+	store  Store
+	client *http.Client
+	config Config
+	wake   chan struct{}
+}
+
+// Returns a deliverer sending the deliveries of the store with the client.
+func NewDeliverer(store Store, client *http.Client, config Config) *Deliverer {
+	return &Deliverer{store: store, client: client, config: config, wake: make(chan struct{}, 1)}
+}
+
+/*
+Enqueues the deliveries of the event to the webhooks accepting it.
+Used as the handler of the SUBSCRIBER subscriber of the dispatcher, events delivered
+more than once are enqueued once per webhook.
+*/
+func (d *Deliverer) Handle(ctx context.Context, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	n, err := d.store.EnqueueDeliveries(ctx, event, payload)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		d.Notify()
+	}
+
+	return nil
+}
+
+// Wakes the deliverer up to send deliveries which were just enqueued. Never blocks.
+func (d *Deliverer) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sends deliveries until ctx is done.
+func (d *Deliverer) Run(ctx context.Context) {
+	poll := time.NewTicker(d.config.PollInterval)
+	defer poll.Stop()
+
+	for {
+		n, err := d.Deliver(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "failed to deliver webhooks", "error", err)
+		}
+
+		// Continue without waiting while there may be more deliveries due.
+		if err == nil && n == d.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-d.wake:
+		}
+	}
+}
+
+/*
+Sends one batch of the deliveries which are due, and returns the number of deliveries claimed.
+The deliveries of each webhook are sent in order, and up to Concurrency webhooks are delivered to at once.
+*/
+func (d *Deliverer) Deliver(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+
+	deliveries, err := d.store.ClaimDeliveries(ctx, now, now.Add(d.config.Lease), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Group the deliveries by webhook, in the order of their first delivery.
+	var webhooks []int64
+	groups := make(map[int64][]Delivery)
+	for _, v := range deliveries {
+		if _, ok := groups[v.WebhookID]; !ok {
+			webhooks = append(webhooks, v.WebhookID)
+		}
+		groups[v.WebhookID] = append(groups[v.WebhookID], v)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var first error
+	workers := make(chan struct{}, max(d.config.Concurrency, 1))
+
+	for _, id := range webhooks {
+		workers <- struct{}{}
+		wg.Add(1)
+
+		go func(group []Delivery) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			// Stop at the first delivery of the webhook which cannot be recorded, the rest are sent again after the lease.
+			for _, v := range group {
+				if err := d.deliver(ctx, v); err != nil {
+					mu.Lock()
+					if first == nil {
+						first = err
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		}(groups[id])
+	}
+
+	wg.Wait()
+
+	return len(deliveries), first
+}
+
+// Makes an attempt of the delivery, and records it with the next status of the delivery.
+func (d *Deliverer) deliver(ctx context.Context, delivery Delivery) error {
+	attempt := Send(ctx, d.client, delivery)
+
+	status := STATUS_SUCCEEDED
+	nextAttemptAt := attempt.AttemptedAt
+	if !attempt.Succeeded() {
+		metrics.WebhookAttempts.WithLabelValues(events.RESULT_FAILURE).Inc()
+		slog.WarnContext(ctx, "failed to deliver webhook",
+			"delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "event_id", delivery.EventID,
+			"attempt", delivery.Attempts+1, "error", attempt.Error)
+
+		status = STATUS_PENDING
+		if delivery.Attempts+1 >= d.config.MaxAttempts {
+			status = STATUS_FAILED
+		} else {
+			nextAttemptAt = time.Now().UTC().Add(events.Backoff(d.config.RetryBackoff, d.config.MaxRetryBackoff, delivery.Attempts))
+		}
+	} else {
+		metrics.WebhookAttempts.WithLabelValues(events.RESULT_SUCCESS).Inc()
+	}
+
+	return d.store.RecordAttempt(ctx, attempt, status, nextAttemptAt)
+}
+
+/*
+Sends a ping delivery to its webhook once, and records the attempt.
+Pings are not retried, so that receivers can be tested without waiting.
+*/
+func Ping(ctx context.Context, store Store, client *http.Client, delivery Delivery) (Attempt, error) {
+	attempt := Send(ctx, client, delivery)
+
+	status := STATUS_SUCCEEDED
+	if !attempt.Succeeded() {
+		status = STATUS_FAILED
+	}
+
+	return attempt, store.RecordAttempt(ctx, attempt, status, attempt.AttemptedAt)
+}
+
+// Returns the payload of a ping of a webhook, of the same shape as the payloads of events.
+func PingPayload() ([]byte, error) {
+	return json.Marshal(events.New(TYPE_PING, []string{}, []string{}))
+}
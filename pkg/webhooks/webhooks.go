@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"govtech/pkg/events"
+)
+
+// Headers of the requests delivering events to registered webhooks, besides the event ID and type.
+const HEADER_SIGNATURE = "X-Webhook-Signature"
+const HEADER_TIMESTAMP = "X-Webhook-Timestamp"
+const HEADER_DELIVERY_ID = "X-Webhook-Delivery"
+
+// Prefix of signatures, followed by the hex HMAC-SHA256 of the timestamp and body.
+const SIGNATURE_PREFIX = "sha256="
+
+// Type of the events sent to test webhooks, which are never filtered out.
+const TYPE_PING = "ping"
+
+// Types of events which webhooks can subscribe to.
+var EVENT_TYPES = []string{
+	events.TYPE_STUDENT_REGISTERED,
+	events.TYPE_STUDENT_DEREGISTERED,
+	events.TYPE_STUDENT_SUSPENDED,
+	events.TYPE_STUDENT_UNSUSPENDED,
+	events.TYPE_NOTIFICATION_ISSUED,
+}
+
+// Statuses of deliveries.
+const STATUS_PENDING = "pending"
+const STATUS_SUCCEEDED = "succeeded"
+const STATUS_FAILED = "failed"
+
+// Maximum length of the errors recorded for failed attempts.
+const MAX_ERROR_LENGTH = 1000
+
+// Structure for a webhook registered to receive events.
+type Webhook struct {
+	ID  int64
+	URL string
+	// Types of events sent to the webhook, every type if empty.
+	Events []string
+	// Secret shared with the receiver, used to sign deliveries.
+	Secret    string
+	CreatedAt time.Time
+}
+
+// Structure for the delivery of an event to a webhook.
+type Delivery struct {
+	ID        int64
+	WebhookID int64
+	// URL and secret of the webhook, set on claimed deliveries.
+	URL    string
+	Secret string
+	// ID of the event in the outbox, 0 for pings.
+	EventID   int64
+	EventType string
+	// JSON of the event, sent as the body of every attempt.
+	Payload []byte
+	Status  string
+	// Number of previous attempts.
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	// Attempts made, in order, set on listed deliveries.
+	History []Attempt
+}
+
+// Structure for an attempt to deliver an event to a webhook.
+type Attempt struct {
+	DeliveryID  int64
+	AttemptedAt time.Time
+	// Status of the response of the webhook, 0 if there was none.
+	ResponseStatus int
+	// Error of a failed attempt, empty if the attempt succeeded.
+	Error    string
+	Duration time.Duration
+}
+
+// Returns true if the attempt succeeded.
+func (a Attempt) Succeeded() bool {
+	return a.Error == ""
+}
+
+// Returns true if the webhook receives events of the given type.
+func (w Webhook) Accepts(eventType string) bool {
+	if len(w.Events) == 0 || eventType == TYPE_PING {
+		return true
+	}
+
+	for _, v := range w.Events {
+		if v == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Returns the signature of a delivery with the given timestamp and body, of the form
+"sha256=<hex HMAC-SHA256 of the secret over '<timestamp>.<body>'>".
+Receivers compute the same signature to check that deliveries come from the server
+and reject timestamps which are too old to prevent replays.
+*/
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return SIGNATURE_PREFIX + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns true if the signature is the signature of the timestamp and body with the secret.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+/*
+Posts the payload of the delivery to its webhook, signed with the secret of the webhook,
+and returns the attempt. The attempt fails unless the webhook responds with a 2xx status.
+*/
+func Send(ctx context.Context, client *http.Client, delivery Delivery) Attempt {
+	start := time.Now()
+	attempt := Attempt{DeliveryID: delivery.ID, AttemptedAt: start.UTC().Truncate(time.Millisecond)}
+
+	status, err := send(ctx, client, delivery, start)
+	attempt.ResponseStatus = status
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		if len(attempt.Error) > MAX_ERROR_LENGTH {
+			attempt.Error = attempt.Error[:MAX_ERROR_LENGTH]
+		}
+	}
+
+	return attempt
+}
+
+// Posts the payload of the delivery, and returns the status of the response.
+func send(ctx context.Context, client *http.Client, delivery Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "govtech-webhooks")
+	req.Header.Set(HEADER_DELIVERY_ID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HEADER_TIMESTAMP, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HEADER_SIGNATURE, Sign(delivery.Secret, timestamp, delivery.Payload))
+	req.Header.Set(events.HEADER_EVENT_TYPE, delivery.EventType)
+	if delivery.EventID != 0 {
+		req.Header.Set(events.HEADER_EVENT_ID, strconv.FormatInt(delivery.EventID, 10))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Returns true if the URL is an absolute http or https URL.
+func IsHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
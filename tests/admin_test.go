@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/admin"
+	"govtech/pkg/config"
+	"govtech/pkg/fixtures"
+	"govtech/pkg/imports"
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/auth"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+)
+
+// Returns a CLI with a closed DB, and its stdout and stderr.
+func testCLI(t *testing.T, stdin string) (*admin.CLI, *bytes.Buffer, *bytes.Buffer) {
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	db.Close()
+
+	var stdout, stderr bytes.Buffer
+	cli := &admin.CLI{
+		Service: services.New(database.NewStore(db)),
+		Stdin:   strings.NewReader(stdin),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	}
+
+	return cli, &stdout, &stderr
+}
+
+// Tests for the admin CLI which do not need a database.
+func TestAdmin(t *testing.T) {
+	t.Run("config", AdminConfig)
+	t.Run("usage", AdminUsage)
+	t.Run("validation", AdminValidation)
+	t.Run("database errors", AdminDatabaseErrors)
+	t.Run("import", AdminImport)
+	t.Run("seed", AdminSeed)
+	t.Run("token", AdminToken)
+}
+
+// Tests that the config flags are followed by the command and its arguments.
+func AdminConfig(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_USER", "user")
+	t.Setenv("DB_NAME", "name")
+
+	cfg, args, err := config.LoadCommand("admin", []string{"-db-host", "flaghost", "list", "-teacher", "t1@gmail.com", "students"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "flaghost", cfg.Database.Host)
+	assert.Equal(t, []string{"list", "-teacher", "t1@gmail.com", "students"}, args)
+}
+
+// Tests that missing and unknown commands and flags are usage errors, and that the usage lists every command.
+func AdminUsage(t *testing.T) {
+	var usage bytes.Buffer
+	admin.Usage(&usage, "admin")
+	for _, v := range []string{"register", "deregister", "suspend", "unsuspend", "list", "common", "notify", "import", "export", "seed", "token"} {
+		assert.True(t, admin.IsCommand(v), v)
+		assert.Contains(t, usage.String(), "  "+v+" ", v)
+	}
+	assert.False(t, admin.IsCommand("help"))
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"no command", []string{}, admin.EXIT_USAGE},
+		{"unknown command", []string{"drop"}, admin.EXIT_USAGE},
+		{"unknown flag", []string{"suspend", "-force", "s1@gmail.com"}, admin.EXIT_USAGE},
+		{"output", []string{"common", "-o", "yaml", "t1@gmail.com"}, admin.EXIT_USAGE},
+		{"help", []string{"export", "-h"}, admin.EXIT_OK},
+	}
+
+	for _, v := range tests {
+		cli, stdout, stderr := testCLI(t, "")
+		assert.Equal(t, v.code, cli.Run(context.Background(), v.args), v.name)
+		assert.Empty(t, stdout.String(), v.name)
+		assert.NotEmpty(t, stderr.String(), v.name)
+	}
+}
+
+// Tests that arguments are validated with the same rules as the API, before querying the DB.
+func AdminValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		errors []string
+	}{
+		{"register without teacher", []string{"register", "s1@gmail.com"}, []string{"teacher: " + messages.FieldErrorMessage(messages.LANGUAGE_EN, messages.FIELD_CODE_REQUIRED, "")}},
+		{"register both shapes", []string{"register", "-teacher", "t1@gmail.com", "-student", "s1@gmail.com", "t2@gmail.com"}, []string{"student: ", "teachers: "}},
+		{"register invalid student", []string{"register", "-teacher", "t1@gmail.com", "s1@gmail.com", "student"}, []string{"students[1]: " + messages.FieldErrorMessage(messages.LANGUAGE_EN, messages.FIELD_CODE_EMAIL, "")}},
+		{"deregister without students", []string{"deregister", "-teacher", "t1@gmail.com"}, []string{"students: "}},
+		{"suspend invalid student", []string{"suspend", "student"}, []string{"student: "}},
+		{"unsuspend without student", []string{"unsuspend"}, []string{"exactly one student is required"}},
+		{"common without teacher", []string{"common"}, []string{"at least one teacher is required"}},
+		{"notify too long", []string{"notify", "-teacher", "t1@gmail.com", strings.Repeat("a", 201)}, []string{"notification: "}},
+		{"list unknown relation", []string{"list", "classes"}, []string{`unknown relation "classes"`}},
+		{"list invalid suspended", []string{"list", "-suspended", "maybe", "students"}, []string{"expected a boolean"}},
+		{"list invalid teacher", []string{"list", "-teacher", "teacher", "students"}, []string{"teacher: "}},
+		{"export unknown format", []string{"export", "-format", "xlsx", "students"}, []string{`unknown format "xlsx"`}},
+		{"import two files", []string{"import", "a.csv", "b.csv"}, []string{"at most one file is allowed"}},
+		{"seed without students", []string{"seed", "-students", "0"}, []string{"at least one teacher and one student are required"}},
+		{"seed invalid rate", []string{"seed", "-suspended", "2"}, []string{"rates must be between 0 and 1"}},
+		{"token without email", []string{"token"}, []string{"exactly one email is required"}},
+		{"token invalid email", []string{"token", "teacher"}, []string{`"teacher" is not an email`}},
+		{"token invalid ttl", []string{"token", "-ttl", "-1h", "t1@gmail.com"}, []string{"ttl must be positive"}},
+	}
+
+	for _, v := range tests {
+		cli, stdout, stderr := testCLI(t, "")
+		assert.Equal(t, admin.EXIT_USAGE, cli.Run(context.Background(), v.args), v.name)
+		assert.Empty(t, stdout.String(), v.name)
+		for _, e := range v.errors {
+			assert.Contains(t, stderr.String(), e, v.name)
+		}
+		assert.Contains(t, stderr.String(), "usage: "+v.args[0], v.name)
+	}
+}
+
+// Tests that errors of the DB fail the command.
+func AdminDatabaseErrors(t *testing.T) {
+	for _, v := range [][]string{
+		{"register", "-teacher", "t1@gmail.com", "s1@gmail.com"},
+		{"suspend", "s1@gmail.com"},
+		{"list", "-o", "json", "teaches"},
+		{"export", "notifications"},
+	} {
+		cli, _, stderr := testCLI(t, "")
+		assert.Equal(t, admin.EXIT_FAILURE, cli.Run(context.Background(), v), v[0])
+		assert.Contains(t, stderr.String(), "error: sql: database is closed", v[0])
+	}
+}
+
+// Tests that the report of an import is printed, and that invalid rows fail the import.
+func AdminImport(t *testing.T) {
+	csv := "teacher,student\nt1@gmail.com,student\n"
+
+	cli, stdout, stderr := testCLI(t, csv)
+	assert.Equal(t, admin.EXIT_FAILURE, cli.Run(context.Background(), []string{"import", "-dry-run", "-o", "json"}))
+	assert.Contains(t, stderr.String(), "1 invalid rows were skipped")
+
+	var report response.ImportReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Invalid)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 2, report.Errors[0].Row)
+		assert.Equal(t, "student", report.Errors[0].Field)
+	}
+
+	cli, stdout, _ = testCLI(t, csv)
+	cli.Run(context.Background(), []string{"import", "-"})
+	assert.Equal(t, "IMPORT      COUNT\n"+
+		"rows        1\n"+
+		"registered  0\n"+
+		"existing    0\n"+
+		"invalid     1\n"+
+		"row 2       student: "+messages.FieldErrorMessage(messages.LANGUAGE_EN, messages.FIELD_CODE_EMAIL, "")+"\n", stdout.String())
+}
+
+// Tests that generated schools are printed as CSV which can be imported, and that loading fails on DB errors.
+func AdminSeed(t *testing.T) {
+	args := []string{"seed", "-seed", "7", "-teachers", "3", "-students", "4", "-csv"}
+
+	cli, stdout, _ := testCLI(t, "")
+	assert.Equal(t, admin.EXIT_OK, cli.Run(context.Background(), args))
+
+	school := fixtures.Generate(fixtures.Config{Seed: 7, Teachers: 3, Students: 4,
+		ElectiveRate: fixtures.DEFAULT_ELECTIVE_RATE, SuspendedRate: fixtures.DEFAULT_SUSPENDED_RATE})
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	assert.Equal(t, "teacher,student,class", lines[0])
+	assert.Len(t, lines, 1+len(school.Registrations))
+	assert.Equal(t, school.Registrations[0].Teacher+","+school.Registrations[0].Student+",1A", lines[1])
+
+	// The same seed prints the same school.
+	printed := stdout.String()
+	cli, stdout, _ = testCLI(t, "")
+	cli.Run(context.Background(), args)
+	assert.Equal(t, printed, stdout.String())
+
+	// The printed school is imported without invalid rows.
+	report, err := imports.Registrations(context.Background(), strings.NewReader(printed), &testApplier{}, imports.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, imports.Report{Rows: len(school.Registrations), Registered: len(school.Registrations)}, report)
+
+	cli, _, stderr := testCLI(t, "")
+	assert.Equal(t, admin.EXIT_FAILURE, cli.Run(context.Background(), []string{"seed"}))
+	assert.Contains(t, stderr.String(), "error: sql: database is closed")
+}
+
+// Tests that tokens are issued which authenticate their email until they expire, and only with a token secret.
+func AdminToken(t *testing.T) {
+	signer := auth.NewSigner(testSecret)
+
+	cli, stdout, _ := testCLI(t, "")
+	cli.Tokens = signer
+	assert.Equal(t, admin.EXIT_OK, cli.Run(context.Background(), []string{"token", "-ttl", "1h", "-o", "json", "t1@gmail.com"}))
+
+	var result struct {
+		Subject   string    `json:"subject"`
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "t1@gmail.com", result.Subject)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), result.ExpiresAt, time.Minute)
+	assert.NoError(t, signer.Verify("t1@gmail.com", result.Token, time.Now()))
+	assert.ErrorIs(t, signer.Verify("t1@gmail.com", result.Token, result.ExpiresAt), auth.ErrExpiredToken)
+	assert.ErrorIs(t, signer.Verify("t2@gmail.com", result.Token, time.Now()), auth.ErrInvalidToken)
+
+	// Tables show the same token.
+	cli, stdout, _ = testCLI(t, "")
+	cli.Tokens = signer
+	assert.Equal(t, admin.EXIT_OK, cli.Run(context.Background(), []string{"token", "s1@gmail.com"}))
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if assert.Len(t, lines, 2) {
+		fields := strings.Fields(lines[1])
+		assert.Equal(t, "s1@gmail.com", fields[0])
+		assert.NoError(t, signer.Verify("s1@gmail.com", fields[1], time.Now()))
+	}
+
+	cli, stdout, stderr := testCLI(t, "")
+	assert.Equal(t, admin.EXIT_FAILURE, cli.Run(context.Background(), []string{"token", "t1@gmail.com"}))
+	assert.Contains(t, stderr.String(), "error: the token secret is not set (set AUTH_TOKEN_SECRET)")
+	assert.Empty(t, stdout.String())
+}
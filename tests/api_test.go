@@ -2,25 +2,39 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 
+	"govtech/pkg/admin"
 	"govtech/pkg/controllers"
+	"govtech/pkg/events"
+	"govtech/pkg/fixtures"
 	"govtech/pkg/models/request"
+	"govtech/pkg/models/response"
 	"govtech/pkg/server/databases"
+	"govtech/pkg/server/gql"
 	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
 	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+	"govtech/pkg/webhooks"
 )
 
 var dsn string
@@ -38,16 +52,48 @@ func init() {
 		Host:     os.Getenv("DB_HOST"),
 		Name:     os.Getenv("DB_TEST_NAME"),
 	}
-	dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", config.User, config.Password,
+	dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", config.User, config.Password,
 		config.Host, config.Port, config.Name)
 }
 
-// Run all tests.
+// Asserts that the response is a problem with the given code and field errors.
+func assertProblem(t *testing.T, rr *httptest.ResponseRecorder, code string, fields ...string) {
+	var problem response.Problem
+	err := json.Unmarshal(rr.Body.Bytes(), &problem)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, problems.MIME_PROBLEM_JSON, rr.Header().Get("Content-Type"))
+	assert.Equal(t, rr.Code, problem.Status)
+	assert.Equal(t, code, problem.Code)
+
+	errorFields := make([]string, 0, len(problem.Errors))
+	for _, v := range problem.Errors {
+		errorFields = append(errorFields, v.Field)
+	}
+	assert.ElementsMatch(t, fields, errorFields)
+}
+
+// Run all tests against the MySQL DB of "DB_TEST_NAME" if "TEST_MYSQL" is true, or skip them.
 func TestEndPoints(t *testing.T) {
+	if os.Getenv("TEST_MYSQL") != "true" {
+		t.Skip("TEST_MYSQL is not true; the endpoints are tested over the memory store by TestHarness")
+	}
+	if os.Getenv("DB_TEST_NAME") == "" {
+		t.Fatal("TEST_MYSQL is true but DB_TEST_NAME is not set")
+	}
+
 	t.Run("suspend endpoint", Suspend)
 	t.Run("commonstudents endpoint", CommonStudents)
 	t.Run("retrievefornotifications endpoint", RetrieveForNotification)
 	t.Run("register endpoint", Register)
+	t.Run("graphql endpoint", GraphQLEndpoint)
+	t.Run("teacher events", TeacherEvents)
+	t.Run("webhooks endpoint", WebhooksEndpoint)
+	t.Run("import and export endpoints", ImportExportEndpoints)
+	t.Run("admin cli", AdminCLI)
+	t.Run("fixtures", Fixtures)
 }
 
 // Tests for "/api/suspend" endpoint.
@@ -59,7 +105,9 @@ func Suspend(t *testing.T) {
 	}
 	defer db.Close()
 
-	database.InitTestDB(db)
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 	_, err = db.Exec(`INSERT INTO students VALUES ("test@gmail.com", 0)`)
 	if err != nil {
 		t.Fatal(err.Error())
@@ -67,6 +115,7 @@ func Suspend(t *testing.T) {
 
 	// Init router and middlewares.
 	r := gin.Default()
+	middlewares.RegisterProblemMiddleware(r)
 	handlers.RegisterMiddlewares(r, db)
 	r.POST("/api/suspend", controllers.Suspend)
 
@@ -109,10 +158,12 @@ func Suspend(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":{"`+messages.MESSAGE_MISSING_PARAMS+`":{"student":"required"}}}`, rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student")
 
 	// Clean up DB.
-	database.CleanupTestDB(db)
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 	db.Close()
 }
 
@@ -124,7 +175,9 @@ func CommonStudents(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer db.Close()
-	database.InitTestDB(db)
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 
 	_, err = db.Exec(`INSERT INTO students VALUES ("student1@gmail.com", 0)`)
 	if err != nil {
@@ -173,6 +226,7 @@ func CommonStudents(t *testing.T) {
 
 	// Init router and middleware.
 	r := gin.Default()
+	middlewares.RegisterProblemMiddleware(r)
 	handlers.RegisterMiddlewares(r, db)
 	r.GET("/api/commonstudents", controllers.CommonStudents)
 
@@ -225,10 +279,12 @@ func CommonStudents(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"`+messages.MissingQueryParamsMessage([]string{"teacher"})+"\"}", rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teacher")
 
 	// Clean up DB.
-	database.CleanupTestDB(db)
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 	db.Close()
 }
 
@@ -240,7 +296,9 @@ func RetrieveForNotification(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer db.Close()
-	database.InitTestDB(db)
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 
 	_, err = db.Exec(`INSERT INTO teachers VALUES ("teacher@gmail.com")`)
 	if err != nil {
@@ -279,8 +337,10 @@ func RetrieveForNotification(t *testing.T) {
 
 	// Init router and middleware.
 	r := gin.Default()
+	middlewares.RegisterProblemMiddleware(r)
 	handlers.RegisterMiddlewares(r, db)
 	r.POST("/api/retrievefornotifications", controllers.RetrieveForNotifications)
+	r.POST("/api/v2/retrievefornotifications", controllers.RetrieveForNotificationsV2)
 
 	// Test for POST.
 
@@ -314,6 +374,15 @@ func RetrieveForNotification(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.Equal(t, `{"recipient":["nottagged@gmail.com","tagged1@gmail.com","tagged2@gmail.com"]}`, rr.Body.String())
 
+	// Test for valid request body to v2.
+	// Should get status code 200 and the students as "recipients".
+	req, _ = http.NewRequest("POST", `/api/v2/retrievefornotifications`, bytes.NewBuffer(jsonValue))
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"recipients":["nottagged@gmail.com","tagged1@gmail.com","tagged2@gmail.com"]}`, rr.Body.String())
+
 	// Negative cases.
 
 	// Test for wrong teacher field format.
@@ -339,7 +408,7 @@ func RetrieveForNotification(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":{"`+messages.MESSAGE_MISSING_PARAMS+`":{"teacher":"max=60"}}}`, rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teacher")
 
 	// Test for too long notification field > 200.
 	// Should get status code 400 and error message.
@@ -356,7 +425,7 @@ func RetrieveForNotification(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":{"`+messages.MESSAGE_MISSING_PARAMS+`":{"notification":"max=200"}}}`, rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "notification")
 
 	// Test for missing teacher field.
 	// Should get status code 400 and error message.
@@ -369,7 +438,7 @@ func RetrieveForNotification(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":{"`+messages.MESSAGE_MISSING_PARAMS+`":{"teacher":"required"}}}`, rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teacher")
 
 	// Test for missing notification field.
 	// Should get status code 400 and error message.
@@ -382,7 +451,7 @@ func RetrieveForNotification(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":{"`+messages.MESSAGE_MISSING_PARAMS+`":{"notification":"required"}}}`, rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "notification")
 
 	// Test for invalid notification field format.
 	// Should get status code 400 and error message.
@@ -396,10 +465,12 @@ func RetrieveForNotification(t *testing.T) {
 	r.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"`+messages.InvalidParamsMessage([]string{"notification"})+`"}`, rr.Body.String())
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "notification")
 
 	// Clean up DB.
-	database.CleanupTestDB(db)
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 	db.Close()
 }
 
@@ -411,10 +482,13 @@ func Register(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer db.Close()
-	database.InitTestDB(db)
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 
 	// Init router and middleware.
 	r := gin.Default()
+	middlewares.RegisterProblemMiddleware(r)
 	handlers.RegisterMiddlewares(r, db)
 	r.POST("/api/register", controllers.Register)
 
@@ -448,8 +522,10 @@ func Register(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, rr.Code)
 
-	// Test for request body with valid pair of teacher and students, and student and teachers.
-	// Should return status code 204.
+	// Negative Cases.
+
+	// Test for request body with both pairs of teacher and students, and student and teachers.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Teacher:  "teacher3@gmail.com",
 		Students: []string{"student31@gmail.com", "student32@gmail.com"},
@@ -461,12 +537,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusNoContent, rr.Code)
-
-	// Negative Cases.
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student", "teachers")
 
 	// Test for invalid pair of teacher and students(missing).
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Teacher: "onlyteacher@gmail.com",
 	}
@@ -475,11 +550,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"Both fields teacher and students must be present and valid"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "students")
 
 	// Test for invalid pair of teacher(missing) and students.
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Students: []string{"onlystudent1@gmail.com", "onlystudent2@gmail.com"},
 	}
@@ -488,11 +563,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"Both fields teacher and students must be present and valid"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teacher")
 
 	// Test for invalid pair of student and teachers(missing).
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Student: "onlystudent@gmail.com",
 	}
@@ -501,11 +576,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"Both fields student and teachers must be present and valid"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teachers")
 
 	// Test for invalid pair of student(missing) and teachers.
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Teachers: []string{"onlystudent1@gmail.com", "onlystudent2@gmail.com"},
 	}
@@ -514,11 +589,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"Both fields student and teachers must be present and valid"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student")
 
 	// Test for wrong teacher email format.
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Teacher:  "wrong@format",
 		Students: []string{"s1@gmail.com", "s2@gmail.com"},
@@ -528,10 +603,10 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"`+messages.INVALID_TEACHER_EMAIL_FORMAT+`"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teacher")
 	// Test for one wrong student email format.
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Teacher:  "t1@gmail.com",
 		Students: []string{"wrong@format", "s2@gmail.com"},
@@ -541,11 +616,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"`+messages.INVALID_STUDENT_EMAIL_FORMAT+`"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "students[0]")
 
 	// Test for wrong student email format.
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Student:  "wrong.format",
 		Teachers: []string{"t1@gmail.com", "t2@gmail.com"},
@@ -555,11 +630,11 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"`+messages.INVALID_STUDENT_EMAIL_FORMAT+`"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student")
 
 	// Test for one wrong teacher email format.
-	// Should return status code 400 and error response.
+	// Should return status code 422 and error response.
 	payload = request.RegisterRequest{
 		Student:  "s1@gmail.com",
 		Teachers: []string{"t1@gmail.com", "wrongforma.t"},
@@ -569,10 +644,559 @@ func Register(t *testing.T) {
 	rr = httptest.NewRecorder()
 	r.ServeHTTP(rr, req)
 
-	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	assert.Equal(t, `{"message":"`+messages.INVALID_TEACHER_EMAIL_FORMAT+`"}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "teachers[1]")
 
 	// Clean up DB.
-	database.CleanupTestDB(db)
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
 	db.Close()
 }
+
+// Tests for "/graphql" endpoint.
+func GraphQLEndpoint(t *testing.T) {
+	// Init DB.
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	store := database.NewStore(db)
+	err = store.RegisterStudents(context.Background(), "teacher1@gmail.com",
+		[]string{"student1@gmail.com", "common@gmail.com"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = store.RegisterStudents(context.Background(), "teacher2@gmail.com",
+		[]string{"student2@gmail.com", "common@gmail.com"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.SuspendStudent(context.Background(), "student1@gmail.com")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Init router and middleware.
+	r := graphQLRouter(db, gql.Limits{MaxDepth: 6, MaxComplexity: 1000})
+
+	// Test for the students of a teacher and their other teachers.
+	// Should return the relationships sorted by email.
+	rr := graphQLRequest(r, `{ teacher(email: "teacher1@gmail.com") {
+		email
+		students { email suspended teachers { email } }
+		class { size }
+	} }`, "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data": {"teacher": {
+		"email": "teacher1@gmail.com",
+		"students": [
+			{"email": "common@gmail.com", "suspended": false, "teachers": [{"email": "teacher1@gmail.com"}, {"email": "teacher2@gmail.com"}]},
+			{"email": "student1@gmail.com", "suspended": true, "teachers": [{"email": "teacher1@gmail.com"}]}
+		],
+		"class": {"size": 2}
+	}}}`, rr.Body.String())
+
+	// Test for a teacher who does not exist.
+	// Should return null.
+	rr = graphQLRequest(r, `{ teacher(email: "nobody@gmail.com") { email } }`, "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data": {"teacher": null}}`, rr.Body.String())
+
+	// Test for a notification mentioning a student of another teacher.
+	// Should return the students who can receive it.
+	rr = graphQLRequest(r, `{ notification(teacher: "teacher1@gmail.com", text: "Hello @student2@gmail.com") {
+		recipients { email }
+	} }`, "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data": {"notification": {"recipients": [
+		{"email": "common@gmail.com"}, {"email": "student2@gmail.com"}
+	]}}}`, rr.Body.String())
+
+	// Clean up DB.
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// Tests for the events written to the outbox by changes, and published to teachers by the dispatcher.
+func TeacherEvents(t *testing.T) {
+	// Init DB.
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ctx := context.Background()
+	store := database.NewStore(db)
+	service := services.New(store)
+	dispatcher := events.NewDispatcher(store, events.DispatcherConfig{
+		PollInterval: time.Second, BatchSize: 10, Lease: time.Minute,
+		RetryBackoff: time.Second, MaxRetryBackoff: time.Minute, MaxAttempts: 10,
+	})
+	dispatcher.Subscribe(services.SUBSCRIBER_TEACHER_EVENTS, service.PublishTeacherEvent)
+
+	subscription := service.SubscribeTeacherEvents("teacher1@gmail.com")
+	defer subscription.Close()
+
+	// Dispatches the events of the outbox, and returns the number of events.
+	dispatch := func() int {
+		n, err := dispatcher.Dispatch(ctx)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return n
+	}
+
+	// Registration of students to the teacher.
+	// Should publish the registered students once dispatched.
+	err = service.RegisterStudents(ctx, "teacher1@gmail.com", []string{"student1@gmail.com", "student2@gmail.com"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Len(t, subscription.Events(), 0)
+	assert.Equal(t, 1, dispatch())
+
+	event := <-subscription.Events()
+	assert.Equal(t, events.TYPE_STUDENT_REGISTERED, event.Type)
+	assert.Equal(t, []string{"student1@gmail.com", "student2@gmail.com"}, event.Students)
+
+	// Registration of students who are already registered.
+	// Should not write an event.
+	err = service.RegisterStudents(ctx, "teacher1@gmail.com", []string{"student1@gmail.com"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 0, dispatch())
+
+	// Suspension of a student of the teacher.
+	// Should publish the suspended student to the teacher.
+	err = service.Suspend(ctx, "student1@gmail.com")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 1, dispatch())
+
+	event = <-subscription.Events()
+	assert.Equal(t, events.TYPE_STUDENT_SUSPENDED, event.Type)
+	assert.Equal(t, []string{"teacher1@gmail.com"}, event.Teachers)
+	assert.Equal(t, []string{"student1@gmail.com"}, event.Students)
+
+	// Unsuspension of the student, twice.
+	// Should publish the unsuspended student to the teacher once.
+	for i := 0; i < 2; i++ {
+		if err := service.Unsuspend(ctx, "student1@gmail.com"); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	assert.Equal(t, 1, dispatch())
+
+	event = <-subscription.Events()
+	assert.Equal(t, events.TYPE_STUDENT_UNSUSPENDED, event.Type)
+	assert.Equal(t, []string{"student1@gmail.com"}, event.Students)
+
+	err = service.Suspend(ctx, "student1@gmail.com")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 1, dispatch())
+	<-subscription.Events()
+
+	// Deregistration of a student of the teacher and a student of no teacher.
+	// Should publish the student of the teacher only.
+	removed, err := service.DeregisterStudents(ctx, "teacher1@gmail.com", []string{"student2@gmail.com", "nobody@gmail.com"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"student2@gmail.com"}, removed)
+	assert.Equal(t, 1, dispatch())
+
+	event = <-subscription.Events()
+	assert.Equal(t, events.TYPE_STUDENT_DEREGISTERED, event.Type)
+	assert.Equal(t, []string{"student2@gmail.com"}, event.Students)
+
+	// Notification mentioning a student.
+	// Should write the notification to the outbox without publishing it to teachers.
+	_, err = service.RetrieveForNotifications(ctx, "teacher1@gmail.com", "Hello @student2@gmail.com")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 1, dispatch())
+	assert.Len(t, subscription.Events(), 0)
+
+	var pending int
+	err = db.QueryRow(`SELECT COUNT(*) FROM outbox WHERE dispatched_at IS NULL`).Scan(&pending)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, 0, pending)
+
+	// Event of a subscriber which always fails.
+	// Should be dead after the last attempt, and no longer claimed.
+	dead := events.NewDispatcher(store, events.DispatcherConfig{
+		PollInterval: time.Second, BatchSize: 10, Lease: time.Minute,
+		RetryBackoff: time.Second, MaxRetryBackoff: time.Minute, MaxAttempts: 1,
+	})
+	dead.Subscribe("broken", func(ctx context.Context, event events.Event) error {
+		return errors.New("unavailable")
+	})
+	if err := service.Unsuspend(ctx, "student1@gmail.com"); err != nil {
+		t.Fatal(err.Error())
+	}
+	n, err := dead.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = store.CountDeadEvents(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 0, dispatch())
+
+	// Clean up DB.
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// Tests for "/api/v2/webhooks" endpoints, and the delivery of events to registered webhooks.
+func WebhooksEndpoint(t *testing.T) {
+	// Init DB.
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Init router and middleware.
+	r := gin.Default()
+	middlewares.RegisterProblemMiddleware(r)
+	handlers.RegisterMiddlewares(r, db)
+	middlewares.RegisterAuthMiddleware(r, &middlewares.AuthConfig{AdminKey: testAdminKey})
+	controllers.RegisterWebhookEndpoints(r.Group(handlers.API_V2_PREFIX))
+
+	// The receiver is on the loopback address, which is refused by default.
+	controllers.UseWebhookPingClient(webhooks.NewClient(webhooks.PING_TIMEOUT, true))
+	defer controllers.UseWebhookPingClient(webhooks.NewClient(webhooks.PING_TIMEOUT, false))
+
+	receiver := &testReceiver{secret: testWebhookSecret}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	serve := func(method string, path string, body any) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Authorization", "Bearer "+testAdminKey)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Registration of a webhook for notifications.
+	// Should return status code 201 and the webhook without its secret.
+	rr := serve("POST", "/api/v2/webhooks", request.CreateWebhookRequest{
+		URL: server.URL, Events: []string{events.TYPE_NOTIFICATION_ISSUED}, Secret: testWebhookSecret,
+	})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotContains(t, rr.Body.String(), testWebhookSecret)
+
+	var webhook response.Webhook
+	json.Unmarshal(rr.Body.Bytes(), &webhook)
+	assert.Equal(t, server.URL, webhook.URL)
+	assert.Equal(t, []string{events.TYPE_NOTIFICATION_ISSUED}, webhook.Events)
+
+	path := "/api/v2/webhooks/" + fmt.Sprint(webhook.ID)
+	assert.Equal(t, path, rr.Header().Get("Location"))
+
+	rr = serve("GET", path, nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = serve("GET", "/api/v2/webhooks/999", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assertProblem(t, rr, messages.CODE_NOT_FOUND)
+
+	// Ping of the webhook.
+	// Should return the succeeded delivery with its attempt.
+	rr = serve("POST", path+"/ping", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var delivery response.WebhookDelivery
+	json.Unmarshal(rr.Body.Bytes(), &delivery)
+	assert.Equal(t, webhooks.TYPE_PING, delivery.EventType)
+	assert.Equal(t, webhooks.STATUS_SUCCEEDED, delivery.Status)
+	assert.Len(t, delivery.Attempts, 1)
+
+	// Delivery of the events of the outbox.
+	// Should deliver the notification only, signed with the secret.
+	ctx := context.Background()
+	store := database.NewStore(db)
+	service := services.New(store)
+	dispatcher := events.NewDispatcher(store, events.DispatcherConfig{
+		PollInterval: time.Second, BatchSize: 10, Lease: time.Minute,
+		RetryBackoff: time.Second, MaxRetryBackoff: time.Minute, MaxAttempts: 10,
+	})
+	deliverer := webhooks.NewDeliverer(store, server.Client(), webhooksConfig)
+	dispatcher.Subscribe(webhooks.SUBSCRIBER, deliverer.Handle)
+
+	if err := service.RegisterStudents(ctx, "teacher1@gmail.com", []string{"student1@gmail.com"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := service.RetrieveForNotifications(ctx, "teacher1@gmail.com", "Hello"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	n, err := dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 0, receiver.invalid)
+	if assert.Len(t, receiver.received, 2) {
+		assert.Equal(t, events.TYPE_NOTIFICATION_ISSUED, receiver.received[1].Type)
+	}
+
+	// Listing of the deliveries of the webhook.
+	// Should return the notification and the ping, latest first.
+	rr = serve("GET", path+"/deliveries", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var deliveries struct {
+		Deliveries []response.WebhookDelivery `json:"deliveries"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &deliveries)
+	if assert.Len(t, deliveries.Deliveries, 2) {
+		assert.Equal(t, events.TYPE_NOTIFICATION_ISSUED, deliveries.Deliveries[0].EventType)
+		assert.Equal(t, webhooks.STATUS_SUCCEEDED, deliveries.Deliveries[0].Status)
+		assert.Len(t, deliveries.Deliveries[0].Attempts, 1)
+	}
+
+	// Deletion of the webhook.
+	// Should return 204, then 404.
+	rr = serve("DELETE", path, nil)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = serve("DELETE", path, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Clean up DB.
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// Tests registrations imported from CSV and exported again.
+func ImportExportEndpoints(t *testing.T) {
+	// Init DB.
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Init router and middleware.
+	r := gin.Default()
+	middlewares.RegisterProblemMiddleware(r)
+	handlers.RegisterMiddlewares(r, db)
+	controllers.RegisterImportEndpoint(r.Group(handlers.API_V2_PREFIX))
+	controllers.RegisterExportEndpoints(r.Group(handlers.API_V2_PREFIX))
+
+	serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	csv := "teacher,student,class\n" +
+		"teacherken@gmail.com,studentjon@gmail.com,4A\n" +
+		"teacherken@gmail.com,studenthon@gmail.com\n" +
+		"teacherjoe@gmail.com,studentjon@gmail.com,5B\n" +
+		"teacherjoe@gmail.com,student\n"
+
+	// Dry run of the import.
+	// Should report the registrations without writing them.
+	rr := serve("POST", "/api/v2/import/registrations?dry_run=true", csv)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var report response.ImportReport
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	assert.Equal(t, 3, report.Registered)
+	assert.Equal(t, 1, report.Invalid)
+
+	rr = serve("GET", "/api/v2/export/teaches", "")
+	assert.Equal(t, "teacher,student,class,suspended\n", rr.Body.String())
+
+	// Import, then import again.
+	// Should register the valid rows once.
+	rr = serve("POST", "/api/v2/import/registrations", csv)
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	assert.Equal(t, 3, report.Registered)
+
+	rr = serve("POST", "/api/v2/import/registrations", csv)
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	assert.Equal(t, 0, report.Registered)
+	assert.Equal(t, 3, report.Existing)
+
+	// Export of the registrations of a class, and of the students of a teacher.
+	// Should return the rows matching the filters as attachments.
+	rr = serve("GET", "/api/v2/export/teaches?class=4A", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `attachment; filename="teaches.csv"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "teacher,student,class,suspended\nteacherken@gmail.com,studentjon@gmail.com,4A,false\n", rr.Body.String())
+
+	rr = serve("GET", "/api/v2/export/students?teacher=teacherken@gmail.com&format=ndjson", "")
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `{"email":"studenthon@gmail.com","suspended":false}`+"\n"+
+		`{"email":"studentjon@gmail.com","suspended":false}`+"\n", rr.Body.String())
+
+	rr = serve("GET", "/api/v2/export/teachers?suspended=true", "")
+	assert.Equal(t, "email\n", rr.Body.String())
+
+	// Clean up DB.
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// Tests the commands of the admin CLI against the DB.
+func AdminCLI(t *testing.T) {
+	// Init DB.
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var stdout, stderr bytes.Buffer
+	cli := &admin.CLI{Service: services.New(database.NewStore(db)), Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: &stderr}
+
+	// Runs the command, which must succeed, and returns its output.
+	run := func(args ...string) string {
+		stdout.Reset()
+		stderr.Reset()
+		if code := cli.Run(context.Background(), args); code != admin.EXIT_OK {
+			t.Fatalf("%v exited with %d: %s", args, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	run("register", "-teacher", "teacherken@gmail.com", "studentjon@gmail.com", "studenthon@gmail.com")
+	run("register", "-student", "studentjon@gmail.com", "teacherjoe@gmail.com")
+
+	// Common students, as JSON.
+	assert.JSONEq(t, `{"students": ["studentjon@gmail.com"]}`, run("common", "-o", "json", "teacherken@gmail.com", "teacherjoe@gmail.com"))
+
+	// Suspension and unsuspension.
+	run("suspend", "studenthon@gmail.com")
+	assert.Equal(t, "EMAIL                SUSPENDED\nstudenthon@gmail.com  true\n", run("list", "-suspended", "true", "students"))
+
+	run("unsuspend", "studenthon@gmail.com")
+	assert.Equal(t, "EMAIL  SUSPENDED\n", run("list", "-suspended", "true", "students"))
+
+	// Suspension of a student who does not exist.
+	// Should fail.
+	assert.Equal(t, admin.EXIT_FAILURE, cli.Run(context.Background(), []string{"suspend", "nobody@gmail.com"}))
+	assert.Contains(t, stderr.String(), "does not exist")
+
+	// Notification and deregistration.
+	assert.JSONEq(t, `{"recipients": ["studenthon@gmail.com", "studentjon@gmail.com"]}`,
+		run("notify", "-o", "json", "-teacher", "teacherken@gmail.com", "Hello"))
+	assert.JSONEq(t, `{"teacher": "teacherken@gmail.com", "students": ["studenthon@gmail.com"]}`,
+		run("deregister", "-o", "json", "-teacher", "teacherken@gmail.com", "studenthon@gmail.com", "nobody@gmail.com"))
+
+	// Export.
+	assert.Equal(t, "teacher,student,class,suspended\n"+
+		"teacherjoe@gmail.com,studentjon@gmail.com,,false\n"+
+		"teacherken@gmail.com,studentjon@gmail.com,,false\n", run("export", "teaches"))
+
+	// Clean up DB.
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// Tests that a generated school is written to the DB.
+func Fixtures(t *testing.T) {
+	// Init DB.
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := database.InitTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ctx := context.Background()
+	store := database.NewStore(db)
+	school := fixtures.Generate(fixtures.Config{Seed: 1, Teachers: 12, Students: 90, ElectiveRate: 0.2, SuspendedRate: 0.1})
+
+	// Load of the school, twice.
+	// Should register the students once.
+	for _, expected := range []int{len(school.Registrations), 0} {
+		registered, err := fixtures.Load(ctx, store, school)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, expected, registered)
+	}
+
+	// The students common to the teachers of a class are the students of the class.
+	var teachers, students []string
+	for _, v := range school.Registrations {
+		if v.Class != "1A" {
+			continue
+		}
+		if !slices.Contains(teachers, v.Teacher) {
+			teachers = append(teachers, v.Teacher)
+		}
+		if !slices.Contains(students, v.Student) {
+			students = append(students, v.Student)
+		}
+	}
+	common, err := store.CommonStudents(ctx, teachers)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.ElementsMatch(t, students, common)
+
+	suspended := 0
+	err = store.ExportStudents(ctx, database.ExportFilter{}, func(v database.Student) error {
+		if v.Suspended {
+			suspended++
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, len(school.Suspended()), suspended)
+
+	// Clean up DB.
+	if err := database.CleanupTestDB(db); err != nil {
+		t.Fatal(err.Error())
+	}
+}
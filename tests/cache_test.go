@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/events"
+	"govtech/pkg/server/cache"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/metrics"
+	"govtech/pkg/services"
+)
+
+// Structure for a store counting the lookups of rosters and common students.
+type countingStore struct {
+	database.Store
+	rosters        int
+	commonStudents int
+}
+
+func (s *countingStore) ActiveStudentsOf(ctx context.Context, teacher string) ([]string, error) {
+	s.rosters++
+	return s.Store.ActiveStudentsOf(ctx, teacher)
+}
+
+func (s *countingStore) CommonStudents(ctx context.Context, teachers []string) ([]string, error) {
+	s.commonStudents++
+	return s.Store.CommonStudents(ctx, teachers)
+}
+
+// Store whose writes are not seen by the hooks of the service, as if written by another process.
+type otherProcessStore struct {
+	database.Store
+}
+
+func (s *otherProcessStore) OnEventsWritten(hook func([]events.Event)) {}
+
+// Returns a loader of the value, counting its calls.
+func countingLoader(value string, calls *int) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		*calls++
+		return value, nil
+	}
+}
+
+// Tests for caching lookups.
+func TestCache(t *testing.T) {
+	t.Run("lru", CacheLRU)
+	t.Run("ttl", CacheTTL)
+	t.Run("invalidation", CacheInvalidation)
+	t.Run("service", CacheService)
+	t.Run("other processes", CacheOtherProcesses)
+}
+
+// Tests that values are loaded once, and that the least recently used value is evicted.
+func CacheLRU(t *testing.T) {
+	c := cache.NewLRU[string]("test_lru", cache.Config{Size: 2, TTL: time.Minute})
+	ctx := context.Background()
+	calls := 0
+
+	for i := 0; i < 2; i++ {
+		value, err := c.Load(ctx, "a", nil, countingLoader("A", &calls))
+		assert.NoError(t, err)
+		assert.Equal(t, "A", value)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CacheLookups.WithLabelValues("test_lru", cache.RESULT_HIT)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.CacheLookups.WithLabelValues("test_lru", cache.RESULT_MISS)))
+
+	// "a" is used after "b", so "b" is evicted to cache "c".
+	c.Load(ctx, "b", nil, countingLoader("B", &calls))
+	c.Load(ctx, "a", nil, countingLoader("A", &calls))
+	c.Load(ctx, "c", nil, countingLoader("C", &calls))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 2, c.Len())
+
+	c.Load(ctx, "a", nil, countingLoader("A", &calls))
+	assert.Equal(t, 3, calls)
+	c.Load(ctx, "b", nil, countingLoader("B", &calls))
+	assert.Equal(t, 4, calls)
+
+	// Values which fail to load are not cached.
+	_, err := c.Load(ctx, "d", nil, func(context.Context) (string, error) {
+		return "", errors.New("failed")
+	})
+	assert.Error(t, err)
+	c.Load(ctx, "d", nil, countingLoader("D", &calls))
+	assert.Equal(t, 5, calls)
+}
+
+// Tests that values are loaded again once their TTL ends.
+func CacheTTL(t *testing.T) {
+	c := cache.NewLRU[string]("test_ttl", cache.Config{Size: 10, TTL: 20 * time.Millisecond})
+	ctx := context.Background()
+	calls := 0
+
+	c.Load(ctx, "a", nil, countingLoader("A", &calls))
+	c.Load(ctx, "a", nil, countingLoader("A", &calls))
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(50 * time.Millisecond)
+
+	c.Load(ctx, "a", nil, countingLoader("A", &calls))
+	assert.Equal(t, 2, calls)
+}
+
+// Tests that only the values with the invalidated tags are loaded again.
+func CacheInvalidation(t *testing.T) {
+	c := cache.NewLRU[string]("test_invalidation", cache.Config{Size: 10, TTL: time.Minute})
+	ctx := context.Background()
+	calls := 0
+
+	c.Load(ctx, "ken", []string{"ken"}, countingLoader("K", &calls))
+	c.Load(ctx, "joe", []string{"joe"}, countingLoader("J", &calls))
+	c.Load(ctx, "ken,joe", []string{"ken", "joe"}, countingLoader("KJ", &calls))
+	assert.Equal(t, 3, calls)
+
+	c.Invalidate("ken")
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.CacheInvalidations.WithLabelValues("test_invalidation")))
+
+	c.Load(ctx, "joe", []string{"joe"}, countingLoader("J", &calls))
+	assert.Equal(t, 3, calls)
+	c.Load(ctx, "ken", []string{"ken"}, countingLoader("K", &calls))
+	assert.Equal(t, 4, calls)
+
+	// Values loaded while the cache is invalidated may be stale, so they are not cached.
+	c.Load(ctx, "amy", []string{"amy"}, func(context.Context) (string, error) {
+		c.Invalidate("amy")
+		return "A", nil
+	})
+	c.Load(ctx, "amy", []string{"amy"}, countingLoader("A", &calls))
+	assert.Equal(t, 5, calls)
+}
+
+// Tests that the lookups of the service are cached until the students of their teachers change.
+func CacheService(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Store: database.NewMemoryStore()}
+	service := services.New(store)
+	service.UseCaches(services.NewCaches(cache.Config{Size: 100, TTL: time.Minute}))
+
+	assert.NoError(t, service.RegisterStudents(ctx, "teacherken@gmail.com", []string{"studentjon@gmail.com", "studenthon@gmail.com"}))
+	assert.NoError(t, service.RegisterStudents(ctx, "teacherjoe@gmail.com", []string{"studentjon@gmail.com"}))
+	assert.NoError(t, service.RegisterStudents(ctx, "teacheramy@gmail.com", []string{"studentamy@gmail.com"}))
+
+	// The same teachers in any order are looked up once.
+	for _, v := range [][]string{
+		{"teacherken@gmail.com", "teacherjoe@gmail.com"},
+		{"teacherjoe@gmail.com", "teacherken@gmail.com", "teacherjoe@gmail.com"},
+	} {
+		students, err := service.CommonStudents(ctx, v)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"studentjon@gmail.com"}, students)
+	}
+	assert.Equal(t, 1, store.commonStudents)
+
+	// Callers get their own copy of cached students.
+	students, _ := service.CommonStudents(ctx, []string{"teacherken@gmail.com"})
+	students[0] = ""
+	students, _ = service.CommonStudents(ctx, []string{"teacherken@gmail.com"})
+	assert.Equal(t, []string{"studenthon@gmail.com", "studentjon@gmail.com"}, students)
+	assert.Equal(t, 2, store.commonStudents)
+
+	recipients := func(teacher string) []string {
+		recipients, err := service.RetrieveForNotifications(ctx, teacher, "Hello")
+		assert.NoError(t, err)
+		return recipients
+	}
+	// The roster of a teacher is looked up once.
+	assert.Equal(t, []string{"studenthon@gmail.com", "studentjon@gmail.com"}, recipients("teacherken@gmail.com"))
+	assert.Equal(t, []string{"studentamy@gmail.com"}, recipients("teacheramy@gmail.com"))
+	recipients("teacherken@gmail.com")
+	assert.Equal(t, 2, store.rosters)
+
+	// Suspension invalidates the lookups of the teachers of the student only.
+	service.CommonStudents(ctx, []string{"teacheramy@gmail.com"})
+	assert.Equal(t, 3, store.commonStudents)
+	assert.NoError(t, service.Suspend(ctx, "studenthon@gmail.com"))
+	assert.Equal(t, []string{"studentjon@gmail.com"}, recipients("teacherken@gmail.com"))
+	recipients("teacheramy@gmail.com")
+	assert.Equal(t, 3, store.rosters)
+	service.CommonStudents(ctx, []string{"teacheramy@gmail.com"})
+	students, _ = service.CommonStudents(ctx, []string{"teacherken@gmail.com"})
+	assert.Equal(t, []string{"studenthon@gmail.com", "studentjon@gmail.com"}, students)
+	assert.Equal(t, 4, store.commonStudents)
+
+	// Registration invalidates the lookups of the teacher.
+	assert.NoError(t, service.RegisterTeachers(ctx, "studentagnes@gmail.com", []string{"teacherjoe@gmail.com", "teacherken@gmail.com"}))
+	students, _ = service.CommonStudents(ctx, []string{"teacherken@gmail.com", "teacherjoe@gmail.com"})
+	assert.Equal(t, []string{"studentagnes@gmail.com", "studentjon@gmail.com"}, students)
+	assert.Equal(t, 5, store.commonStudents)
+
+	// Registration of students already registered changes nothing, so invalidates nothing.
+	assert.NoError(t, service.RegisterStudents(ctx, "teacherken@gmail.com", []string{"studentjon@gmail.com"}))
+	service.CommonStudents(ctx, []string{"teacherken@gmail.com", "teacherjoe@gmail.com"})
+	assert.Equal(t, 5, store.commonStudents)
+
+	// Deregistration invalidates the lookups of the teacher.
+	_, err := service.DeregisterStudents(ctx, "teacherjoe@gmail.com", []string{"studentjon@gmail.com"})
+	assert.NoError(t, err)
+	students, _ = service.CommonStudents(ctx, []string{"teacherken@gmail.com", "teacherjoe@gmail.com"})
+	assert.Equal(t, []string{"studentagnes@gmail.com"}, students)
+	assert.Equal(t, 6, store.commonStudents)
+
+	// Notifications change no student, so invalidate nothing.
+	recipients("teacheramy@gmail.com")
+	service.CommonStudents(ctx, []string{"teacheramy@gmail.com"})
+	assert.Equal(t, 3, store.rosters)
+	assert.Equal(t, 6, store.commonStudents)
+}
+
+// Tests that the changes written by other processes invalidate the caches once dispatched from the outbox.
+func CacheOtherProcesses(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	service := services.New(&otherProcessStore{Store: store})
+	service.UseCaches(services.NewCaches(cache.Config{Size: 100, TTL: time.Minute}))
+
+	dispatcher := events.NewDispatcher(store, events.DispatcherConfig{
+		PollInterval: time.Second, BatchSize: 100, Lease: time.Minute,
+		RetryBackoff: time.Second, MaxRetryBackoff: time.Minute, MaxAttempts: 10,
+	})
+	dispatcher.Subscribe(services.SUBSCRIBER_CACHES, service.InvalidateCaches)
+
+	assert.NoError(t, store.RegisterStudents(ctx, "teacherken@gmail.com", []string{"studentjon@gmail.com"}))
+	dispatcher.Dispatch(ctx)
+	students, _ := service.CommonStudents(ctx, []string{"teacherken@gmail.com"})
+	assert.Equal(t, []string{"studentjon@gmail.com"}, students)
+
+	// The change is missed until its event is dispatched.
+	assert.NoError(t, store.RegisterStudents(ctx, "teacherken@gmail.com", []string{"studenthon@gmail.com"}))
+	students, _ = service.CommonStudents(ctx, []string{"teacherken@gmail.com"})
+	assert.Equal(t, []string{"studentjon@gmail.com"}, students)
+
+	_, err := dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	students, _ = service.CommonStudents(ctx, []string{"teacherken@gmail.com"})
+	assert.Equal(t, []string{"studenthon@gmail.com", "studentjon@gmail.com"}, students)
+
+	// Suspensions by other processes are missed by notifications until their event is dispatched.
+	recipients, err := service.RetrieveForNotifications(ctx, "teacherken@gmail.com", "Hello")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"studenthon@gmail.com", "studentjon@gmail.com"}, recipients)
+
+	_, err = store.SuspendStudent(ctx, "studentjon@gmail.com")
+	assert.NoError(t, err)
+	recipients, _ = service.RetrieveForNotifications(ctx, "teacherken@gmail.com", "Hello")
+	assert.Equal(t, []string{"studenthon@gmail.com", "studentjon@gmail.com"}, recipients)
+
+	_, err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	recipients, _ = service.RetrieveForNotifications(ctx, "teacherken@gmail.com", "Hello")
+	assert.Equal(t, []string{"studenthon@gmail.com"}, recipients)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/config"
+)
+
+func TestConfig(t *testing.T) {
+	t.Run("config precedence", ConfigPrecedence)
+	t.Run("config validation", ConfigValidation)
+}
+
+// Tests that flags override env variables, which override the config file.
+func ConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(file, []byte(`
+database:
+  user: fileuser
+  host: filehost
+  name: filename
+  max_open_conns: 10
+router:
+  port: "9000"
+  write_timeout: 1m
+`), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("DB_HOST", "envhost")
+	t.Setenv("DB_NAME", "envname")
+	t.Setenv("DB_USER", "")
+
+	cfg, err := config.Load("test", []string{"-db-name", "flagname", "-enable-metrics=false"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "fileuser", cfg.Database.User)
+	assert.Equal(t, "envhost", cfg.Database.Host)
+	assert.Equal(t, "flagname", cfg.Database.Name)
+	assert.Equal(t, 10, cfg.Database.MaxOpenConns)
+	assert.Equal(t, "9000", cfg.Router.Port)
+	assert.Equal(t, time.Minute, cfg.Router.WriteTimeout.Duration)
+	assert.Equal(t, 5*time.Second, cfg.Router.ReadHeaderTimeout.Duration)
+	assert.Equal(t, false, cfg.Features.Metrics)
+	assert.Equal(t, true, cfg.Features.RateLimit)
+}
+
+// Tests that every invalid value is reported.
+func ConfigValidation(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_USER", "")
+	t.Setenv("DB_NAME", "")
+	t.Setenv("DB_PORT", "not a port")
+	t.Setenv("DB_MAX_OPEN_CONNS", "ten")
+	t.Setenv("LOG_LEVEL", "verbose")
+
+	_, err := config.Load("test", []string{"-tls-cert-file", "cert.pem", "-enable-websocket", "-cache-size", "0"})
+
+	var validationErr *config.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, err.Error(), `DB_MAX_OPEN_CONNS: invalid value "ten"`)
+	assert.Contains(t, err.Error(), "database.user is required (set DB_USER)")
+	assert.Contains(t, err.Error(), "database.name is required (set DB_NAME)")
+	assert.Contains(t, err.Error(), `database.port must be a port number between 0 and 65535, got "not a port"`)
+	assert.Contains(t, err.Error(), "router.tls_cert_file and router.tls_key_file must be set together")
+	assert.Contains(t, err.Error(), "auth.token_secret is required (set AUTH_TOKEN_SECRET)")
+	assert.Contains(t, err.Error(), `log.level must be one of debug, info, warn or error, got "verbose"`)
+	assert.Contains(t, err.Error(), "cache.size must be at least 1")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/server/databases"
+)
+
+func TestDatabase(t *testing.T) {
+	t.Run("dsn", DSN)
+	t.Run("ping with retry", PingWithRetry)
+}
+
+// Tests for building the DSN from the configuration.
+func DSN(t *testing.T) {
+	config := database.MySqlConfig{
+		User:         "user",
+		Password:     "p@ss:word/",
+		Host:         "localhost",
+		Port:         "3306",
+		Name:         "govtech",
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		TLS:          "skip-verify",
+	}
+
+	dsn, err := config.DSN()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "user:p@ss:word/@tcp(localhost:3306)/govtech?parseTime=true&readTimeout=30s&timeout=5s&tls=skip-verify&writeTimeout=30s", dsn)
+
+	// Test for a CA file.
+	// Should use the registered TLS configuration, without reading the file.
+	config.TLSCAFile = "missing.pem"
+	dsn, err = config.DSN()
+	assert.NoError(t, err)
+	assert.Contains(t, dsn, "tls="+database.TLS_CONFIG_CUSTOM)
+
+	// Test for registering a missing CA file, and a CA file without certificates.
+	// Should return an error.
+	assert.NotNil(t, config.RegisterTLSConfig())
+
+	config.TLSCAFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(config.TLSCAFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.ErrorContains(t, config.RegisterTLSConfig(), "no certificates found")
+
+	// Test for no CA file.
+	// Should register nothing.
+	config.TLSCAFile = ""
+	assert.NoError(t, config.RegisterTLSConfig())
+}
+
+// Tests for retrying to reach the DB on startup.
+func PingWithRetry(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:1)/unreachable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	config := database.MySqlConfig{
+		ConnectAttempts:   3,
+		ConnectBackoff:    time.Millisecond,
+		ConnectMaxBackoff: 2 * time.Millisecond,
+	}
+
+	err = database.PingWithRetry(context.Background(), db, &config)
+	assert.ErrorContains(t, err, "failed to connect to database after 3 attempt(s)")
+
+	// Test for cancelled context.
+	// Should stop retrying.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	config.ConnectBackoff = time.Hour
+
+	err = database.PingWithRetry(ctx, db, &config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/events"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/metrics"
+)
+
+// Outbox of events kept in memory.
+type testOutbox struct {
+	mu         sync.Mutex
+	events     []events.Pending
+	delivered  map[int64][]string
+	dispatched map[int64]bool
+	retries    map[int64]time.Time
+	dead       map[int64]bool
+}
+
+func newTestOutbox(list ...events.Event) *testOutbox {
+	outbox := &testOutbox{
+		delivered:  make(map[int64][]string),
+		dispatched: make(map[int64]bool),
+		retries:    make(map[int64]time.Time),
+		dead:       make(map[int64]bool),
+	}
+	for _, v := range list {
+		outbox.events = append(outbox.events, events.Pending{Event: v})
+	}
+
+	return outbox
+}
+
+func (o *testOutbox) ClaimEvents(ctx context.Context, now time.Time, lease time.Time, limit int) ([]events.Pending, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var pending []events.Pending
+	for _, v := range o.events {
+		id := v.Event.ID
+		if o.dispatched[id] || o.dead[id] || o.retries[id].After(now) || len(pending) == limit {
+			continue
+		}
+		v.Delivered = o.delivered[id]
+		pending = append(pending, v)
+	}
+
+	return pending, nil
+}
+
+func (o *testOutbox) MarkDelivered(ctx context.Context, id int64, subscriber string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.delivered[id] = append(o.delivered[id], subscriber)
+	return nil
+}
+
+func (o *testOutbox) MarkDispatched(ctx context.Context, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.dispatched[id] = true
+	return nil
+}
+
+func (o *testOutbox) RetryEvent(ctx context.Context, id int64, retryAt time.Time, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, v := range o.events {
+		if v.Event.ID == id {
+			o.events[i].Attempts++
+		}
+	}
+	o.retries[id] = retryAt
+	return nil
+}
+
+func (o *testOutbox) KillEvent(ctx context.Context, id int64, reason string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.dead[id] = true
+	return nil
+}
+
+func (o *testOutbox) CountDeadEvents(ctx context.Context) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.dead), nil
+}
+
+func (o *testOutbox) isDispatched(id int64) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.dispatched[id]
+}
+
+// Tests for the dispatch of domain events from the outbox.
+func TestEvents(t *testing.T) {
+	t.Run("dispatch", Dispatch)
+	t.Run("retries", Retries)
+	t.Run("dead events", DeadEvents)
+	t.Run("notify", Notify)
+	t.Run("webhook", Webhook)
+}
+
+var dispatcherConfig = events.DispatcherConfig{
+	PollInterval:    time.Hour,
+	BatchSize:       10,
+	Lease:           time.Minute,
+	RetryBackoff:    time.Minute,
+	MaxRetryBackoff: 4 * time.Minute,
+	MaxAttempts:     5,
+}
+
+// Returns an event of the outbox with the given ID.
+func testEvent(id int64, eventType string) events.Event {
+	event := events.New(eventType, []string{"t1@gmail.com"}, []string{"s1@gmail.com"})
+	event.ID = id
+
+	return event
+}
+
+// Tests that events are delivered to every subscriber in order.
+func Dispatch(t *testing.T) {
+	outbox := newTestOutbox(testEvent(1, events.TYPE_STUDENT_REGISTERED), testEvent(2, events.TYPE_STUDENT_SUSPENDED))
+	dispatcher := events.NewDispatcher(outbox, dispatcherConfig)
+
+	var received []int64
+	dispatcher.Subscribe("first", func(ctx context.Context, event events.Event) error {
+		received = append(received, event.ID)
+		return nil
+	})
+	dispatcher.Subscribe("second", func(ctx context.Context, event events.Event) error {
+		return nil
+	})
+
+	n, err := dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{1, 2}, received)
+	assert.True(t, outbox.isDispatched(1))
+	assert.True(t, outbox.isDispatched(2))
+	assert.Equal(t, []string{"first", "second"}, outbox.delivered[1])
+
+	// Dispatched events are not delivered again.
+	n, err = dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+// Tests that failed events are retried with backoff, only to the subscribers which failed.
+func Retries(t *testing.T) {
+	outbox := newTestOutbox(testEvent(1, events.TYPE_NOTIFICATION_ISSUED))
+	dispatcher := events.NewDispatcher(outbox, dispatcherConfig)
+
+	succeeded := 0
+	dispatcher.Subscribe("ok", func(ctx context.Context, event events.Event) error {
+		succeeded++
+		return nil
+	})
+
+	failures := 3
+	dispatcher.Subscribe("flaky", func(ctx context.Context, event events.Event) error {
+		if failures > 0 {
+			failures--
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	dispatcher.Subscribe("panics", func(ctx context.Context, event events.Event) error {
+		if failures > 1 {
+			panic("handler bug")
+		}
+		return nil
+	})
+
+	// The first attempt fails, and the event is retried after the backoff.
+	start := time.Now()
+	_, err := dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, outbox.isDispatched(1))
+	assert.WithinDuration(t, start.Add(time.Minute), outbox.retries[1], 5*time.Second)
+
+	// Retries are delivered to the subscribers which failed only, with the backoff doubled up to the maximum.
+	backoffs := []time.Duration{2 * time.Minute, 4 * time.Minute}
+	for _, v := range backoffs {
+		outbox.retries[1] = time.Time{}
+		start = time.Now()
+		_, err = dispatcher.Dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.WithinDuration(t, start.Add(v), outbox.retries[1], 5*time.Second)
+	}
+
+	outbox.retries[1] = time.Time{}
+	_, err = dispatcher.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, outbox.isDispatched(1))
+	assert.Equal(t, 1, succeeded)
+	assert.ElementsMatch(t, []string{"ok", "panics", "flaky"}, outbox.delivered[1])
+}
+
+// Tests that events are dead once MaxAttempts have failed, and are counted but no longer dispatched.
+func DeadEvents(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	assert.NoError(t, store.RegisterStudents(ctx, "t1@gmail.com", []string{"s1@gmail.com"}))
+	assert.NoError(t, store.RegisterStudents(ctx, "t2@gmail.com", []string{"s1@gmail.com"}))
+
+	config := dispatcherConfig
+	config.RetryBackoff = time.Nanosecond
+	config.MaxRetryBackoff = time.Nanosecond
+	config.MaxAttempts = 3
+	dispatcher := events.NewDispatcher(store, config)
+
+	attempts := map[string]int{}
+	dispatcher.Subscribe("broken", func(ctx context.Context, event events.Event) error {
+		attempts[event.Teachers[0]]++
+		if event.Teachers[0] == "t1@gmail.com" {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	// Every attempt of the first event fails, and it is dead after the last.
+	for i := 0; i < config.MaxAttempts; i++ {
+		time.Sleep(time.Millisecond)
+		_, err := dispatcher.Dispatch(ctx)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, map[string]int{"t1@gmail.com": 3, "t2@gmail.com": 1}, attempts)
+
+	n, err := store.CountDeadEvents(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.NewDeadEventsCollector(store.CountDeadEvents)))
+
+	// Dead events are not claimed again.
+	time.Sleep(time.Millisecond)
+	n, err = dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 3, attempts["t1@gmail.com"])
+}
+
+// Tests that the dispatcher dispatches events without waiting for the next poll once notified.
+func Notify(t *testing.T) {
+	outbox := newTestOutbox()
+	dispatcher := events.NewDispatcher(outbox, dispatcherConfig)
+	dispatcher.Subscribe("noop", func(ctx context.Context, event events.Event) error {
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx)
+	}()
+
+	outbox.mu.Lock()
+	outbox.events = append(outbox.events, events.Pending{Event: testEvent(1, events.TYPE_STUDENT_REGISTERED)})
+	outbox.mu.Unlock()
+	dispatcher.Notify()
+
+	assert.Eventually(t, func() bool { return outbox.isDispatched(1) }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+// Tests the delivery of events to webhooks.
+func Webhook(t *testing.T) {
+	var received events.Event
+	var header http.Header
+	status := http.StatusNoContent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	handler := events.Webhook(server.Client(), server.URL)
+	event := testEvent(7, events.TYPE_STUDENT_SUSPENDED)
+
+	assert.NoError(t, handler(context.Background(), event))
+	assert.Equal(t, "7", header.Get(events.HEADER_EVENT_ID))
+	assert.Equal(t, events.TYPE_STUDENT_SUSPENDED, header.Get(events.HEADER_EVENT_TYPE))
+	assert.Equal(t, event.ID, received.ID)
+	assert.Equal(t, event.Students, received.Students)
+
+	// Deliveries fail unless the webhook responds with a 2xx status.
+	status = http.StatusInternalServerError
+	assert.Error(t, handler(context.Background(), event))
+
+	// Names of webhooks are stable and fit the outbox.
+	assert.Equal(t, events.WebhookName(server.URL), events.WebhookName(server.URL))
+	assert.LessOrEqual(t, len(events.WebhookName(server.URL)), 60)
+}
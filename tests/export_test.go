@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/exports"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+)
+
+// Source of exports with fixed rows, which records the filters it is given.
+type testExportSource struct {
+	filters []database.ExportFilter
+	// Fails after the given number of rows, if positive.
+	failAfter int
+}
+
+func (s *testExportSource) ExportTeachers(ctx context.Context, filter database.ExportFilter, fn func(string) error) error {
+	s.filters = append(s.filters, filter)
+	return each(s, []string{"t1@gmail.com", "t2@gmail.com"}, fn)
+}
+
+func (s *testExportSource) ExportStudents(ctx context.Context, filter database.ExportFilter, fn func(database.Student) error) error {
+	s.filters = append(s.filters, filter)
+	return each(s, []database.Student{{Email: "s1@gmail.com"}, {Email: "+s2@gmail.com", Suspended: true}}, fn)
+}
+
+func (s *testExportSource) ExportTeaches(ctx context.Context, filter database.ExportFilter, fn func(database.RosterEntry) error) error {
+	s.filters = append(s.filters, filter)
+	return each(s, []database.RosterEntry{
+		{Teacher: "t1@gmail.com", Student: "s1@gmail.com", Class: "4A"},
+		{Teacher: "t1@gmail.com", Student: "s2@gmail.com", Suspended: true},
+	}, fn)
+}
+
+func (s *testExportSource) ExportNotifications(ctx context.Context, filter database.ExportFilter, fn func(database.NotificationRecipient) error) error {
+	s.filters = append(s.filters, filter)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	return each(s, []database.NotificationRecipient{
+		{Notification: database.Notification{ID: 1, Teacher: "t1@gmail.com", Text: `Hi, "class"`, CreatedAt: createdAt}, Student: "s1@gmail.com"},
+		{Notification: database.Notification{ID: 2, Teacher: "t1@gmail.com", Text: "=1+1", CreatedAt: createdAt}},
+	}, fn)
+}
+
+// Calls fn with every row, and fails after failAfter rows if positive.
+func each[T any](s *testExportSource, rows []T, fn func(T) error) error {
+	for i, v := range rows {
+		if s.failAfter > 0 && i == s.failAfter {
+			return errors.New("connection lost")
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Tests for the exports of relations.
+func TestExport(t *testing.T) {
+	t.Run("csv", ExportCSV)
+	t.Run("ndjson", ExportNDJSON)
+	t.Run("errors", ExportErrors)
+	t.Run("endpoint", ExportEndpoint)
+	t.Run("failure midway", ExportFailure)
+}
+
+// Tests that every relation is exported as CSV with a header row.
+func ExportCSV(t *testing.T) {
+	tests := []struct {
+		resource string
+		expected string
+	}{
+		{exports.RESOURCE_TEACHERS, "email\nt1@gmail.com\nt2@gmail.com\n"},
+		// Emails starting with "+" or "-" are not escaped.
+		{exports.RESOURCE_STUDENTS, "email,suspended\ns1@gmail.com,false\n+s2@gmail.com,true\n"},
+		{exports.RESOURCE_TEACHES, "teacher,student,class,suspended\n" +
+			"t1@gmail.com,s1@gmail.com,4A,false\n" +
+			"t1@gmail.com,s2@gmail.com,,true\n"},
+		// Formulas are escaped so that spreadsheets show them as text.
+		{exports.RESOURCE_NOTIFICATIONS, "id,teacher,notification,created_at,student\n" +
+			"1,t1@gmail.com,\"Hi, \"\"class\"\"\",2024-01-02T03:04:05.678Z,s1@gmail.com\n" +
+			"2,t1@gmail.com,'=1+1,2024-01-02T03:04:05.678Z,\n"},
+	}
+
+	for _, v := range tests {
+		source := &testExportSource{}
+		filter := database.ExportFilter{Teacher: "t1@gmail.com", Class: "4A"}
+		var body strings.Builder
+
+		n, err := exports.Export(context.Background(), source, v.resource, filter, exports.NewWriter(exports.FORMAT_CSV, &body))
+		assert.NoError(t, err, v.resource)
+		assert.Equal(t, 2, n, v.resource)
+		assert.Equal(t, v.expected, body.String(), v.resource)
+		assert.Equal(t, []database.ExportFilter{filter}, source.filters, v.resource)
+	}
+}
+
+// Tests that rows are exported as JSON Lines with typed values, keyed by column in order.
+func ExportNDJSON(t *testing.T) {
+	tests := []struct {
+		resource string
+		expected string
+	}{
+		{exports.RESOURCE_STUDENTS, `{"email":"s1@gmail.com","suspended":false}` + "\n" +
+			`{"email":"+s2@gmail.com","suspended":true}` + "\n"},
+		{exports.RESOURCE_NOTIFICATIONS, `{"id":1,"teacher":"t1@gmail.com","notification":"Hi, \"class\"","created_at":"2024-01-02T03:04:05.678Z","student":"s1@gmail.com"}` + "\n" +
+			`{"id":2,"teacher":"t1@gmail.com","notification":"=1+1","created_at":"2024-01-02T03:04:05.678Z","student":""}` + "\n"},
+	}
+
+	for _, v := range tests {
+		var body strings.Builder
+
+		_, err := exports.Export(context.Background(), &testExportSource{}, v.resource, database.ExportFilter{}, exports.NewWriter(exports.FORMAT_NDJSON, &body))
+		assert.NoError(t, err, v.resource)
+		assert.Equal(t, v.expected, body.String(), v.resource)
+	}
+}
+
+// Tests that the rows written before an error are flushed, and the error is returned.
+func ExportErrors(t *testing.T) {
+	var body strings.Builder
+
+	n, err := exports.Export(context.Background(), &testExportSource{failAfter: 1}, exports.RESOURCE_TEACHERS,
+		database.ExportFilter{}, exports.NewWriter(exports.FORMAT_CSV, &body))
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "email\nt1@gmail.com\n", body.String())
+}
+
+// Tests for the "/api/v2/export/{resource}" endpoints which do not need a database.
+func ExportEndpoint(t *testing.T) {
+	r := openAPIRouter(t, &middlewares.OpenAPIConfig{})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Invalid query parameters.
+	// Should return status code 400 with the invalid fields.
+	tests := []struct {
+		name   string
+		path   string
+		fields []string
+	}{
+		{"format", "/api/v2/export/students?format=xlsx", []string{"format"}},
+		{"teacher", "/api/v2/export/students?teacher=teacher", []string{"teacher"}},
+		{"class", "/api/v2/export/teaches?class=" + strings.Repeat("A", 61), []string{"class"}},
+		{"suspended", "/api/v2/export/students?suspended=maybe", []string{"suspended"}},
+	}
+
+	for _, v := range tests {
+		rr := serve(v.path)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, v.name)
+		assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, v.fields...)
+	}
+
+	// Exports are transfers, which are written until the deadline of transfers.
+	for _, v := range exports.RESOURCES {
+		assert.True(t, middlewares.IsTransferRoute("/api/v2/export/"+v), v)
+	}
+
+	// Exports at the unversioned paths they were added at.
+	// Should be served as at v2, without announcing a deprecation.
+	rr := serve("/api/export/students?format=xlsx")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "format")
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+	assert.True(t, middlewares.IsTransferRoute("/api/export/teachers"))
+
+	// Exports at v1.
+	// Should return status code 404, as the endpoints were added after v1.
+	rr = serve("/api/v1/export/teachers")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Valid query parameters.
+	// Should return status code 500 as the DB is closed, before any row is written.
+	for _, v := range exports.RESOURCES {
+		rr := serve("/api/v2/export/" + v + "?format=ndjson&teacher=t1@gmail.com&class=4A&suspended=false")
+		assert.Equal(t, http.StatusInternalServerError, rr.Code, v)
+		assertProblem(t, rr, messages.CODE_DATABASE_ERROR)
+		assert.Empty(t, rr.Header().Get("Content-Disposition"), v)
+	}
+}
+
+// Store whose exports of teachers fail after the given number of rows.
+type failingExportStore struct {
+	database.Store
+	rows int
+}
+
+func (s *failingExportStore) ExportTeachers(ctx context.Context, filter database.ExportFilter, fn func(string) error) error {
+	for i := 0; i < s.rows; i++ {
+		if err := fn(fmt.Sprintf("teacher%d@gmail.com", i)); err != nil {
+			return err
+		}
+	}
+
+	return errors.New("connection lost")
+}
+
+// Tests that the connection of an export which fails midway is aborted, so that the client sees an incomplete body.
+func ExportFailure(t *testing.T) {
+	r := handlers.InitRouter()
+	handlers.RegisterServiceMiddlewares(r, services.New(&failingExportStore{Store: database.NewMemoryStore(), rows: 5000}))
+	handlers.RegisterEndpoints(r, nil, &middlewares.DeprecationConfig{})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/api/v2/export/teachers")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := io.ReadAll(res.Body)
+	assert.Error(t, err)
+	assert.NotContains(t, string(body), messages.CODE_INTERNAL_ERROR)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/fixtures"
+	"govtech/pkg/utilities/patterns"
+)
+
+// Loader which records the chunks and suspensions it is given.
+type testLoader struct {
+	testApplier
+	suspended []string
+}
+
+func (l *testLoader) SuspendStudent(ctx context.Context, student string) (bool, error) {
+	l.suspended = append(l.suspended, student)
+	return true, nil
+}
+
+// Tests for the generated schools.
+func TestFixtures(t *testing.T) {
+	t.Run("deterministic", FixturesDeterministic)
+	t.Run("shape", FixturesShape)
+	t.Run("small", FixturesSmall)
+	t.Run("load", FixturesLoad)
+}
+
+// Tests that the same seed generates the same school, and another seed another school.
+func FixturesDeterministic(t *testing.T) {
+	config := fixtures.Config{Seed: 42, Teachers: 30, Students: 600, ElectiveRate: 0.2, SuspendedRate: 0.05}
+
+	school := fixtures.Generate(config)
+	assert.Equal(t, school, fixtures.Generate(config))
+
+	config.Seed = 43
+	assert.NotEqual(t, school, fixtures.Generate(config))
+}
+
+// Tests that students are split into classes taught by distinct teachers, with valid and unique emails.
+func FixturesShape(t *testing.T) {
+	school := fixtures.Generate(fixtures.Config{Seed: 1, Teachers: 30, Students: 600, ElectiveRate: 0.2, SuspendedRate: 0.05})
+	assert.Len(t, school.Teachers, 30)
+	assert.Len(t, school.Students, 600)
+	assert.Len(t, school.Classes, 600/fixtures.DEFAULT_CLASS_SIZE)
+	assert.Equal(t, "1A", school.Classes[0])
+	assert.Equal(t, "2J", school.Classes[19])
+
+	// Emails are valid for the API, and unique.
+	email := regexp.MustCompile("^" + patterns.REGEX_PATTERN_EMAIL + "$")
+	emails := map[string]bool{}
+	for _, v := range school.Teachers {
+		assert.Regexp(t, email, v)
+		assert.LessOrEqual(t, len(v), 60)
+		emails[v] = true
+	}
+	for _, v := range school.Students {
+		assert.Regexp(t, email, v.Email)
+		assert.LessOrEqual(t, len(v.Email), 60)
+		emails[v.Email] = true
+	}
+	assert.Len(t, emails, 630)
+
+	// Registrations are unique, and every student is registered to the teachers of one class.
+	teachersOf := map[string]map[string]bool{}
+	classOf := map[string]string{}
+	electives := 0
+	for _, v := range school.Registrations {
+		if teachersOf[v.Student] == nil {
+			teachersOf[v.Student] = map[string]bool{}
+		}
+		assert.False(t, teachersOf[v.Student][v.Teacher], v)
+		teachersOf[v.Student][v.Teacher] = true
+
+		if v.Class == "" {
+			electives++
+			continue
+		}
+		if class, ok := classOf[v.Student]; ok {
+			assert.Equal(t, class, v.Class, v.Student)
+		}
+		classOf[v.Student] = v.Class
+	}
+	assert.Len(t, classOf, 600)
+	assert.Len(t, school.Registrations, 600*(1+fixtures.DEFAULT_SUBJECT_TEACHERS)+electives)
+	assert.InDelta(t, 120, electives, 40)
+	assert.InDelta(t, 30, len(school.Suspended()), 15)
+
+	// Every teacher is given classes.
+	students := map[string]int{}
+	for _, v := range school.Registrations {
+		students[v.Teacher]++
+	}
+	assert.Len(t, students, 30)
+}
+
+// Tests that schools with fewer teachers than teachers per class, or no teachers, are generated.
+func FixturesSmall(t *testing.T) {
+	school := fixtures.Generate(fixtures.Config{Seed: 1, Teachers: 2, Students: 3, ElectiveRate: 1})
+	assert.Equal(t, []string{"1A"}, school.Classes)
+	assert.Len(t, school.Registrations, 6)
+	assert.Empty(t, school.Suspended())
+
+	school = fixtures.Generate(fixtures.Config{Seed: 1, Students: 3})
+	assert.Len(t, school.Students, 3)
+	assert.Empty(t, school.Registrations)
+}
+
+// Tests that schools are written in chunks, then suspended, and that errors stop the load.
+func FixturesLoad(t *testing.T) {
+	school := fixtures.Generate(fixtures.Config{Seed: 1, Teachers: 10, Students: 300, SuspendedRate: 0.1})
+	loader := &testLoader{}
+
+	registered, err := fixtures.Load(context.Background(), loader, school)
+	assert.NoError(t, err)
+	assert.Equal(t, len(school.Registrations), registered)
+	assert.Len(t, loader.chunks, (len(school.Registrations)+fixtures.CHUNK_SIZE-1)/fixtures.CHUNK_SIZE)
+	assert.Len(t, loader.chunks[0], fixtures.CHUNK_SIZE)
+	assert.Equal(t, school.Suspended(), loader.suspended)
+
+	loader = &testLoader{testApplier: testApplier{failAfter: 1}}
+	registered, err = fixtures.Load(context.Background(), loader, school)
+	assert.Error(t, err)
+	assert.Equal(t, fixtures.CHUNK_SIZE, registered)
+	assert.Empty(t, loader.suspended)
+}
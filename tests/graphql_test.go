@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/server/gql"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/utilities/messages"
+)
+
+// Query of the students of a teacher and their other teachers.
+const teacherStudentsQuery = `{ teacher(email: "t1@gmail.com") { students { email teachers { email } } } }`
+
+// Structure for the result of a GraphQL request.
+type graphQLResult struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code   string `json:"code"`
+			Errors []struct {
+				Field string `json:"field"`
+			} `json:"errors"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// Returns a router serving "/graphql" within the limits, using the given database.
+func graphQLRouter(db *sql.DB, limits gql.Limits) *gin.Engine {
+	r := handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, db)
+	controllers.RegisterGraphQLEndpoint(r, &limits)
+
+	return r
+}
+
+// Returns the response of the router to a GraphQL query.
+func graphQLRequest(r *gin.Engine, query string, lang string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(gql.Request{Query: query})
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// Tests for the "/graphql" endpoint.
+func TestGraphQL(t *testing.T) {
+	t.Run("measure", GraphQLMeasure)
+	t.Run("limits", GraphQLLimits)
+	t.Run("errors", GraphQLErrors)
+	t.Run("loader", GraphQLLoader)
+}
+
+// Tests for the depth and complexity of queries.
+func GraphQLMeasure(t *testing.T) {
+	handler, err := gql.NewHandler(gql.Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		depth      int
+		complexity int
+	}{
+		{
+			name:       "single field",
+			query:      `{ teacher(email: "t1@gmail.com") { email } }`,
+			depth:      2,
+			complexity: 2,
+		},
+		{
+			// Fields of lists count 10 times.
+			name:       "nested lists",
+			query:      teacherStudentsQuery,
+			depth:      4,
+			complexity: 122,
+		},
+		{
+			name: "fragments",
+			query: `{ teacher(email: "t1@gmail.com") { ...students } }
+				fragment students on Teacher { students { email teachers { ... on Teacher { email } } } }`,
+			depth:      4,
+			complexity: 122,
+		},
+		{
+			name:       "introspection",
+			query:      `{ __schema { types { name fields { name } } } teachers { email } }`,
+			depth:      2,
+			complexity: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			depth, complexity := gql.Measure(handler.Schema(), doc, "")
+			assert.Equal(t, tt.depth, depth)
+			assert.Equal(t, tt.complexity, complexity)
+		})
+	}
+}
+
+// Tests that queries over the limits are rejected before they are executed.
+func GraphQLLimits(t *testing.T) {
+	// A closed database fails every query without a database server.
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	tests := []struct {
+		name   string
+		limits gql.Limits
+		code   string
+	}{
+		{
+			name:   "too deep",
+			limits: gql.Limits{MaxDepth: 3},
+			code:   messages.CODE_QUERY_TOO_DEEP,
+		},
+		{
+			name:   "too complex",
+			limits: gql.Limits{MaxComplexity: 100},
+			code:   messages.CODE_QUERY_TOO_COMPLEX,
+		},
+		{
+			// Executed, and fails to query the database.
+			name:   "within limits",
+			limits: gql.Limits{MaxDepth: 4, MaxComplexity: 122},
+			code:   messages.CODE_DATABASE_ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := graphQLRequest(graphQLRouter(db, tt.limits), teacherStudentsQuery, "")
+
+			var result graphQLResult
+			if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			if assert.Len(t, result.Errors, 1) {
+				assert.Equal(t, tt.code, result.Errors[0].Extensions.Code)
+			}
+		})
+	}
+}
+
+// Tests for invalid requests.
+func GraphQLErrors(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	r := graphQLRouter(db, gql.Limits{MaxDepth: 6, MaxComplexity: 1000})
+
+	// Request body without a query.
+	// Should return a problem.
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "query")
+
+	// Field which does not exist.
+	// Should return an error of the query.
+	rr = graphQLRequest(r, `{ teacher(email: "t1@gmail.com") { name } }`, "")
+
+	var result graphQLResult
+	json.Unmarshal(rr.Body.Bytes(), &result)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, result.Errors, 1)
+
+	// Notification of the wrong format.
+	// Should return a validation error of the text, in the language of the client.
+	rr = graphQLRequest(r, `{ notification(teacher: "t1@gmail.com", text: "Hello @") { recipients { email } } }`, "zh")
+
+	result = graphQLResult{}
+	json.Unmarshal(rr.Body.Bytes(), &result)
+
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, messages.CODE_VALIDATION_FAILED, result.Errors[0].Extensions.Code)
+		assert.Equal(t, messages.Translate(messages.LANGUAGE_ZH, messages.MESSAGE_INVALID_PARAMS), result.Errors[0].Message)
+		if assert.Len(t, result.Errors[0].Extensions.Errors, 1) {
+			assert.Equal(t, "text", result.Errors[0].Extensions.Errors[0].Field)
+		}
+	}
+}
+
+// Tests that the loader fetches primed keys together.
+func GraphQLLoader(t *testing.T) {
+	var fetches [][]string
+	loader := gql.NewLoader(func(ctx context.Context, keys []string) (map[string]int, error) {
+		fetches = append(fetches, keys)
+
+		values := make(map[string]int)
+		for _, v := range keys {
+			if v != "missing" {
+				values[v] = len(v)
+			}
+		}
+		return values, nil
+	})
+
+	ctx := context.Background()
+	loader.Prime("a", "bb", "ccc")
+
+	v, err := loader.Load(ctx, "bb")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+
+	// Primed keys are fetched together with the first key loaded, and cached.
+	v, _ = loader.Load(ctx, "ccc")
+	assert.Equal(t, 3, v)
+	v, _ = loader.Load(ctx, "a")
+	assert.Equal(t, 1, v)
+	assert.Equal(t, [][]string{{"bb", "a", "ccc"}}, fetches)
+
+	// Keys which are not found are cached as the zero value.
+	v, _ = loader.Load(ctx, "missing")
+	assert.Equal(t, 0, v)
+	loader.Load(ctx, "missing")
+	assert.Equal(t, [][]string{{"bb", "a", "ccc"}, {"missing"}}, fetches)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/rpc"
+	"govtech/pkg/server/rpc/pb"
+	"govtech/pkg/services"
+)
+
+// Returns a client of a gRPC server served in memory, using the given store.
+func grpcClient(t *testing.T, store database.Store, opts ...grpc.ServerOption) pb.TeacherServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := rpc.NewGRPCServer(services.New(store), opts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewTeacherServiceClient(conn)
+}
+
+// Returns the fields of the field violations of a status error.
+func violatedFields(err error) []string {
+	fields := []string{}
+	for _, v := range status.Convert(err).Details() {
+		if badRequest, ok := v.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+
+	return fields
+}
+
+// Tests for the failure paths of the gRPC API.
+func TestGRPC(t *testing.T) {
+	// A closed database fails every query without a database server.
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	client := grpcClient(t, database.NewStore(db))
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		call   func() (proto.Message, error)
+		code   codes.Code
+		fields []string
+	}{
+		{
+			name: "register without registration",
+			call: func() (proto.Message, error) {
+				return client.Register(ctx, &pb.RegisterRequest{})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"teacher", "students"},
+		},
+		{
+			name: "register invalid email",
+			call: func() (proto.Message, error) {
+				return client.Register(ctx, &pb.RegisterRequest{
+					Registration: &pb.RegisterRequest_StudentsOfTeacher{StudentsOfTeacher: &pb.StudentsOfTeacher{
+						Teacher:  "t1@gmail.com",
+						Students: []string{"s1@gmail.com", "s2"},
+					}},
+				})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"students[1]"},
+		},
+		{
+			name: "register missing student",
+			call: func() (proto.Message, error) {
+				return client.Register(ctx, &pb.RegisterRequest{
+					Registration: &pb.RegisterRequest_TeachersOfStudent{TeachersOfStudent: &pb.TeachersOfStudent{
+						Teachers: []string{"t1@gmail.com"},
+					}},
+				})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"student"},
+		},
+		{
+			name: "register database error",
+			call: func() (proto.Message, error) {
+				return client.Register(ctx, &pb.RegisterRequest{
+					Registration: &pb.RegisterRequest_StudentsOfTeacher{StudentsOfTeacher: &pb.StudentsOfTeacher{
+						Teacher:  "t1@gmail.com",
+						Students: []string{"s1@gmail.com"},
+					}},
+				})
+			},
+			code: codes.Internal,
+		},
+		{
+			name: "common students without teachers",
+			call: func() (proto.Message, error) {
+				return client.CommonStudents(ctx, &pb.CommonStudentsRequest{})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"teachers"},
+		},
+		{
+			name: "common students database error",
+			call: func() (proto.Message, error) {
+				return client.CommonStudents(ctx, &pb.CommonStudentsRequest{Teachers: []string{"t1@gmail.com"}})
+			},
+			code: codes.Internal,
+		},
+		{
+			name: "retrieve for notifications missing fields",
+			call: func() (proto.Message, error) {
+				return client.RetrieveForNotifications(ctx, &pb.RetrieveForNotificationsRequest{})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"teacher", "notification"},
+		},
+		{
+			name: "retrieve for notifications invalid notification",
+			call: func() (proto.Message, error) {
+				return client.RetrieveForNotifications(ctx, &pb.RetrieveForNotificationsRequest{
+					Teacher:      "t1@gmail.com",
+					Notification: "Hello @",
+				})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"notification"},
+		},
+		{
+			name: "suspend invalid email",
+			call: func() (proto.Message, error) {
+				return client.Suspend(ctx, &pb.SuspendRequest{Student: "s1"})
+			},
+			code:   codes.InvalidArgument,
+			fields: []string{"student"},
+		},
+		{
+			name: "suspend database error",
+			call: func() (proto.Message, error) {
+				return client.Suspend(ctx, &pb.SuspendRequest{Student: "s1@gmail.com"})
+			},
+			code: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.call()
+
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.fields != nil {
+				assert.ElementsMatch(t, tt.fields, violatedFields(err))
+			}
+		})
+	}
+
+	// Request IDs given by the client are returned in the response headers.
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, rpc.METADATA_REQUEST_ID, "grpc-request-1")
+	_, err = client.Suspend(ctx, &pb.SuspendRequest{Student: "s1"}, grpc.Header(&header))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, []string{"grpc-request-1"}, header.Get(rpc.METADATA_REQUEST_ID))
+}
+
+// Tests that calls have the rate limits and deadlines of the equivalent routes.
+func TestGRPCLimits(t *testing.T) {
+	rateLimitConfig := middlewares.RateLimitConfig{
+		KeyBy: middlewares.RATE_LIMIT_KEY_TEACHER,
+		Routes: map[string]middlewares.RateLimit{
+			"/api/commonstudents": {Rate: 0.001, Burst: 1},
+		},
+	}
+	timeoutConfig := middlewares.TimeoutConfig{
+		Default: 10 * time.Millisecond,
+	}
+	rateLimitStore := middlewares.NewMemoryRateLimitStore()
+
+	client := grpcClient(t, &blockingStore{Store: database.NewMemoryStore()}, grpc.ChainUnaryInterceptor(
+		rpc.RateLimitInterceptor(&rateLimitConfig, rateLimitStore),
+		rpc.TimeoutInterceptor(&timeoutConfig),
+	))
+	ctx := context.Background()
+
+	// The first call of a teacher reaches the store, and is cancelled at the deadline.
+	// Should return DeadlineExceeded.
+	var header metadata.MD
+	_, err := client.CommonStudents(ctx, &pb.CommonStudentsRequest{Teachers: []string{"t1@gmail.com"}}, grpc.Header(&header))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+
+	// The second call of the same teachers in any order is limited.
+	// Should return ResourceExhausted and the retry-after header.
+	_, err = client.CommonStudents(ctx, &pb.CommonStudentsRequest{Teachers: []string{"T1@gmail.com"}}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1000"}, header.Get("retry-after"))
+
+	// Calls share the buckets of the HTTP API.
+	// Should return ResourceExhausted once the teacher has used the route.
+	rateLimitStore.Take(middlewares.RateLimitBucket("/api/v1/commonstudents", "teacher:t2@gmail.com"), middlewares.RateLimit{Rate: 0.001, Burst: 1}, time.Now())
+	_, err = client.CommonStudents(ctx, &pb.CommonStudentsRequest{Teachers: []string{"t2@gmail.com"}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Methods without limits are not limited.
+	// Should return OK.
+	for i := 0; i < 3; i++ {
+		_, err = client.Suspend(ctx, &pb.SuspendRequest{Student: "s1@gmail.com"})
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/events"
+	"govtech/pkg/fixtures"
+	"govtech/pkg/server/cache"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/gql"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/openapi"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+)
+
+/*
+Structure for the full router of the server over its own memory store, so that tests with
+their own harness run in parallel without a DB. Lookups are cached as by the server, and
+responses which do not match the OpenAPI document fail the test.
+*/
+type harness struct {
+	t          *testing.T
+	router     *gin.Engine
+	store      *database.MemoryStore
+	service    *services.Service
+	dispatcher *events.Dispatcher
+}
+
+// Returns a harness of the router with the endpoints, the middlewares and the GraphQL endpoint of the server.
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	store := database.NewMemoryStore()
+	service := services.New(store)
+	service.UseCaches(services.NewCaches(cache.Config{Size: 100, TTL: time.Minute}))
+
+	r := handlers.InitRouter()
+	err = middlewares.RegisterOpenAPIMiddleware(r, doc, &middlewares.OpenAPIConfig{
+		ValidateResponses: true,
+		OnResponseError: func(c *gin.Context, err error) {
+			t.Errorf("%s %s: response does not match the document: %s", c.Request.Method, c.Request.URL, err.Error())
+		},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	handlers.RegisterServiceMiddlewares(r, service)
+	handlers.RegisterEndpoints(r, nil, &middlewares.DeprecationConfig{})
+	controllers.RegisterGraphQLEndpoint(r, &gql.Limits{MaxDepth: 5, MaxComplexity: 1000})
+
+	dispatcher := events.NewDispatcher(store, events.DispatcherConfig{
+		PollInterval: time.Second, BatchSize: 100, Lease: time.Minute,
+		RetryBackoff: time.Second, MaxRetryBackoff: time.Minute, MaxAttempts: 10,
+	})
+	dispatcher.Subscribe(services.SUBSCRIBER_TEACHER_EVENTS, service.PublishTeacherEvent)
+	dispatcher.Subscribe(services.SUBSCRIBER_CACHES, service.InvalidateCaches)
+
+	return &harness{t: t, router: r, store: store, service: service, dispatcher: dispatcher}
+}
+
+// Serves the request, with a JSON body if body is not empty.
+func (h *harness) serve(method string, path string, body string) *httptest.ResponseRecorder {
+	h.t.Helper()
+
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		h.t.Fatal(err.Error())
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rr := httptest.NewRecorder()
+	h.router.ServeHTTP(rr, req)
+
+	return rr
+}
+
+// Writes the school to the store.
+func (h *harness) seed(school fixtures.School) {
+	h.t.Helper()
+
+	if _, err := fixtures.Load(context.Background(), h.store, school); err != nil {
+		h.t.Fatal(err.Error())
+	}
+}
+
+// Dispatches the events of the outbox, and returns the number of events.
+func (h *harness) dispatch() int {
+	h.t.Helper()
+
+	n, err := h.dispatcher.Dispatch(context.Background())
+	if err != nil {
+		h.t.Fatal(err.Error())
+	}
+
+	return n
+}
+
+// Tests of the endpoints over the memory store, each with its own harness.
+func TestHarness(t *testing.T) {
+	t.Run("isolation", HarnessIsolation)
+	t.Run("register and commonstudents", HarnessRegister)
+	t.Run("suspend and retrievefornotifications", HarnessNotifications)
+	t.Run("events", HarnessEvents)
+	t.Run("deregister", HarnessDeregister)
+	t.Run("email case", HarnessEmailCase)
+	t.Run("fixtures", HarnessFixtures)
+	t.Run("readiness", HarnessReadiness)
+}
+
+// Tests that every harness has its own store.
+func HarnessIsolation(t *testing.T) {
+	t.Parallel()
+
+	h1, h2 := newHarness(t), newHarness(t)
+
+	rr := h1.serve("POST", "/api/v2/register", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = h1.serve("GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com", "")
+	assert.JSONEq(t, `{"students": ["studentjon@gmail.com"]}`, rr.Body.String())
+
+	rr = h2.serve("GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com", "")
+	assert.JSONEq(t, `{"students": []}`, rr.Body.String())
+}
+
+// Tests for the "/api/register" and "/api/commonstudents" endpoints.
+func HarnessRegister(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	// Registration of students to teachers, of both shapes.
+	// Should return status code 204.
+	for _, v := range []string{
+		`{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com", "studenthon@gmail.com"]}`,
+		`{"student": "studentjon@gmail.com", "teachers": ["teacherjoe@gmail.com"]}`,
+	} {
+		rr := h.serve("POST", "/api/register", v)
+		assert.Equal(t, http.StatusNoContent, rr.Code, v)
+	}
+
+	// Common students of one and of both teachers.
+	// Should return status code 200 and the students sorted by email.
+	rr := h.serve("GET", "/api/commonstudents?teacher=teacherken@gmail.com", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"students": ["studenthon@gmail.com", "studentjon@gmail.com"]}`, rr.Body.String())
+
+	rr = h.serve("GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com&teacher=teacherjoe@gmail.com", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"students": ["studentjon@gmail.com"]}`, rr.Body.String())
+
+	// Registration of an invalid student.
+	// Should return status code 422 with the invalid field.
+	rr = h.serve("POST", "/api/v2/register", `{"teacher": "teacherken@gmail.com", "students": ["student"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "students[0]")
+}
+
+// Tests for the "/api/suspend" and "/api/retrievefornotifications" endpoints.
+func HarnessNotifications(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	rr := h.serve("POST", "/api/v2/register", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com", "studenthon@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = h.serve("POST", "/api/v2/suspend", `{"student": "studenthon@gmail.com"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Notification with a mention of a student who is not registered.
+	// Should return the active students of the teacher and the mentioned student, without the suspended student.
+	rr = h.serve("POST", "/api/v2/register", `{"student": "studentagnes@gmail.com", "teachers": ["teacherjoe@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = h.serve("POST", "/api/v2/retrievefornotifications",
+		`{"teacher": "teacherken@gmail.com", "notification": "Hello @studentagnes@gmail.com @studenthon@gmail.com"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"recipients": ["studentagnes@gmail.com", "studentjon@gmail.com"]}`, rr.Body.String())
+
+	// The notification is kept in the history of its recipients.
+	notifications, err := h.store.NotificationsOf(context.Background(), "studentjon@gmail.com", 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, "teacherken@gmail.com", notifications[0].Teacher)
+	}
+}
+
+// Tests that changes are written to the outbox, and published to teachers once dispatched.
+func HarnessEvents(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	subscription := h.service.SubscribeTeacherEvents("teacherken@gmail.com")
+	defer subscription.Close()
+
+	rr := h.serve("POST", "/api/v2/register", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = h.serve("POST", "/api/v2/suspend", `{"student": "studentjon@gmail.com"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Suspension of a student who is already suspended.
+	// Should write no event.
+	rr = h.serve("POST", "/api/v2/suspend", `{"student": "studentjon@gmail.com"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	assert.Equal(t, 2, h.dispatch())
+	assert.Equal(t, 0, h.dispatch())
+
+	for _, v := range []string{events.TYPE_STUDENT_REGISTERED, events.TYPE_STUDENT_SUSPENDED} {
+		event := <-subscription.Events()
+		assert.Equal(t, v, event.Type)
+		assert.Equal(t, []string{"studentjon@gmail.com"}, event.Students)
+	}
+}
+
+// Tests that emails are matched in any case like in MySQL, and returned as spelled when first registered.
+func HarnessEmailCase(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	rr := h.serve("POST", "/api/v2/register", `{"teacher": "TeacherKen@gmail.com", "students": ["StudentJon@gmail.com", "studenthon@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Registration of the same student and teacher in another case.
+	// Should register nothing new, and write no event.
+	rr = h.serve("POST", "/api/v2/register", `{"teacher": "teacherken@gmail.com", "students": ["STUDENTJON@GMAIL.COM"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, h.dispatch())
+
+	rr = h.serve("POST", "/api/v2/register", `{"student": "studentjon@gmail.com", "teachers": ["teacherjoe@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = h.serve("GET", "/api/v2/commonstudents?teacher=TEACHERKEN@gmail.com&teacher=teacherjoe@gmail.com", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"students": ["StudentJon@gmail.com"]}`, rr.Body.String())
+
+	// Suspension and notification in another case.
+	// Should suspend the student, and leave them out of the recipients.
+	rr = h.serve("POST", "/api/v2/suspend", `{"student": "studentJON@gmail.com"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = h.serve("POST", "/api/v2/retrievefornotifications", `{"teacher": "teacherken@gmail.com", "notification": "Hello"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"recipients": ["studenthon@gmail.com"]}`, rr.Body.String())
+
+	notifications, err := h.store.NotificationsOf(context.Background(), "StudentHon@gmail.com", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 1)
+
+	// Deregistration in another case.
+	// Should return the students as first registered.
+	rr = h.serve("POST", "/api/v2/deregister", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com", "STUDENTJON@gmail.com"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"students": ["StudentJon@gmail.com"]}`, rr.Body.String())
+}
+
+// Tests for the "/api/v2/deregister" endpoint, whose deregistrations are published to teachers once dispatched.
+func HarnessDeregister(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	subscription := h.service.SubscribeTeacherEvents("teacherken@gmail.com")
+	defer subscription.Close()
+
+	rr := h.serve("POST", "/api/v2/register", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com", "studenthon@gmail.com"]}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = h.serve("GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com", "")
+	assert.JSONEq(t, `{"students": ["studenthon@gmail.com", "studentjon@gmail.com"]}`, rr.Body.String())
+
+	// Deregistration of a registered student and of a student who is not registered.
+	// Should return status code 200 and the students which were registered.
+	rr = h.serve("POST", "/api/v2/deregister", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com", "studentamy@gmail.com"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"students": ["studentjon@gmail.com"]}`, rr.Body.String())
+
+	rr = h.serve("GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com", "")
+	assert.JSONEq(t, `{"students": ["studenthon@gmail.com"]}`, rr.Body.String())
+
+	// Deregistration of students who are no longer registered.
+	// Should return no students and write no event.
+	rr = h.serve("POST", "/api/v2/deregister", `{"teacher": "teacherken@gmail.com", "students": ["studentjon@gmail.com"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"students": []}`, rr.Body.String())
+
+	assert.Equal(t, 2, h.dispatch())
+	<-subscription.Events()
+	event := <-subscription.Events()
+	assert.Equal(t, events.TYPE_STUDENT_DEREGISTERED, event.Type)
+	assert.Equal(t, []string{"studentjon@gmail.com"}, event.Students)
+
+	// Invalid deregistrations.
+	// Should return status code 400 with the invalid fields.
+	for _, tt := range []struct {
+		body   string
+		fields []string
+	}{
+		{`{"teacher": "teacherken@gmail.com"}`, []string{"students"}},
+		{`{"teacher": "teacherken@gmail.com", "students": []}`, []string{"students"}},
+		{`{"teacher": "teacher", "students": ["student"]}`, []string{"teacher", "students[0]"}},
+	} {
+		rr = h.serve("POST", "/api/v2/deregister", tt.body)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, tt.body)
+		assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, tt.fields...)
+	}
+
+	// Deregistration is served at v2 only.
+	rr = h.serve("POST", "/api/deregister", `{"teacher": "teacherken@gmail.com", "students": ["studenthon@gmail.com"]}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// Tests the endpoints over a generated school.
+func HarnessFixtures(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	school := fixtures.Generate(fixtures.Config{Seed: 3, Teachers: 6, Students: 60, SuspendedRate: 0.2})
+	h.seed(school)
+
+	// The roster of a teacher of the class is exported with the students of the class.
+	var teacher string
+	for _, v := range school.Registrations {
+		if v.Class == "1B" {
+			teacher = v.Teacher
+			break
+		}
+	}
+	rr := h.serve("GET", "/api/v2/export/teaches?format=csv&class=1B&teacher="+teacher, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, strings.Split(strings.TrimSpace(rr.Body.String()), "\n"), 1+fixtures.DEFAULT_CLASS_SIZE)
+
+	// The suspended students are exported.
+	rr = h.serve("GET", "/api/v2/export/students?format=csv&suspended=true", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, strings.Split(strings.TrimSpace(rr.Body.String()), "\n"), 1+len(school.Suspended()))
+}
+
+// Tests that the memory store is ready.
+func HarnessReadiness(t *testing.T) {
+	t.Parallel()
+	h := newHarness(t)
+
+	rr := h.serve("GET", "/readyz", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
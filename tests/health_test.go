@@ -0,0 +1,44 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/server/handlers"
+)
+
+// Tests for "/healthz" and "/readyz" endpoints.
+func TestHealth(t *testing.T) {
+	// DB which cannot be reached.
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:1)/unreachable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	r := handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, db)
+	controllers.RegisterHealthEndpoints(r)
+
+	// Server is able to handle requests.
+	// Should return status code 200.
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"status":"ok"}`, rr.Body.String())
+
+	// DB cannot be reached.
+	// Should return status code 503.
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
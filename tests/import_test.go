@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/imports"
+	"govtech/pkg/models/response"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+)
+
+// Applier of registrations which records the chunks applied.
+type testApplier struct {
+	chunks [][]database.Registration
+	dryRun []bool
+	// Registrations which are already registered.
+	existing map[database.Registration]bool
+	// Fails every chunk after the given number of chunks, if positive.
+	failAfter int
+}
+
+func (a *testApplier) ImportRegistrations(ctx context.Context, registrations []database.Registration, dryRun bool) (int, error) {
+	if a.failAfter > 0 && len(a.chunks) == a.failAfter {
+		return 0, errors.New("connection lost")
+	}
+
+	a.chunks = append(a.chunks, append([]database.Registration(nil), registrations...))
+	a.dryRun = append(a.dryRun, dryRun)
+
+	n := 0
+	for _, v := range registrations {
+		if !a.existing[v] {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// Tests for the import of registrations from CSV.
+func TestImport(t *testing.T) {
+	t.Run("rows", ImportRows)
+	t.Run("row errors", ImportRowErrors)
+	t.Run("chunks", ImportChunks)
+	t.Run("endpoint", ImportEndpoint)
+}
+
+// Tests that valid rows are applied, with or without a header and class.
+func ImportRows(t *testing.T) {
+	csv := "Teacher,Student,Class\n" +
+		"t1@gmail.com,s1@gmail.com,4A\n" +
+		" t1@gmail.com , s2@gmail.com\n" +
+		"t2@gmail.com,s1@gmail.com,\n"
+	applier := &testApplier{existing: map[database.Registration]bool{
+		{Teacher: "t1@gmail.com", Student: "s2@gmail.com"}: true,
+	}}
+
+	report, err := imports.Registrations(context.Background(), strings.NewReader(csv), applier, imports.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, imports.Report{Rows: 3, Registered: 2, Existing: 1}, report)
+	assert.Equal(t, [][]database.Registration{{
+		{Teacher: "t1@gmail.com", Student: "s1@gmail.com", Class: "4A"},
+		{Teacher: "t1@gmail.com", Student: "s2@gmail.com"},
+		{Teacher: "t2@gmail.com", Student: "s1@gmail.com"},
+	}}, applier.chunks)
+
+	// Dry runs are passed to the applier.
+	applier = &testApplier{}
+	report, err = imports.Registrations(context.Background(), strings.NewReader("t1@gmail.com,s1@gmail.com\n"), applier, imports.Options{DryRun: true})
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Registered)
+	assert.Equal(t, []bool{true}, applier.dryRun)
+}
+
+// Tests that invalid rows are reported with their line and skipped.
+func ImportRowErrors(t *testing.T) {
+	csv := "t1@gmail.com,s1@gmail.com\n" +
+		"t1@gmail.com,student\n" +
+		",s2@gmail.com\n" +
+		"t1@gmail.com\n" +
+		"t1@gmail.com,s3@gmail.com,4A,extra\n" +
+		"t1@gmail.com,s5@gmail.com," + strings.Repeat("A", 61) + "\n" +
+		strings.Repeat("a", 60) + "@gmail.com,s6@gmail.com\n" +
+		// The unterminated quote runs to the end of the file.
+		"t1@gmail.com,\"s4@gmail.com\nt1@gmail.com,s7@gmail.com\n"
+	applier := &testApplier{}
+
+	report, err := imports.Registrations(context.Background(), strings.NewReader(csv), applier, imports.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, report.Rows)
+	assert.Equal(t, 1, report.Registered)
+	assert.Equal(t, 7, report.Invalid)
+	assert.Equal(t, []imports.RowError{
+		{Line: 2, Field: imports.COLUMN_STUDENT, Code: messages.FIELD_CODE_EMAIL},
+		{Line: 3, Field: imports.COLUMN_TEACHER, Code: messages.FIELD_CODE_REQUIRED},
+		{Line: 4, Field: imports.FIELD_ROW, Code: messages.FIELD_CODE_FORMAT},
+		{Line: 5, Field: imports.FIELD_ROW, Code: messages.FIELD_CODE_FORMAT},
+		{Line: 6, Field: imports.COLUMN_CLASS, Code: "max", Param: "60"},
+		{Line: 7, Field: imports.COLUMN_TEACHER, Code: "max", Param: "60"},
+		{Line: 8, Field: imports.FIELD_ROW, Code: messages.FIELD_CODE_FORMAT},
+	}, report.Errors)
+
+	// Errors after the maximum are counted only.
+	csv = strings.Repeat("teacher,student\n", imports.MAX_ERRORS+5)
+	report, err = imports.Registrations(context.Background(), strings.NewReader(csv), applier, imports.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, imports.MAX_ERRORS+4, report.Invalid)
+	assert.Len(t, report.Errors, imports.MAX_ERRORS)
+	assert.True(t, report.ErrorsTruncated)
+}
+
+// Tests that rows are applied in chunks, and that chunks applied before a failure are reported.
+func ImportChunks(t *testing.T) {
+	csv := "t1@gmail.com,s1@gmail.com\n" +
+		"t1@gmail.com,s2@gmail.com\n" +
+		"t1@gmail.com,s3@gmail.com\n" +
+		"t1@gmail.com,s4@gmail.com\n" +
+		"t1@gmail.com,s5@gmail.com\n"
+
+	applier := &testApplier{}
+	report, err := imports.Registrations(context.Background(), strings.NewReader(csv), applier, imports.Options{ChunkSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Registered)
+	if assert.Len(t, applier.chunks, 3) {
+		assert.Len(t, applier.chunks[0], 2)
+		assert.Len(t, applier.chunks[2], 1)
+	}
+
+	applier = &testApplier{failAfter: 1}
+	report, err = imports.Registrations(context.Background(), strings.NewReader(csv), applier, imports.Options{ChunkSize: 2})
+	assert.Error(t, err)
+	assert.Equal(t, 2, report.Registered)
+
+	// Rows which stop being sent are reported as a read error, with the chunks applied before.
+	var readError *imports.ReadError
+	body := io.MultiReader(strings.NewReader(csv[:strings.Index(csv, "s4")]), iotest.ErrReader(errors.New("connection reset")))
+	report, err = imports.Registrations(context.Background(), body, &testApplier{}, imports.Options{ChunkSize: 2})
+	assert.ErrorAs(t, err, &readError)
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 2, report.Registered)
+}
+
+// Tests for the "/api/v2/import/registrations" endpoint which do not need a database.
+func ImportEndpoint(t *testing.T) {
+	r := openAPIRouter(t, &middlewares.OpenAPIConfig{})
+
+	serve := func(path string, contentType string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Body which is not CSV.
+	// Should return status code 415.
+	rr := serve("/api/v2/import/registrations", "application/json", `{}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	assertProblem(t, rr, messages.CODE_BAD_REQUEST)
+
+	// Dry run parameter which is not a boolean.
+	// Should return status code 400.
+	rr = serve("/api/v2/import/registrations?dry_run=maybe", middlewares.MIME_CSV, "t1@gmail.com,s1@gmail.com\n")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "dry_run")
+
+	// Import at the unversioned path it was added at.
+	// Should be served as at v2, without announcing a deprecation.
+	rr = serve("/api/import/registrations?dry_run=maybe", middlewares.MIME_CSV, "t1@gmail.com,s1@gmail.com\n")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "dry_run")
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+
+	// Import at v1.
+	// Should return status code 404, as the endpoint was added after v1.
+	rr = serve("/api/v1/import/registrations", middlewares.MIME_CSV, "t1@gmail.com,s1@gmail.com\n")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Rows which are all invalid.
+	// Should return the report without querying the DB, with details in the language of the client.
+	req, _ := http.NewRequest("POST", "/api/v2/import/registrations?dry_run=true", strings.NewReader("teacher,student\nt1@gmail.com,student\n"))
+	req.Header.Set("Content-Type", middlewares.MIME_CSV+"; charset=utf-8")
+	req.Header.Set("Accept-Language", "ms")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var report response.ImportReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Invalid)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 2, report.Errors[0].Row)
+		assert.Equal(t, messages.FieldErrorMessage(messages.LANGUAGE_MS, messages.FIELD_CODE_EMAIL, ""), report.Errors[0].Detail)
+	}
+
+	// Valid rows.
+	// Should return status code 500 as the DB is closed, with the report of the rows read.
+	rr = serve("/api/v2/import/registrations", middlewares.MIME_CSV, "t1@gmail.com,s1@gmail.com\nt1@gmail.com,student\n")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assertProblem(t, rr, messages.CODE_DATABASE_ERROR)
+
+	var problem response.Problem
+	json.Unmarshal(rr.Body.Bytes(), &problem)
+	if assert.NotNil(t, problem.Report) {
+		assert.Equal(t, 2, problem.Report.Rows)
+		assert.Equal(t, 0, problem.Report.Registered)
+		assert.Equal(t, 1, problem.Report.Invalid)
+	}
+
+	// Body which cannot be read to the end.
+	// Should return status code 400 with the report of the rows read.
+	body := io.MultiReader(strings.NewReader("t1@gmail.com,student\n"), iotest.ErrReader(errors.New("connection reset")))
+	req, _ = http.NewRequest("POST", "/api/v2/import/registrations", body)
+	req.Header.Set("Content-Type", middlewares.MIME_CSV)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_BAD_REQUEST)
+
+	problem = response.Problem{}
+	json.Unmarshal(rr.Body.Bytes(), &problem)
+	if assert.NotNil(t, problem.Report) {
+		assert.Equal(t, 1, problem.Report.Invalid)
+	}
+}
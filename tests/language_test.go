@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/models/request"
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func TestLanguage(t *testing.T) {
+	t.Run("negotiate language", NegotiateLanguage)
+	t.Run("catalogs", Catalogs)
+	t.Run("localised problem", LocalisedProblem)
+}
+
+// Tests for parsing of the Accept-Language header.
+func NegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", messages.LANGUAGE_EN},
+		{"zh", messages.LANGUAGE_ZH},
+		{"zh-CN,zh;q=0.9,en;q=0.8", messages.LANGUAGE_ZH},
+		{"ms-MY", messages.LANGUAGE_MS},
+		{"ta_SG", messages.LANGUAGE_TA},
+		{"fr-FR, ta;q=0.5, en;q=0.4", messages.LANGUAGE_TA},
+		{"en;q=0.2, ms;q=0.7", messages.LANGUAGE_MS},
+		{"fr, de", messages.LANGUAGE_EN},
+		{"*, zh;q=0.5", messages.LANGUAGE_EN},
+		{"zh;q=0, ms;q=abc", messages.LANGUAGE_EN},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, middlewares.NegotiateLanguage(tt.header), tt.header)
+	}
+}
+
+// Tests that every catalog has a message for every message code.
+func Catalogs(t *testing.T) {
+	for _, lang := range messages.LANGUAGES {
+		assert.Empty(t, messages.MissingMessages(lang), lang)
+	}
+}
+
+// Tests for problems in the language of the client.
+func LocalisedProblem(t *testing.T) {
+	r := handlers.InitRouter()
+	r.POST("/api/suspend", func(c *gin.Context) {
+		var body request.SuspendRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			middlewares.AbortWithProblem(c, problems.FromBindError(err))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	send := func(lang string) (*httptest.ResponseRecorder, response.Problem) {
+		req, _ := http.NewRequest("POST", "/api/suspend", bytes.NewBufferString(`{"student": "wrong.format"}`))
+		req.Header.Set("Accept-Language", lang)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		var problem response.Problem
+		err := json.Unmarshal(rr.Body.Bytes(), &problem)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return rr, problem
+	}
+
+	// Test for a supported language.
+	// Should return the title and details in the language, with the same codes.
+	rr, problem := send("zh-CN")
+	assert.Equal(t, "zh", rr.Header().Get("Content-Language"))
+	assert.Equal(t, "验证失败", problem.Title)
+	assert.Equal(t, "一个或多个字段缺失或无效。", problem.Detail)
+	assert.Equal(t, messages.CODE_VALIDATION_FAILED, problem.Code)
+	assert.Equal(t, messages.FIELD_CODE_EMAIL, problem.Errors[0].Code)
+	assert.Equal(t, "必须是有效的电子邮件地址。", problem.Errors[0].Detail)
+
+	rr, problem = send("ms")
+	assert.Equal(t, "ms", rr.Header().Get("Content-Language"))
+	assert.Equal(t, "Mestilah alamat e-mel yang sah.", problem.Errors[0].Detail)
+
+	// Test for an unsupported language.
+	// Should return the title and details in English.
+	rr, problem = send("fr")
+	assert.Equal(t, "en", rr.Header().Get("Content-Language"))
+	assert.Equal(t, "Validation failed", problem.Title)
+	assert.Equal(t, "Must be a valid email address.", problem.Errors[0].Detail)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/logging"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+func TestLogging(t *testing.T) {
+	t.Run("request id", RequestID)
+	t.Run("request log", RequestLog)
+}
+
+// Tests for X-Request-ID propagation and generation.
+func RequestID(t *testing.T) {
+	r := handlers.InitRouter()
+	r.GET("/error", func(c *gin.Context) {
+		middlewares.AbortWithProblem(c, problems.New(http.StatusBadRequest, messages.CODE_BAD_REQUEST, "error"))
+	})
+
+	// Test for request with a valid request ID.
+	// Should return the same request ID in the header and error response.
+	req, _ := http.NewRequest("GET", "/error", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", rr.Header().Get("X-Request-ID"))
+	assert.Equal(t, `{"type":"/problems/bad_request","title":"Bad request","status":400,"detail":"error",`+
+		`"code":"bad_request","instance":"/error","request_id":"abc-123"}`, rr.Body.String())
+
+	// Test for request with an invalid request ID.
+	// Should return a generated request ID.
+	req, _ = http.NewRequest("GET", "/error", nil)
+	req.Header.Set("X-Request-ID", "abc 123\n")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Len(t, rr.Header().Get("X-Request-ID"), 32)
+}
+
+// Tests for the JSON request log line.
+func RequestLog(t *testing.T) {
+	var buffer bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(logging.NewLogger(&buffer, slog.LevelInfo))
+	defer slog.SetDefault(defaultLogger)
+
+	r := handlers.InitRouter()
+	r.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest("GET", "/ok", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	var line map[string]any
+	err := json.Unmarshal(buffer.Bytes(), &line)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "abc-123", line["request_id"])
+	assert.Equal(t, "/ok", line["route"])
+	assert.Equal(t, float64(http.StatusNoContent), line["status"])
+	assert.NotContains(t, line, "problem")
+
+	// Test for a request aborted with a problem.
+	// Should log the code of the problem.
+	r.GET("/error", func(c *gin.Context) {
+		middlewares.AbortWithProblem(c, problems.New(http.StatusBadRequest, messages.CODE_BAD_REQUEST, "error"))
+	})
+
+	buffer.Reset()
+	req, _ = http.NewRequest("GET", "/error", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	line = map[string]any{}
+	err = json.Unmarshal(buffer.Bytes(), &line)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Equal(t, float64(http.StatusBadRequest), line["status"])
+	assert.Equal(t, messages.CODE_BAD_REQUEST, line["problem"])
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/metrics"
+	"govtech/pkg/services"
+)
+
+// Tests for "/metrics" endpoint.
+func TestMetrics(t *testing.T) {
+	r := handlers.InitRouter()
+	controllers.RegisterMetricsEndpoint(r)
+	r.GET("/api/ok", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	req, _ := http.NewRequest("GET", "/api/ok", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	// Should return status code 200 and the request counted by route.
+	req, _ = http.NewRequest("GET", "/metrics", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `http_requests_total{method="GET",route="/api/ok",status="204"} 1`)
+	assert.Contains(t, rr.Body.String(), `http_request_duration_seconds_bucket{method="GET",route="/api/ok"`)
+	// The counters are shared by the tests of the package, eg. the suspensions of TestHarness.
+	assert.Contains(t, rr.Body.String(), `# TYPE students_suspended_total counter`)
+}
+
+// Tests that only suspensions which change a student are counted.
+func TestMetricsSuspensions(t *testing.T) {
+	ctx := context.Background()
+	service := services.New(database.NewMemoryStore())
+	assert.NoError(t, service.RegisterStudents(ctx, "teacherken@gmail.com", []string{"studentjon@gmail.com"}))
+
+	before := testutil.ToFloat64(metrics.StudentsSuspended)
+
+	// Students already suspended, or who do not exist, are not counted.
+	for _, v := range []string{"studentjon@gmail.com", "studentjon@gmail.com", "studenthon@gmail.com"} {
+		assert.NoError(t, service.Suspend(ctx, v))
+	}
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.StudentsSuspended))
+}
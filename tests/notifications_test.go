@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/server/auth"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+)
+
+// Tests for the "/api/v2/students/{email}/notifications/stream" endpoint which do not need a database.
+// Clients authenticate as the student with a token, whose secret is testSecret.
+func TestNotificationStream(t *testing.T) {
+	// A closed database fails every query without a database server.
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	signer := auth.NewSigner(testSecret)
+	r := handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, db)
+	middlewares.RegisterAuthMiddleware(r, &middlewares.AuthConfig{Tokens: signer})
+	controllers.RegisterNotificationStreamEndpoint(r.Group(handlers.API_V2_PREFIX))
+
+	token := signer.Sign("s1@gmail.com", time.Now().Add(time.Minute))
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		lastEventID   string
+		status        int
+		code          string
+		fields        []string
+	}{
+		{
+			name:   "missing token",
+			path:   "/api/v2/students/s1@gmail.com/notifications/stream",
+			status: http.StatusUnauthorized,
+			code:   messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:          "token of another student",
+			path:          "/api/v2/students/s1@gmail.com/notifications/stream",
+			authorization: "Bearer " + signer.Sign("s2@gmail.com", time.Now().Add(time.Minute)),
+			lastEventID:   "0",
+			status:        http.StatusUnauthorized,
+			code:          messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:   "expired token in query",
+			path:   "/api/v2/students/s1@gmail.com/notifications/stream?token=" + signer.Sign("s1@gmail.com", time.Now().Add(-time.Minute)),
+			status: http.StatusUnauthorized,
+			code:   messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:   "invalid email",
+			path:   "/api/v2/students/student/notifications/stream",
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"email"},
+		},
+		{
+			name:          "invalid last event id",
+			path:          "/api/v2/students/s1@gmail.com/notifications/stream",
+			authorization: "Bearer " + token,
+			lastEventID:   "latest",
+			status:        http.StatusBadRequest,
+			code:          messages.CODE_VALIDATION_FAILED,
+			fields:        []string{controllers.HEADER_LAST_EVENT_ID},
+		},
+		{
+			name:          "database error on resume",
+			path:          "/api/v2/students/s1@gmail.com/notifications/stream",
+			authorization: "Bearer " + token,
+			lastEventID:   "5",
+			status:        http.StatusInternalServerError,
+			code:          messages.CODE_DATABASE_ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Accept", middlewares.MIME_EVENT_STREAM)
+			req.Header.Set("Authorization", tt.authorization)
+			if tt.lastEventID != "" {
+				req.Header.Set(controllers.HEADER_LAST_EVENT_ID, tt.lastEventID)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assertProblem(t, rr, tt.code, tt.fields...)
+		})
+	}
+
+	// Router without a secret of tokens.
+	// Should refuse every stream.
+	unauthenticated := handlers.InitRouter()
+	handlers.RegisterMiddlewares(unauthenticated, db)
+	middlewares.RegisterAuthMiddleware(unauthenticated, &middlewares.AuthConfig{})
+	controllers.RegisterNotificationStreamEndpoint(unauthenticated.Group(handlers.API_V2_PREFIX))
+	req, _ := http.NewRequest("GET", "/api/v2/students/s1@gmail.com/notifications/stream?token="+token, nil)
+	rr := httptest.NewRecorder()
+	unauthenticated.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Stream without Last-Event-ID, with the token in the query.
+	// Should stream until the client disconnects.
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ = http.NewRequestWithContext(ctx, "GET", "/api/v2/students/s1@gmail.com/notifications/stream?token="+token, nil)
+	req.Header.Set("Accept", middlewares.MIME_EVENT_STREAM)
+	rr = httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(rr, req)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not end after the client disconnected")
+	}
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, middlewares.MIME_EVENT_STREAM, rr.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "retry: "))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/server/gql"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/openapi"
+	"govtech/pkg/server/ws"
+)
+
+// Prefixes of routes which are not part of the API, eg. static files.
+var undocumentedRoutes = []string{"/docs/assets/"}
+
+func TestOpenAPI(t *testing.T) {
+	t.Run("valid spec", ValidSpec)
+	t.Run("documented routes", DocumentedRoutes)
+	t.Run("docs endpoints", DocsEndpoints)
+}
+
+// Returns the OpenAPI document served by the API.
+func loadSpec(t *testing.T) *openapi3.T {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return doc
+}
+
+// Tests that the OpenAPI document is valid.
+func ValidSpec(t *testing.T) {
+	_, err := openapi.Load()
+
+	assert.NoError(t, err)
+}
+
+// Tests that every registered route is in the OpenAPI document, and every documented route is registered.
+func DocumentedRoutes(t *testing.T) {
+	doc := loadSpec(t)
+
+	r := handlers.InitRouter()
+	handlers.RegisterEndpoints(r, nil, &middlewares.DeprecationConfig{})
+	controllers.RegisterMetricsEndpoint(r)
+	controllers.RegisterGraphQLEndpoint(r, &gql.Limits{})
+	controllers.RegisterTeacherEventsEndpoint(r, &ws.Config{})
+
+	// Path parameters are written as ":name" by gin and "{name}" by OpenAPI.
+	param := regexp.MustCompile(`[:*]([a-zA-Z0-9_]+)`)
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		if isUndocumented(route.Path) {
+			continue
+		}
+
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		registered[route.Method+" "+path] = true
+		item := doc.Paths.Find(path)
+		if item == nil {
+			t.Errorf("route %s %s is missing from the OpenAPI document", route.Method, route.Path)
+			continue
+		}
+
+		if item.GetOperation(route.Method) == nil {
+			t.Errorf("route %s %s is missing from the OpenAPI document", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item.Operations() {
+			if !registered[method+" "+path] {
+				t.Errorf("route %s %s is in the OpenAPI document but not registered", method, path)
+			}
+		}
+	}
+}
+
+func isUndocumented(path string) bool {
+	for _, v := range undocumentedRoutes {
+		if strings.HasPrefix(path, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tests for the "/openapi.json" and "/docs" endpoints.
+func DocsEndpoints(t *testing.T) {
+	r := handlers.InitRouter()
+	controllers.RegisterDocsEndpoints(r)
+
+	for _, path := range []string{"/openapi.json", "/docs", "/docs/assets/swagger-ui-bundle.js"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+	}
+}
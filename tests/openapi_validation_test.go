@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/openapi"
+	"govtech/pkg/utilities/messages"
+)
+
+func TestOpenAPIValidation(t *testing.T) {
+	t.Run("requests", ValidateRequests)
+	t.Run("responses", ValidateResponses)
+}
+
+// Returns a router with the endpoints behind the OpenAPI validation middleware,
+// and a closed database which fails every query.
+func openAPIRouter(t *testing.T, config *middlewares.OpenAPIConfig) *gin.Engine {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	db.Close()
+
+	r := handlers.InitRouter()
+	err = middlewares.RegisterOpenAPIMiddleware(r, doc, config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	handlers.RegisterMiddlewares(r, db)
+	handlers.RegisterEndpoints(r, db, &middlewares.DeprecationConfig{})
+
+	return r
+}
+
+// Tests for requests which do not match the OpenAPI document.
+func ValidateRequests(t *testing.T) {
+	r := openAPIRouter(t, &middlewares.OpenAPIConfig{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{
+			name:   "missing query parameter",
+			method: "GET",
+			path:   "/api/commonstudents",
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teacher"},
+		},
+		{
+			name:   "invalid query parameter",
+			method: "GET",
+			path:   "/api/commonstudents?teacher=t1@gmail.com&teacher=wrong.format",
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teacher[1]"},
+		},
+		{
+			name:   "malformed json",
+			method: "POST",
+			path:   "/api/suspend",
+			body:   `{"student": `,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_BAD_REQUEST,
+		},
+		{
+			name:   "missing field",
+			method: "POST",
+			path:   "/api/suspend",
+			body:   `{}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"student"},
+		},
+		{
+			name:   "invalid email",
+			method: "POST",
+			path:   "/api/suspend",
+			body:   `{"student": "wrong.format"}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"student"},
+		},
+		{
+			name:   "too long notification",
+			method: "POST",
+			path:   "/api/retrievefornotifications",
+			body:   `{"teacher": "t1@gmail.com", "notification": "` + string(bytes.Repeat([]byte("a"), 201)) + `"}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"notification"},
+		},
+		{
+			name:   "invalid email in list",
+			method: "POST",
+			path:   "/api/register",
+			body:   `{"teacher": "t1@gmail.com", "students": ["s1@gmail.com", "wrong.format"]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students[1]"},
+		},
+		{
+			name:   "too many emails",
+			method: "POST",
+			path:   "/api/register",
+			body:   `{"student": "s1@gmail.com", "teachers": ` + emailList("t", 51) + `}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teachers"},
+		},
+		{
+			name:   "wrong field type",
+			method: "POST",
+			path:   "/api/register",
+			body:   `{"teacher": "t1@gmail.com", "students": "s1@gmail.com"}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students"},
+		},
+		{
+			name:   "valid request",
+			method: "POST",
+			path:   "/api/suspend",
+			body:   `{"student": "s1@gmail.com"}`,
+			status: http.StatusInternalServerError,
+			code:   messages.CODE_DATABASE_ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assertProblem(t, rr, tt.code, tt.fields...)
+		})
+	}
+
+	// Test for a route which is not in the document.
+	// Should pass the request on to the router.
+	req, _ := http.NewRequest("GET", "/unknown", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// Tests for responses which do not match the OpenAPI document.
+func ValidateResponses(t *testing.T) {
+	var errs []error
+	config := &middlewares.OpenAPIConfig{
+		ValidateResponses: true,
+		OnResponseError: func(c *gin.Context, err error) {
+			errs = append(errs, err)
+		},
+	}
+	r := openAPIRouter(t, config)
+
+	// Test for documented responses of the endpoints.
+	// Should not report any error.
+	for _, path := range []string{"/healthz", "/readyz", "/api/commonstudents?teacher=t1@gmail.com"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+	}
+	req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(`{"teacher": "t1@gmail.com"}`))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Empty(t, errs)
+
+	// Test for a response which does not match the document.
+	// Should report the error.
+	r = gin.New()
+	middlewares.RegisterProblemMiddleware(r)
+	doc, _ := openapi.Load()
+	middlewares.RegisterOpenAPIMiddleware(r, doc, config)
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": 1})
+	})
+
+	req, _ = http.NewRequest("GET", "/healthz", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, errs, 1)
+}
@@ -3,14 +3,19 @@ package main
 import (
 	"testing"
 
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 
+	"govtech/pkg/models/request"
+	"govtech/pkg/utilities/messages"
 	"govtech/pkg/utilities/patterns"
 )
 
 func TestPattern(t *testing.T) {
 	t.Run("email regexp", EmailRegexp)
 	t.Run("notification field regexp", NotificationRegexp)
+	t.Run("email tag", EmailTag)
 }
 
 // Test for email regexp.
@@ -80,3 +85,28 @@ func NotificationRegexp(t *testing.T) {
 	assert.Equal(t, false, patterns.ValidatePattern(patterns.REGEX_PATTERN_NOTIFICATION, i_notification_4))
 	assert.Equal(t, false, patterns.ValidatePattern(patterns.REGEX_PATTERN_NOTIFICATION, i_notification_5))
 }
+
+// Test that the email tag of request bodies accepts the same emails as the email regexp.
+func EmailTag(t *testing.T) {
+	for email, valid := range map[string]bool{
+		"test@test.com":     true,
+		"T3st@test.org":     true,
+		"test@localhost":    false,
+		"test@gmailcom":     false,
+		"testgmail.com":     false,
+		"\"test\"@test.com": false,
+	} {
+		assert.Equal(t, valid, patterns.IsEmail(email), email)
+
+		err := binding.Validator.ValidateStruct(&request.SuspendRequest{Student: email})
+		if valid {
+			assert.NoError(t, err, email)
+			continue
+		}
+
+		var errs validator.ValidationErrors
+		if assert.ErrorAs(t, err, &errs, email) {
+			assert.Equal(t, messages.FIELD_CODE_EMAIL, errs[0].Tag())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/models/request"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/utilities/problems"
+)
+
+// Tests for problems returned for request body binding errors.
+func TestProblems(t *testing.T) {
+	r := gin.New()
+	middlewares.RegisterProblemMiddleware(r)
+	r.POST("/api/suspend", func(c *gin.Context) {
+		var body request.SuspendRequest
+		if err := c.ShouldBindJSON(&body); err != nil {
+			middlewares.AbortWithProblem(c, problems.FromBindError(err))
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/suspend", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Missing field.
+	// Should return a validation problem for the field.
+	rr := send(`{}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student")
+
+	// Wrong field type.
+	// Should return a validation problem for the field.
+	rr = send(`{"student": 1}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student")
+	assert.Contains(t, rr.Body.String(), `"detail":"Must be of type string."`)
+
+	// Malformed JSON.
+	// Should return a bad request problem without field errors.
+	rr = send(`{"student": `)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assertProblem(t, rr, messages.CODE_BAD_REQUEST)
+}
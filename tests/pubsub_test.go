@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/server/pubsub"
+)
+
+// Tests for publishing events to the subscribers of topics.
+func TestPubSub(t *testing.T) {
+	hub := pubsub.New[int](2)
+
+	a := hub.Subscribe("a")
+	b := hub.Subscribe("b")
+	defer b.Close()
+
+	assert.Equal(t, 1, hub.Subscribers("a"))
+
+	// Events are only received by the subscribers of their topic.
+	hub.Publish("a", 1)
+	hub.Publish("c", 2)
+
+	assert.Equal(t, 1, <-a.Events())
+	assert.Len(t, b.Events(), 0)
+
+	// Subscribers which fall behind are unsubscribed instead of blocking the publisher.
+	hub.Publish("a", 3)
+	hub.Publish("a", 4)
+	hub.Publish("a", 5)
+
+	assert.Equal(t, 0, hub.Subscribers("a"))
+	assert.Equal(t, 3, <-a.Events())
+	assert.Equal(t, 4, <-a.Events())
+	_, ok := <-a.Events()
+	assert.False(t, ok)
+
+	// Closing a subscription again has no effect.
+	a.Close()
+	assert.Equal(t, 1, hub.Subscribers("b"))
+}
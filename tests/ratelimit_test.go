@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/config"
+	"govtech/pkg/server/handlers/middlewares"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Run("token bucket", TokenBucket)
+	t.Run("rate limit middleware", RateLimitMiddleware)
+	t.Run("rate limit config", RateLimitConfig)
+}
+
+// Tests for the in-process token bucket store.
+func TokenBucket(t *testing.T) {
+	store := middlewares.NewMemoryRateLimitStore()
+	limit := middlewares.RateLimit{Rate: 1, Burst: 2}
+	now := time.Now()
+
+	// Bucket starts full, so burst requests are allowed.
+	result := store.Take("key", limit, now)
+	assert.Equal(t, true, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	result = store.Take("key", limit, now)
+	assert.Equal(t, true, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Bucket is empty, should wait 1 second for the next token.
+	result = store.Take("key", limit, now)
+	assert.Equal(t, false, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// Other keys have their own bucket.
+	result = store.Take("other", limit, now)
+	assert.Equal(t, true, result.Allowed)
+
+	// Bucket refills over time.
+	result = store.Take("key", limit, now.Add(time.Second))
+	assert.Equal(t, true, result.Allowed)
+
+	// Buckets of clients which pause are kept until they would have refilled.
+	// Should not regain the burst before then, although the store was swept.
+	limit = middlewares.RateLimit{Rate: 0.001, Burst: 1}
+	result = store.Take("slow", limit, now)
+	assert.Equal(t, true, result.Allowed)
+	result = store.Take("slow", limit, now.Add(11*time.Minute))
+	assert.Equal(t, false, result.Allowed)
+	result = store.Take("slow", limit, now.Add(time.Second*1000))
+	assert.Equal(t, true, result.Allowed)
+}
+
+// Tests for the rate limiting middleware.
+func RateLimitMiddleware(t *testing.T) {
+	config := middlewares.RateLimitConfig{
+		KeyBy:   middlewares.RATE_LIMIT_KEY_TEACHER,
+		Default: middlewares.RateLimit{Rate: 0, Burst: 0},
+		Routes: map[string]middlewares.RateLimit{
+			"/api/retrievefornotifications": {Rate: 0.001, Burst: 1},
+		},
+	}
+
+	r := gin.New()
+	middlewares.RegisterProblemMiddleware(r)
+	middlewares.RegisterRateLimitMiddleware(r, &config, middlewares.NewMemoryRateLimitStore())
+	retrieveForNotifications := func(c *gin.Context) {
+		// Body should still be readable by the handler.
+		var body struct {
+			Teacher string `json:"teacher"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	for _, v := range []string{"/api", "/api/v1", "/api/v2"} {
+		r.POST(v+"/retrievefornotifications", retrieveForNotifications)
+	}
+	r.POST("/api/suspend", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	send := func(path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// First request of a teacher is allowed.
+	// Should return status code 200 and rate limit headers.
+	rr := send("/api/retrievefornotifications", `{"teacher":"teacher1@gmail.com"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+	// Second request of the same teacher is limited.
+	// Should return status code 429 and Retry-After header.
+	rr = send("/api/retrievefornotifications", `{"teacher":"teacher1@gmail.com"}`)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1000", rr.Header().Get("Retry-After"))
+
+	// Requests of another teacher are not limited.
+	// Should return status code 200.
+	rr = send("/api/retrievefornotifications", `{"teacher":"teacher2@gmail.com"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Versions of a route share the limit of a teacher.
+	// Should return status code 429 for the versions after the first request.
+	rr = send("/api/v2/retrievefornotifications", `{"teacher":"teacher3@gmail.com"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	for _, v := range []string{"/api", "/api/v1", "/api/v2"} {
+		rr = send(v+"/retrievefornotifications", `{"teacher":"teacher3@gmail.com"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code, v)
+	}
+
+	// Teachers are the same client in any order or case.
+	assert.Equal(t, middlewares.RateLimitTeachers([]string{"B@gmail.com", "a@gmail.com"}),
+		middlewares.RateLimitTeachers([]string{"A@gmail.com", "b@gmail.com"}))
+
+	// Body above the limit of the teacher identifier.
+	// Should not be read to the end, and the client is identified by its IP instead.
+	rr = send("/api/retrievefornotifications", `{"teacher":"teacher1@gmail.com","notification":"`+
+		strings.Repeat("a", middlewares.RATE_LIMIT_MAX_BODY)+`"}`)
+	assert.NotEqual(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+	// Routes without limits are not limited.
+	// Should return status code 204 and no rate limit headers.
+	for i := 0; i < 3; i++ {
+		rr = send("/api/suspend", `{"student":"student@gmail.com"}`)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "", rr.Header().Get("RateLimit-Limit"))
+	}
+}
+
+// Tests for parsing of rate limit configuration.
+func RateLimitConfig(t *testing.T) {
+	routes, err := config.ParseRateLimitRoutes("/api/register=2:10, /api/suspend=0.5:1")
+	assert.Nil(t, err)
+	assert.Equal(t, config.RateLimit{Rate: 2, Burst: 10}, routes["/api/register"])
+	assert.Equal(t, config.RateLimit{Rate: 0.5, Burst: 1}, routes["/api/suspend"])
+
+	_, err = config.ParseRateLimitRoutes("/api/register")
+	assert.NotNil(t, err)
+
+	_, err = config.ParseRateLimit("ten:10")
+	assert.NotNil(t, err)
+
+	// The configuration of the middleware has the parsed limits.
+	rateLimitConfig := middlewares.NewRateLimitConfig(config.RateLimitConfig{
+		KeyBy:   config.RATE_LIMIT_KEY_IP,
+		Default: "10:20",
+		Routes:  "/api/register=2:10",
+	})
+	assert.Equal(t, middlewares.RateLimit{Rate: 10, Burst: 20}, rateLimitConfig.Default)
+	assert.Equal(t, middlewares.RateLimit{Rate: 2, Burst: 10}, rateLimitConfig.Routes["/api/register"])
+}
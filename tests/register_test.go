@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+)
+
+// Returns a JSON list of n distinct emails.
+func emailList(prefix string, n int) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		fmt.Fprintf(&buffer, `"%s%d@gmail.com"`, prefix, i)
+	}
+	buffer.WriteString("]")
+
+	return buffer.String()
+}
+
+// Tests for the failure paths of the "/api/register" endpoint.
+func TestRegisterValidation(t *testing.T) {
+	// A closed database fails every query without a database server.
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	r := gin.New()
+	middlewares.RegisterProblemMiddleware(r)
+	handlers.RegisterMiddlewares(r, db)
+	r.POST("/api/register", controllers.Register)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{
+			name:   "malformed json",
+			body:   `{"teacher": `,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_BAD_REQUEST,
+		},
+		{
+			name:   "wrong field type",
+			body:   `{"teacher": "t1@gmail.com", "students": "s1@gmail.com"}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students"},
+		},
+		{
+			name:   "empty body",
+			body:   `{}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teacher", "students"},
+		},
+		{
+			name:   "missing students",
+			body:   `{"teacher": "t1@gmail.com"}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students"},
+		},
+		{
+			name:   "empty students",
+			body:   `{"teacher": "t1@gmail.com", "students": []}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students"},
+		},
+		{
+			name:   "missing teacher",
+			body:   `{"students": ["s1@gmail.com"]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teacher"},
+		},
+		{
+			name:   "missing teachers",
+			body:   `{"student": "s1@gmail.com"}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teachers"},
+		},
+		{
+			name:   "missing student",
+			body:   `{"teachers": ["t1@gmail.com"]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"student"},
+		},
+		{
+			name:   "both shapes",
+			body:   `{"teacher": "t1@gmail.com", "students": ["s1@gmail.com"], "student": "s2@gmail.com", "teachers": ["t2@gmail.com"]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"student", "teachers"},
+		},
+		{
+			name:   "invalid teacher email",
+			body:   `{"teacher": "wrong@format", "students": ["s1@gmail.com"]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teacher"},
+		},
+		{
+			name:   "invalid student emails",
+			body:   `{"teacher": "t1@gmail.com", "students": ["s1@gmail.com", "wrong.format", ""]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students[1]", "students[2]"},
+		},
+		{
+			name:   "invalid teachers email",
+			body:   `{"student": "s1@gmail.com", "teachers": ["t1@gmail.com", "wrongforma.t"]}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teachers[1]"},
+		},
+		{
+			name:   "too many students",
+			body:   `{"teacher": "t1@gmail.com", "students": ` + emailList("s", 51) + `}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"students"},
+		},
+		{
+			name:   "too many teachers",
+			body:   `{"student": "s1@gmail.com", "teachers": ` + emailList("t", 51) + `}`,
+			status: http.StatusUnprocessableEntity,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"teachers"},
+		},
+		{
+			name:   "database error",
+			body:   `{"teacher": "t1@gmail.com", "students": ` + emailList("s", 50) + `}`,
+			status: http.StatusInternalServerError,
+			code:   messages.CODE_DATABASE_ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/register", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assertProblem(t, rr, tt.code, tt.fields...)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/config"
+	database "govtech/pkg/server/databases"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/services"
+	"govtech/pkg/utilities/messages"
+)
+
+// Store whose lookups of common students block until their context is done.
+type blockingStore struct {
+	database.Store
+}
+
+func (s *blockingStore) CommonStudents(ctx context.Context, teachers []string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeout(t *testing.T) {
+	t.Run("middleware", TimeoutMiddleware)
+	t.Run("controller", TimeoutController)
+	t.Run("write timeout", TimeoutWrite)
+	t.Run("shutdown", TimeoutShutdown)
+	t.Run("transfer", TimeoutTransfer)
+}
+
+// Tests for per-route request deadlines.
+func TimeoutMiddleware(t *testing.T) {
+	timeoutConfig := middlewares.TimeoutConfig{
+		Default: time.Hour,
+		Routes: map[string]time.Duration{
+			"/api/slow":   time.Millisecond,
+			"/api/stream": time.Millisecond,
+		},
+	}
+
+	r := gin.New()
+	middlewares.RegisterTimeoutMiddleware(r, &timeoutConfig)
+
+	waitForDeadline := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			assert.ErrorIs(t, c.Request.Context().Err(), context.DeadlineExceeded)
+			c.Status(http.StatusGatewayTimeout)
+		case <-time.After(50 * time.Millisecond):
+			c.Status(http.StatusOK)
+		}
+	}
+	r.GET("/api/slow", waitForDeadline)
+	r.GET("/api/v2/slow", waitForDeadline)
+	r.GET("/api/default", waitForDeadline)
+	middlewares.RegisterStreamingRoute(r, "/api/stream", waitForDeadline)
+
+	// Route with a short deadline.
+	// Should be cancelled once the deadline is reached.
+	req, _ := http.NewRequest("GET", "/api/slow", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+
+	// Versioned route of a route with a short deadline.
+	// Should use the deadline of the unversioned route.
+	req, _ = http.NewRequest("GET", "/api/v2/slow", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+
+	// Route with the default deadline.
+	// Should not be cancelled.
+	req, _ = http.NewRequest("GET", "/api/default", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Streaming route with a short deadline.
+	// Should not be cancelled.
+	req, _ = http.NewRequest("GET", "/api/stream", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Request to a route with a short deadline, asking for a stream.
+	// Should be cancelled, as only streaming routes have no deadline.
+	req, _ = http.NewRequest("GET", "/api/slow", nil)
+	req.Header.Set("Accept", middlewares.MIME_EVENT_STREAM)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+
+	// Parsing of per-route deadlines.
+	routes, err := config.ParseRouteTimeouts("/api/register=5s, /api/suspend=250ms")
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, routes["/api/register"])
+	assert.Equal(t, 250*time.Millisecond, routes["/api/suspend"])
+
+	_, err = config.ParseRouteTimeouts("/api/register=soon")
+	assert.NotNil(t, err)
+}
+
+// Tests that controllers answer DB calls cut off by the deadline, and nothing to cancelled requests.
+func TimeoutController(t *testing.T) {
+	r := handlers.InitRouter()
+	middlewares.RegisterTimeoutMiddleware(r, &middlewares.TimeoutConfig{Default: 10 * time.Millisecond})
+	handlers.RegisterServiceMiddlewares(r, services.New(&blockingStore{Store: database.NewMemoryStore()}))
+	handlers.RegisterEndpoints(r, nil, &middlewares.DeprecationConfig{})
+
+	// Lookup which is still running at the deadline.
+	// Should return status code 504 with a problem.
+	req, _ := http.NewRequest("GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assertProblem(t, rr, messages.CODE_TIMEOUT)
+
+	// Lookup of a client which has disconnected before the deadline.
+	// Should write no response.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ = http.NewRequestWithContext(ctx, "GET", "/api/v2/commonstudents?teacher=teacherken@gmail.com", nil)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Empty(t, rr.Body.String())
+	assert.Empty(t, rr.Header().Get("Content-Type"))
+}
+
+// Tests that the write timeout of the server cuts off responses, except those of streaming routes.
+func TimeoutWrite(t *testing.T) {
+	r := gin.New()
+	writeLate := func(c *gin.Context) {
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "late")
+	}
+	r.GET("/api/slow", writeLate)
+	middlewares.RegisterStreamingRoute(r, "/api/stream", writeLate)
+
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = handlers.NewServer(r, &handlers.RouterConfig{WriteTimeout: 20 * time.Millisecond})
+	server.Start()
+	defer server.Close()
+
+	// Response written after the write timeout.
+	// Should be cut off.
+	_, err := http.Get(server.URL + "/api/slow")
+	assert.Error(t, err)
+
+	// Response of a streaming route written after the write timeout.
+	// Should be received.
+	resp, err := http.Get(server.URL + "/api/stream")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "late", string(body))
+	}
+}
+
+// Tests that streams end once the server shuts down, so that shutdown does not wait for them.
+func TimeoutShutdown(t *testing.T) {
+	r := gin.New()
+	middlewares.RegisterStreamingRoute(r, "/api/stream", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+		<-c.Request.Context().Done()
+	})
+
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = handlers.NewServer(r, &handlers.RouterConfig{})
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/stream")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	// Should return before the shutdown deadline, once the stream has ended.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, server.Config.Shutdown(ctx))
+}
+
+// Tests that transfer routes have the deadline of transfers, and are read until it instead of the read timeout.
+func TimeoutTransfer(t *testing.T) {
+	r := gin.New()
+	middlewares.RegisterTimeoutMiddleware(r, &middlewares.TimeoutConfig{Default: 20 * time.Millisecond, Transfer: time.Second})
+	readBody := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusRequestTimeout)
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	}
+	r.POST("/api/upload", readBody)
+	middlewares.RegisterTransferRoute(r, http.MethodPost, "/api/import", readBody)
+	middlewares.RegisterTransferRoute(r, http.MethodGet, "/api/export", readBody)
+
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = handlers.NewServer(r, &handlers.RouterConfig{ReadTimeout: 20 * time.Millisecond})
+	server.Start()
+	defer server.Close()
+
+	// Returns the response to a body sent in two parts, with a delay between them.
+	upload := func(path string) (*http.Response, error) {
+		body, writer := io.Pipe()
+		go func() {
+			writer.Write([]byte("first,"))
+			time.Sleep(100 * time.Millisecond)
+			writer.Write([]byte("second"))
+			writer.Close()
+		}()
+
+		return http.Post(server.URL+path, middlewares.MIME_CSV, body)
+	}
+
+	// Body sent for longer than the read timeout.
+	// Should fail to be read.
+	resp, err := upload("/api/upload")
+	if err == nil {
+		assert.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	// Body of a transfer route sent for longer than the read timeout and the default deadline.
+	// Should be read until the deadline of transfers.
+	resp, err = upload("/api/import")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "first,second", string(body))
+	}
+
+	// Deadlines of routes.
+	// Should be the deadline of transfers for transfer routes, unless set by route.
+	timeoutConfig := middlewares.TimeoutConfig{Default: time.Second, Transfer: time.Minute, Routes: map[string]time.Duration{"/api/export": time.Hour}}
+	assert.Equal(t, time.Second, timeoutConfig.RouteTimeout("/api/upload"))
+	assert.Equal(t, time.Minute, timeoutConfig.RouteTimeout("/api/import"))
+	assert.Equal(t, time.Hour, timeoutConfig.RouteTimeout("/api/export"))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+)
+
+// Tests for the versions of the API routes.
+func TestVersions(t *testing.T) {
+	r := handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, nil)
+	handlers.RegisterEndpoints(r, nil, &middlewares.DeprecationConfig{
+		Deprecation: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		Successor:   handlers.API_V2_PREFIX,
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		deprecated bool
+		successor  string
+	}{
+		{"unversioned", "/api/suspend", true, "/api/v2/suspend"},
+		{"v1", "/api/v1/suspend", true, "/api/v2/suspend"},
+		{"v2", "/api/v2/suspend", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Invalid requests are answered without reaching the DB.
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(`{}`))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assertProblem(t, rr, messages.CODE_VALIDATION_FAILED, "student")
+
+			if tt.deprecated {
+				assert.Equal(t, "@1767225600", rr.Header().Get("Deprecation"))
+				assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+				assert.Equal(t, `<`+tt.successor+`>; rel="successor-version"`, rr.Header().Get("Link"))
+			} else {
+				assert.Empty(t, rr.Header().Get("Deprecation"))
+				assert.Empty(t, rr.Header().Get("Sunset"))
+			}
+		})
+	}
+
+	// Endpoints added after v1 are served at the unversioned paths they were added at and at v2, but not at v1.
+	for _, v := range []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/students/:email/notifications/stream"},
+		{"POST", "/webhooks"},
+		{"GET", "/webhooks/:id/deliveries"},
+		{"POST", "/import/registrations"},
+		{"GET", "/export/teaches"},
+	} {
+		routes := map[string]bool{}
+		for _, route := range r.Routes() {
+			if route.Method == v.method {
+				routes[route.Path] = true
+			}
+		}
+
+		assert.True(t, routes[handlers.API_PREFIX+v.path], v.path)
+		assert.True(t, routes[handlers.API_V2_PREFIX+v.path], v.path)
+		assert.False(t, routes[handlers.API_V1_PREFIX+v.path], v.path)
+	}
+}
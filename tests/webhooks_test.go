@@ -0,0 +1,599 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/events"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/utilities/messages"
+	"govtech/pkg/webhooks"
+)
+
+// Store of webhooks and their deliveries kept in memory.
+type testWebhookStore struct {
+	mu         sync.Mutex
+	webhooks   []webhooks.Webhook
+	deliveries []webhooks.Delivery
+	attempts   []webhooks.Attempt
+}
+
+func (s *testWebhookStore) EnqueueDeliveries(ctx context.Context, event events.Event, payload []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, w := range s.webhooks {
+		if !w.Accepts(event.Type) || s.hasDelivery(w.ID, event.ID) {
+			continue
+		}
+		s.deliveries = append(s.deliveries, webhooks.Delivery{
+			ID:        int64(len(s.deliveries) + 1),
+			WebhookID: w.ID,
+			URL:       w.URL,
+			Secret:    w.Secret,
+			EventID:   event.ID,
+			EventType: event.Type,
+			Payload:   payload,
+			Status:    webhooks.STATUS_PENDING,
+		})
+		n++
+	}
+
+	return n, nil
+}
+
+func (s *testWebhookStore) hasDelivery(webhookID int64, eventID int64) bool {
+	for _, v := range s.deliveries {
+		if v.WebhookID == webhookID && v.EventID == eventID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *testWebhookStore) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Time, limit int) ([]webhooks.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed []webhooks.Delivery
+	for i, v := range s.deliveries {
+		if v.Status != webhooks.STATUS_PENDING || v.NextAttemptAt.After(now) || len(claimed) == limit {
+			continue
+		}
+		s.deliveries[i].NextAttemptAt = lease
+		claimed = append(claimed, v)
+	}
+
+	return claimed, nil
+}
+
+func (s *testWebhookStore) RecordAttempt(ctx context.Context, attempt webhooks.Attempt, status string, nextAttemptAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts = append(s.attempts, attempt)
+	for i, v := range s.deliveries {
+		if v.ID == attempt.DeliveryID {
+			s.deliveries[i].Attempts++
+			s.deliveries[i].Status = status
+			s.deliveries[i].NextAttemptAt = nextAttemptAt
+		}
+	}
+
+	return nil
+}
+
+// Returns the delivery with the given ID.
+func (s *testWebhookStore) delivery(id int64) webhooks.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deliveries[id-1]
+}
+
+// Receiver of webhook deliveries which checks their signatures.
+type testReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	statuses []int
+	received []events.Event
+	headers  []http.Header
+	invalid  int
+}
+
+// Responds with the next status, and 204 once there are none left.
+func (r *testReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	timestamp, _ := strconv.ParseInt(req.Header.Get(webhooks.HEADER_TIMESTAMP), 10, 64)
+	if !webhooks.Verify(r.secret, timestamp, body, req.Header.Get(webhooks.HEADER_SIGNATURE)) {
+		r.invalid++
+	}
+
+	var event events.Event
+	json.Unmarshal(body, &event)
+	r.received = append(r.received, event)
+	r.headers = append(r.headers, req.Header.Clone())
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// Tests for the deliveries to the webhooks registered with the API.
+func TestWebhooks(t *testing.T) {
+	t.Run("signatures", WebhookSignatures)
+	t.Run("deliveries", WebhookDeliveries)
+	t.Run("retries", WebhookRetries)
+	t.Run("filters", WebhookFilters)
+	t.Run("concurrency", WebhookConcurrency)
+	t.Run("addresses", WebhookAddresses)
+	t.Run("ping", WebhookPing)
+	t.Run("validation", WebhookValidation)
+}
+
+var webhooksConfig = webhooks.Config{
+	PollInterval:    time.Hour,
+	BatchSize:       10,
+	Concurrency:     4,
+	Lease:           time.Minute,
+	RetryBackoff:    time.Minute,
+	MaxRetryBackoff: 2 * time.Minute,
+	MaxAttempts:     4,
+}
+
+const testWebhookSecret = "0123456789abcdef"
+
+// Admin key of the routers serving the webhook endpoints in tests.
+const testAdminKey = "0123456789abcdef0123456789abcdef"
+
+// Returns a receiver and the store with a webhook of the receiver accepting the given types of events.
+func webhookReceiver(t *testing.T, eventTypes ...string) (*testReceiver, *testWebhookStore) {
+	receiver := &testReceiver{secret: testWebhookSecret}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	store := &testWebhookStore{webhooks: []webhooks.Webhook{
+		{ID: 1, URL: server.URL, Events: eventTypes, Secret: testWebhookSecret},
+	}}
+
+	return receiver, store
+}
+
+// Tests for signing and verifying deliveries.
+func WebhookSignatures(t *testing.T) {
+	body := []byte(`{"type":"student_registered"}`)
+	signature := webhooks.Sign(testWebhookSecret, 1700000000, body)
+
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	assert.True(t, webhooks.Verify(testWebhookSecret, 1700000000, body, signature))
+	assert.False(t, webhooks.Verify(testWebhookSecret, 1700000001, body, signature))
+	assert.False(t, webhooks.Verify(testWebhookSecret, 1700000000, []byte(`{}`), signature))
+	assert.False(t, webhooks.Verify("another secret", 1700000000, body, signature))
+}
+
+// Tests that events are delivered once per webhook, signed with its secret.
+func WebhookDeliveries(t *testing.T) {
+	receiver, store := webhookReceiver(t)
+	deliverer := webhooks.NewDeliverer(store, http.DefaultClient, webhooksConfig)
+	ctx := context.Background()
+
+	event := testEvent(7, events.TYPE_STUDENT_SUSPENDED)
+	assert.NoError(t, deliverer.Handle(ctx, event))
+	// Events delivered more than once by the dispatcher are enqueued once.
+	assert.NoError(t, deliverer.Handle(ctx, event))
+
+	n, err := deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, webhooks.STATUS_SUCCEEDED, store.delivery(1).Status)
+	if assert.Len(t, receiver.received, 1) {
+		assert.Equal(t, event.ID, receiver.received[0].ID)
+		assert.Equal(t, event.Students, receiver.received[0].Students)
+
+		header := receiver.headers[0]
+		assert.Equal(t, "7", header.Get(events.HEADER_EVENT_ID))
+		assert.Equal(t, events.TYPE_STUDENT_SUSPENDED, header.Get(events.HEADER_EVENT_TYPE))
+		assert.Equal(t, "1", header.Get(webhooks.HEADER_DELIVERY_ID))
+		assert.WithinDuration(t, time.Now(), time.Unix(mustParseInt(t, header.Get(webhooks.HEADER_TIMESTAMP)), 0), 5*time.Second)
+	}
+	assert.Equal(t, 0, receiver.invalid)
+
+	// Every attempt is recorded with the response of the webhook.
+	if assert.Len(t, store.attempts, 1) {
+		assert.True(t, store.attempts[0].Succeeded())
+		assert.Equal(t, http.StatusNoContent, store.attempts[0].ResponseStatus)
+	}
+
+	// Succeeded deliveries are not sent again.
+	n, err = deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+// Tests that failed deliveries are retried with backoff until the maximum number of attempts.
+func WebhookRetries(t *testing.T) {
+	receiver, store := webhookReceiver(t)
+	receiver.statuses = []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusInternalServerError}
+	deliverer := webhooks.NewDeliverer(store, http.DefaultClient, webhooksConfig)
+	ctx := context.Background()
+
+	assert.NoError(t, deliverer.Handle(ctx, testEvent(1, events.TYPE_STUDENT_REGISTERED)))
+
+	// Retries are due after the backoff, doubled up to the maximum.
+	backoffs := []time.Duration{time.Minute, 2 * time.Minute, 2 * time.Minute}
+	for i, v := range backoffs {
+		start := time.Now()
+		_, err := deliverer.Deliver(ctx)
+		assert.NoError(t, err)
+
+		delivery := store.delivery(1)
+		assert.Equal(t, webhooks.STATUS_PENDING, delivery.Status)
+		assert.Equal(t, i+1, delivery.Attempts)
+		assert.WithinDuration(t, start.Add(v), delivery.NextAttemptAt, 5*time.Second)
+
+		// Deliveries which are not due are not sent.
+		n, err := deliverer.Deliver(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		store.mu.Lock()
+		store.deliveries[0].NextAttemptAt = time.Time{}
+		store.mu.Unlock()
+	}
+
+	// The delivery fails after the maximum number of attempts.
+	_, err := deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, webhooks.STATUS_FAILED, store.delivery(1).Status)
+	assert.Len(t, receiver.received, 4)
+
+	if assert.Len(t, store.attempts, 4) {
+		assert.Equal(t, http.StatusBadGateway, store.attempts[1].ResponseStatus)
+		assert.Contains(t, store.attempts[1].Error, "502")
+	}
+
+	n, err := deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+// Tests that webhooks only receive the types of events they subscribed to.
+func WebhookFilters(t *testing.T) {
+	receiver, store := webhookReceiver(t, events.TYPE_NOTIFICATION_ISSUED)
+	deliverer := webhooks.NewDeliverer(store, http.DefaultClient, webhooksConfig)
+	ctx := context.Background()
+
+	assert.NoError(t, deliverer.Handle(ctx, testEvent(1, events.TYPE_STUDENT_REGISTERED)))
+	assert.NoError(t, deliverer.Handle(ctx, testEvent(2, events.TYPE_NOTIFICATION_ISSUED)))
+
+	n, err := deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	if assert.Len(t, receiver.received, 1) {
+		assert.Equal(t, events.TYPE_NOTIFICATION_ISSUED, receiver.received[0].Type)
+	}
+}
+
+// Tests that a slow webhook does not hold up the deliveries to the others, which are sent in order.
+func WebhookConcurrency(t *testing.T) {
+	receiver, store := webhookReceiver(t)
+
+	// The slow webhook responds once released.
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+
+	store.webhooks = append([]webhooks.Webhook{{ID: 2, URL: slow.URL, Secret: testWebhookSecret}}, store.webhooks...)
+	deliverer := webhooks.NewDeliverer(store, http.DefaultClient, webhooksConfig)
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, deliverer.Handle(ctx, testEvent(i, events.TYPE_STUDENT_REGISTERED)))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := deliverer.Deliver(ctx)
+		done <- err
+	}()
+
+	// The fast webhook receives its deliveries in order while the slow webhook has not responded.
+	assert.Eventually(t, func() bool {
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		return len(receiver.received) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	receiver.mu.Lock()
+	for i, v := range receiver.received {
+		assert.Equal(t, int64(i+1), v.ID)
+	}
+	receiver.mu.Unlock()
+
+	select {
+	case err := <-done:
+		t.Fatalf("deliveries to the slow webhook completed before it responded: %v", err)
+	default:
+	}
+
+	// The batch completes once the slow webhook responds.
+	release <- struct{}{}
+	release <- struct{}{}
+	release <- struct{}{}
+	assert.NoError(t, <-done)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, v := range store.deliveries {
+		assert.Equal(t, webhooks.STATUS_SUCCEEDED, v.Status, v.ID)
+	}
+}
+
+// Tests that webhooks at addresses which are not public, eg. loopback, link-local or private, are refused unless allowed.
+func WebhookAddresses(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.20.0.1", true},
+		{"224.0.0.1", false},
+		{"239.255.255.250", false},
+		{"ff02::1", false},
+		{"ff0e::1", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.public, webhooks.IsPublicAddress(netip.MustParseAddr(tt.ip)), tt.ip)
+	}
+
+	// The receiver is on the loopback address, which is checked once resolved.
+	receiver, store := webhookReceiver(t)
+	store.webhooks[0].URL = strings.Replace(store.webhooks[0].URL, "127.0.0.1", "localhost", 1)
+	ctx := context.Background()
+
+	deliverer := webhooks.NewDeliverer(store, webhooks.NewClient(time.Second, false), webhooksConfig)
+	assert.NoError(t, deliverer.Handle(ctx, testEvent(1, events.TYPE_STUDENT_REGISTERED)))
+	_, err := deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+
+	assert.Empty(t, receiver.received)
+	if assert.Len(t, store.attempts, 1) {
+		assert.Contains(t, store.attempts[0].Error, webhooks.ErrForbiddenAddress.Error())
+	}
+
+	// Private addresses are allowed by the configuration.
+	store.mu.Lock()
+	store.deliveries[0].NextAttemptAt = time.Time{}
+	store.mu.Unlock()
+
+	deliverer = webhooks.NewDeliverer(store, webhooks.NewClient(time.Second, true), webhooksConfig)
+	_, err = deliverer.Deliver(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, receiver.received, 1)
+	assert.Equal(t, webhooks.STATUS_SUCCEEDED, store.delivery(1).Status)
+}
+
+// Tests that pings are sent once and recorded.
+func WebhookPing(t *testing.T) {
+	receiver, store := webhookReceiver(t, events.TYPE_NOTIFICATION_ISSUED)
+	receiver.statuses = []int{http.StatusOK, http.StatusNotFound}
+	ctx := context.Background()
+
+	payload, err := webhooks.PingPayload()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ping := func(id int64) webhooks.Attempt {
+		store.deliveries = append(store.deliveries, webhooks.Delivery{
+			ID: id, WebhookID: 1, URL: store.webhooks[0].URL, Secret: testWebhookSecret,
+			EventType: webhooks.TYPE_PING, Payload: payload, Status: webhooks.STATUS_PENDING,
+		})
+		attempt, err := webhooks.Ping(ctx, store, http.DefaultClient, store.deliveries[id-1])
+		assert.NoError(t, err)
+		return attempt
+	}
+
+	// Pings are sent regardless of the types of events of the webhook.
+	attempt := ping(1)
+	assert.True(t, attempt.Succeeded())
+	assert.Equal(t, webhooks.STATUS_SUCCEEDED, store.delivery(1).Status)
+	if assert.Len(t, receiver.received, 1) {
+		assert.Equal(t, webhooks.TYPE_PING, receiver.received[0].Type)
+		assert.Empty(t, receiver.headers[0].Get(events.HEADER_EVENT_ID))
+	}
+	assert.Equal(t, 0, receiver.invalid)
+
+	// Failed pings are not retried.
+	attempt = ping(2)
+	assert.False(t, attempt.Succeeded())
+	assert.Equal(t, http.StatusNotFound, attempt.ResponseStatus)
+	assert.Equal(t, webhooks.STATUS_FAILED, store.delivery(2).Status)
+}
+
+// Tests that invalid requests to the "/api/v2/webhooks" endpoints are rejected before querying the DB.
+func WebhookValidation(t *testing.T) {
+	// A closed database fails every query without a database server.
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	r := handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, db)
+	middlewares.RegisterAuthMiddleware(r, &middlewares.AuthConfig{AdminKey: testAdminKey})
+	handlers.RegisterEndpoints(r, db, &middlewares.DeprecationConfig{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{
+			name:   "missing admin key",
+			method: "GET",
+			path:   "/api/v2/webhooks",
+			status: http.StatusUnauthorized,
+			code:   messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:   "wrong admin key",
+			method: "POST",
+			path:   "/api/webhooks",
+			key:    "Bearer " + strings.Repeat("0", len(testAdminKey)),
+			body:   `{"url": "https://example.com/events", "secret": "` + testWebhookSecret + `"}`,
+			status: http.StatusUnauthorized,
+			code:   messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:   "admin key of another scheme",
+			method: "GET",
+			path:   "/api/v2/webhooks/1/deliveries",
+			key:    "Basic " + testAdminKey,
+			status: http.StatusUnauthorized,
+			code:   messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:   "missing fields",
+			method: "POST",
+			path:   "/api/v2/webhooks",
+			body:   `{}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"url", "secret"},
+		},
+		{
+			name:   "url which is not http",
+			method: "POST",
+			path:   "/api/v2/webhooks",
+			body:   `{"url": "ftp://example.com/events", "secret": "` + testWebhookSecret + `"}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"url"},
+		},
+		{
+			name:   "unknown event type and short secret",
+			method: "POST",
+			path:   "/api/v2/webhooks",
+			body:   `{"url": "https://example.com/events", "events": ["student_deleted"], "secret": "secret"}`,
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"events[0]", "secret"},
+		},
+		{
+			name:   "invalid id",
+			method: "GET",
+			path:   "/api/v2/webhooks/first",
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"id"},
+		},
+		{
+			name:   "ping of invalid id",
+			method: "POST",
+			path:   "/api/v2/webhooks/0/ping",
+			status: http.StatusBadRequest,
+			code:   messages.CODE_VALIDATION_FAILED,
+			fields: []string{"id"},
+		},
+		{
+			name:   "database error",
+			method: "POST",
+			path:   "/api/v2/webhooks",
+			body:   `{"url": "https://example.com/events", "secret": "` + testWebhookSecret + `"}`,
+			status: http.StatusInternalServerError,
+			code:   messages.CODE_DATABASE_ERROR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			// Requests have the admin key unless refused for their key.
+			authorization := "Bearer " + testAdminKey
+			if tt.status == http.StatusUnauthorized {
+				authorization = tt.key
+			}
+			req.Header.Set("Authorization", authorization)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assertProblem(t, rr, tt.code, tt.fields...)
+			if tt.status == http.StatusUnauthorized {
+				assert.Equal(t, middlewares.AUTH_SCHEME_BEARER, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	// Router without an admin key.
+	// Should refuse every request, even without a key.
+	r = handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, db)
+	middlewares.RegisterAuthMiddleware(r, &middlewares.AuthConfig{})
+	handlers.RegisterEndpoints(r, db, &middlewares.DeprecationConfig{})
+	for _, key := range []string{"", "Bearer "} {
+		req, _ := http.NewRequest("GET", "/api/webhooks", nil)
+		req.Header.Set("Authorization", key)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func mustParseInt(t *testing.T, s string) int64 {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return v
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+
+	"govtech/pkg/controllers"
+	"govtech/pkg/events"
+	"govtech/pkg/models/response"
+	"govtech/pkg/server/auth"
+	"govtech/pkg/server/handlers"
+	"govtech/pkg/server/handlers/middlewares"
+	"govtech/pkg/server/pubsub"
+	"govtech/pkg/server/ws"
+	"govtech/pkg/utilities/messages"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// Source of teacher events published by the tests.
+type testSource struct {
+	hub           *pubsub.Hub[events.Event]
+	mu            sync.Mutex
+	subscriptions []*pubsub.Subscription[events.Event]
+}
+
+func (s *testSource) SubscribeTeacherEvents(teacher string) *pubsub.Subscription[events.Event] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription := s.hub.Subscribe(teacher)
+	s.subscriptions = append(s.subscriptions, subscription)
+
+	return subscription
+}
+
+// Tests for the "/ws/teachers" endpoint which do not need a database.
+func TestWebSocket(t *testing.T) {
+	t.Run("tokens", Tokens)
+	t.Run("handshake", Handshake)
+	t.Run("subscriptions", Subscriptions)
+	t.Run("heartbeat", Heartbeat)
+	t.Run("slow client", SlowClient)
+	t.Run("shutdown", WebSocketShutdown)
+}
+
+// Returns a server of teacher events from the source, and the URL of its WebSocket.
+func webSocketServer(t *testing.T, source ws.Source, config ws.Config) string {
+	_, url := webSocketShutdownServer(t, source, config)
+	return url
+}
+
+// Returns a server of teacher events from the source which is shut down like the server of the API, and the URL of its WebSocket.
+func webSocketShutdownServer(t *testing.T, source ws.Source, config ws.Config) (*httptest.Server, string) {
+	upgrader := websocket.Upgrader{}
+
+	r := gin.New()
+	middlewares.RegisterStreamingRoute(r, "/ws/teachers", func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		ws.Serve(c.Request.Context(), conn, source, messages.LANGUAGE_DEFAULT, &config)
+	})
+
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = handlers.NewServer(r, &handlers.RouterConfig{})
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/teachers"
+}
+
+// Returns a connection to the WebSocket at url, closed at the end of the test.
+func dialWebSocket(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+// Sends the message, written as is if it is bytes, and returns the next message of the server.
+func roundTrip(t *testing.T, conn *websocket.Conn, message any) response.TeacherMessage {
+	var err error
+	if data, ok := message.([]byte); ok {
+		err = conn.WriteMessage(websocket.TextMessage, data)
+	} else {
+		err = conn.WriteJSON(message)
+	}
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return readTeacherMessage(t, conn)
+}
+
+func readTeacherMessage(t *testing.T, conn *websocket.Conn) response.TeacherMessage {
+	var message response.TeacherMessage
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return message
+}
+
+func subscribeMessage(teacher string, token string) map[string]string {
+	return map[string]string{"type": "subscribe", "teacher": teacher, "token": token}
+}
+
+// Tests for signing and verifying the tokens of teachers.
+func Tokens(t *testing.T) {
+	signer := auth.NewSigner(testSecret)
+	now := time.Now()
+	token := signer.Sign("t1@gmail.com", now.Add(time.Minute))
+
+	assert.NoError(t, signer.Verify("t1@gmail.com", token, now))
+	assert.ErrorIs(t, signer.Verify("t2@gmail.com", token, now), auth.ErrInvalidToken)
+	assert.ErrorIs(t, signer.Verify("t1@gmail.com", token, now.Add(time.Hour)), auth.ErrExpiredToken)
+	assert.ErrorIs(t, signer.Verify("t1@gmail.com", "token", now), auth.ErrInvalidToken)
+	assert.ErrorIs(t, auth.NewSigner("another secret").Verify("t1@gmail.com", token, now), auth.ErrInvalidToken)
+}
+
+// Tests that requests which are not WebSocket handshakes are rejected.
+func Handshake(t *testing.T) {
+	// A closed database fails every query without a database server.
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	r := handlers.InitRouter()
+	handlers.RegisterMiddlewares(r, db)
+	controllers.RegisterTeacherEventsEndpoint(r, &ws.Config{Secret: testSecret, PingInterval: time.Second, WriteTimeout: time.Second, Buffer: 1})
+
+	req, _ := http.NewRequest("GET", "/ws/teachers", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUpgradeRequired, rr.Code)
+	assert.Equal(t, "websocket", rr.Header().Get("Upgrade"))
+	assertProblem(t, rr, messages.CODE_BAD_REQUEST)
+}
+
+// Tests for subscribing to the events of teachers.
+func Subscriptions(t *testing.T) {
+	source := &testSource{hub: pubsub.New[events.Event](8)}
+	url := webSocketServer(t, source, ws.Config{Secret: testSecret, PingInterval: time.Minute, WriteTimeout: time.Second, Buffer: 8})
+	conn := dialWebSocket(t, url)
+
+	signer := auth.NewSigner(testSecret)
+	token := signer.Sign("t1@gmail.com", time.Now().Add(time.Minute))
+
+	tests := []struct {
+		name    string
+		message any
+		code    string
+	}{
+		{
+			name:    "invalid json",
+			message: []byte("subscribe"),
+			code:    messages.CODE_BAD_REQUEST,
+		},
+		{
+			name:    "invalid teacher",
+			message: subscribeMessage("teacher", token),
+			code:    messages.CODE_VALIDATION_FAILED,
+		},
+		{
+			name:    "unknown type",
+			message: map[string]string{"type": "publish", "teacher": "t1@gmail.com"},
+			code:    messages.CODE_VALIDATION_FAILED,
+		},
+		{
+			name:    "token of another teacher",
+			message: subscribeMessage("t2@gmail.com", token),
+			code:    messages.CODE_UNAUTHORIZED,
+		},
+		{
+			name:    "expired token",
+			message: subscribeMessage("t1@gmail.com", signer.Sign("t1@gmail.com", time.Now().Add(-time.Minute))),
+			code:    messages.CODE_UNAUTHORIZED,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := roundTrip(t, conn, tt.message)
+
+			assert.Equal(t, response.MESSAGE_ERROR, message.Type)
+			if assert.NotNil(t, message.Problem) {
+				assert.Equal(t, tt.code, message.Problem.Code)
+			}
+		})
+	}
+
+	// Subscription with a token of the teacher.
+	// Should receive the events of the teacher only.
+	message := roundTrip(t, conn, subscribeMessage("t1@gmail.com", token))
+	assert.Equal(t, response.TeacherMessage{Type: response.MESSAGE_SUBSCRIBED, Teacher: "t1@gmail.com"}, message)
+
+	source.hub.Publish("t2@gmail.com", events.New(events.TYPE_STUDENT_SUSPENDED, []string{"t2@gmail.com"}, []string{"s2@gmail.com"}))
+	source.hub.Publish("t1@gmail.com", events.New(events.TYPE_STUDENT_REGISTERED, []string{"t1@gmail.com"}, []string{"s1@gmail.com"}))
+
+	message = readTeacherMessage(t, conn)
+	assert.Equal(t, events.TYPE_STUDENT_REGISTERED, message.Type)
+	assert.Equal(t, "t1@gmail.com", message.Teacher)
+	assert.Equal(t, []string{"s1@gmail.com"}, message.Students)
+	assert.NotNil(t, message.OccurredAt)
+
+	// Unsubscription.
+	// Should no longer be subscribed to the teacher.
+	message = roundTrip(t, conn, map[string]string{"type": "unsubscribe", "teacher": "t1@gmail.com"})
+	assert.Equal(t, response.MESSAGE_UNSUBSCRIBED, message.Type)
+	assert.Eventually(t, func() bool { return source.hub.Subscribers("t1@gmail.com") == 0 }, time.Second, 10*time.Millisecond)
+}
+
+// Tests that clients are pinged to keep idle connections open.
+func Heartbeat(t *testing.T) {
+	source := &testSource{hub: pubsub.New[events.Event](8)}
+	url := webSocketServer(t, source, ws.Config{Secret: testSecret, PingInterval: 20 * time.Millisecond, WriteTimeout: time.Second, Buffer: 8})
+	conn := dialWebSocket(t, url)
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// Control messages are handled while reading.
+	go conn.ReadMessage()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Fatal("client was not pinged")
+	}
+}
+
+// Tests that clients which fall behind their events are disconnected.
+func SlowClient(t *testing.T) {
+	source := &testSource{hub: pubsub.New[events.Event](8)}
+	url := webSocketServer(t, source, ws.Config{Secret: testSecret, PingInterval: time.Minute, WriteTimeout: time.Second, Buffer: 8})
+	conn := dialWebSocket(t, url)
+
+	token := auth.NewSigner(testSecret).Sign("t1@gmail.com", time.Now().Add(time.Minute))
+	message := roundTrip(t, conn, subscribeMessage("t1@gmail.com", token))
+	assert.Equal(t, response.MESSAGE_SUBSCRIBED, message.Type)
+
+	// The hub closes the subscriptions of subscribers which fall behind.
+	source.mu.Lock()
+	source.subscriptions[0].Close()
+	source.mu.Unlock()
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), err)
+}
+
+// Tests that clients are disconnected with a going away close message once the server shuts down.
+func WebSocketShutdown(t *testing.T) {
+	source := &testSource{hub: pubsub.New[events.Event](8)}
+	server, url := webSocketShutdownServer(t, source, ws.Config{Secret: testSecret, PingInterval: time.Minute, WriteTimeout: time.Second, Buffer: 8})
+	conn := dialWebSocket(t, url)
+
+	token := auth.NewSigner(testSecret).Sign("t1@gmail.com", time.Now().Add(time.Minute))
+	message := roundTrip(t, conn, subscribeMessage("t1@gmail.com", token))
+	assert.Equal(t, response.MESSAGE_SUBSCRIBED, message.Type)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, server.Config.Shutdown(ctx))
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}